@@ -0,0 +1,89 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+const appName = "drone-github-release"
+
+// completionFlag is handled before the flags get parsed, as the cli app
+// would otherwise require all settings for a release.
+var completionFlag = &cli.StringFlag{
+	Name:  "completion",
+	Usage: "print the shell completion script, either bash, zsh or fish",
+}
+
+// completionValue returns the shell requested by the completion flag.
+func completionValue(args []string) string {
+	for i, arg := range args {
+		if arg == "--completion" && i+1 < len(args) {
+			return args[i+1]
+		}
+
+		if strings.HasPrefix(arg, "--completion=") {
+			return strings.TrimPrefix(arg, "--completion=")
+		}
+	}
+
+	return ""
+}
+
+// completion generates a completion script for the flags of the app.
+func completion(shell string, flags []cli.Flag) (string, error) {
+	var names []string
+
+	for _, flag := range flags {
+		for _, name := range flag.Names() {
+			if len(name) == 1 {
+				names = append(names, "-"+name)
+			} else {
+				names = append(names, "--"+name)
+			}
+		}
+	}
+
+	sort.Strings(names)
+
+	switch shell {
+	case "bash":
+		return fmt.Sprintf("complete -o default -W %q %s\n", strings.Join(names, " "), appName), nil
+	case "zsh":
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "#compdef %s\n\n_arguments \\\n", appName)
+
+		for _, flag := range flags {
+			usage := ""
+
+			if f, ok := flag.(cli.DocGenerationFlag); ok {
+				usage = strings.NewReplacer("[", "(", "]", ")", "'", "").Replace(f.GetUsage())
+			}
+
+			for _, name := range flag.Names() {
+				if len(name) > 1 {
+					fmt.Fprintf(&sb, "  '--%s[%s]' \\\n", name, usage)
+				}
+			}
+		}
+
+		sb.WriteString("  '*:file:_files'\n")
+		return sb.String(), nil
+	case "fish":
+		app := &cli.App{
+			Name:  appName,
+			Flags: flags,
+		}
+
+		return app.ToFishCompletion()
+	}
+
+	return "", fmt.Errorf("unsupported shell %s", shell)
+}
@@ -0,0 +1,54 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/urfave/cli/v2"
+)
+
+func TestCompletionValue(t *testing.T) {
+	tests := map[string][]string{
+		"bash": {"--completion", "bash"},
+		"zsh":  {"--title", "v1", "--completion=zsh"},
+		"":     {"--title", "v1"},
+	}
+
+	for expected, args := range tests {
+		if actual := completionValue(args); actual != expected {
+			t.Errorf("Unexpected shell of %v (Got: %q, Expected: %q)", args, actual, expected)
+		}
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	flags := []cli.Flag{
+		&cli.StringFlag{Name: "title", Usage: "title of the release"},
+		&cli.BoolFlag{Name: "yes", Aliases: []string{"y"}},
+	}
+
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		script, err := completion(shell, flags)
+
+		if err != nil {
+			t.Fatalf("Unexpected error for %s: %s", shell, err)
+		}
+
+		if !strings.Contains(script, "title") {
+			t.Errorf("Unexpected %s completion without the title flag (Got: %s)", shell, script)
+		}
+	}
+
+	if script, _ := completion("bash", flags); !strings.Contains(script, `"--title --yes -y"`) {
+		t.Errorf("Unexpected bash completion (Got: %s)", script)
+	}
+
+	if _, err := completion("powershell", flags); err == nil {
+		t.Error("Expected an error for an unsupported shell")
+	}
+}
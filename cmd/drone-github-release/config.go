@@ -6,13 +6,18 @@
 package main
 
 import (
+	"fmt"
+	"os"
+	"time"
+
 	"github.com/drone-plugins/drone-github-release/plugin"
+	"github.com/drone-plugins/drone-plugin-lib/urfave"
 	"github.com/urfave/cli/v2"
 )
 
 // settingsFlags has the cli.Flags for the plugin.Settings.
 func settingsFlags(settings *plugin.Settings) []cli.Flag {
-	return []cli.Flag{
+	flags, err := loadSettings(settings, os.Args[1:], []cli.Flag{
 		&cli.StringFlag{
 			Name:        "github-url",
 			Usage:       "github url, defaults to current scm",
@@ -27,7 +32,7 @@ func settingsFlags(settings *plugin.Settings) []cli.Flag {
 		},
 		&cli.StringSliceFlag{
 			Name:        "files",
-			Usage:       "list of files to upload",
+			Usage:       "list of files to upload, supporting globs with ** for nested directories, !pattern to drop files matched before, @file to read a list of files and -:name=asset or fifo:name=asset to upload an asset from stdin or a named pipe, which cannot be combined with checksums, signing or encryption",
 			EnvVars:     []string{"PLUGIN_FILES", "GITHUB_RELEASE_FILES"},
 			Destination: &settings.Files,
 		},
@@ -40,13 +45,13 @@ func settingsFlags(settings *plugin.Settings) []cli.Flag {
 		},
 		&cli.StringSliceFlag{
 			Name:        "checksum",
-			Usage:       "generate specific checksums",
+			Usage:       "generate checksum files for the methods md5, sha1, sha256, sha512, blake2b, adler32 or crc32",
 			EnvVars:     []string{"PLUGIN_CHECKSUM", "GITHUB_RELEASE_CHECKSUM"},
 			Destination: &settings.Checksum,
 		},
 		&cli.StringFlag{
 			Name:        "checksum-file",
-			Usage:       "name used for checksum file. \"CHECKSUM\" is replaced with the chosen method",
+			Usage:       "name used for checksum file. \"CHECKSUM\" or \"{{method}}\" is replaced with the chosen method, \"{{project}}\", \"{{tag}}\" and \"{{version}}\" with the repo name and tag",
 			EnvVars:     []string{"PLUGIN_CHECKSUM_FILE"},
 			Value:       "CHECKSUMsum.txt",
 			Destination: &settings.ChecksumFile,
@@ -57,6 +62,25 @@ func settingsFlags(settings *plugin.Settings) []cli.Flag {
 			EnvVars:     []string{"PLUGIN_CHECKSUM_FLATTEN"},
 			Destination: &settings.ChecksumFlatten,
 		},
+		&cli.BoolFlag{
+			Name:        "checksum-sidecar",
+			Usage:       "upload a <name>.sha256 file next to every file",
+			EnvVars:     []string{"PLUGIN_CHECKSUM_SIDECAR", "GITHUB_RELEASE_CHECKSUM_SIDECAR"},
+			Destination: &settings.ChecksumSidecar,
+		},
+		&cli.StringFlag{
+			Name:        "checksum-combined",
+			Usage:       "name of a single sha256 checksum file listing all assets, uploaded after them (example: checksums.txt)",
+			EnvVars:     []string{"PLUGIN_CHECKSUM_COMBINED", "GITHUB_RELEASE_CHECKSUM_COMBINED"},
+			Destination: &settings.ChecksumCombined,
+		},
+		&cli.StringFlag{
+			Name:        "checksum-format",
+			Value:       "gnu",
+			Usage:       "format of the checksum files, either gnu (<hash>  <file>) or bsd (SHA256 (<file>) = <hash>)",
+			EnvVars:     []string{"PLUGIN_CHECKSUM_FORMAT", "GITHUB_RELEASE_CHECKSUM_FORMAT"},
+			Destination: &settings.ChecksumFormat,
+		},
 		&cli.BoolFlag{
 			Name:        "draft",
 			Usage:       "create a draft release",
@@ -71,13 +95,13 @@ func settingsFlags(settings *plugin.Settings) []cli.Flag {
 		},
 		&cli.StringFlag{
 			Name:        "base-url",
-			Usage:       "api url, needs to be changed for ghe",
+			Usage:       "api url of github enterprise server, overrides the url derived from github-url",
 			EnvVars:     []string{"PLUGIN_BASE_URL", "GITHUB_RELEASE_BASE_URL"},
 			Destination: &settings.BaseURL,
 		},
 		&cli.StringFlag{
 			Name:        "upload-url",
-			Usage:       "upload url, needs to be changed for ghe",
+			Usage:       "upload url of github enterprise server, derived from base-url if that is set",
 			EnvVars:     []string{"PLUGIN_UPLOAD_URL", "GITHUB_RELEASE_UPLOAD_URL"},
 			Destination: &settings.UploadURL,
 		},
@@ -99,5 +123,970 @@ func settingsFlags(settings *plugin.Settings) []cli.Flag {
 			EnvVars:     []string{"PLUGIN_OVERWRITE", "GITHUB_RELEASE_OVERWRIDE"},
 			Destination: &settings.Overwrite,
 		},
+		&cli.StringFlag{
+			Name:        "action",
+			Value:       "release",
+			Usage:       "action to perform, either release, list or compare",
+			EnvVars:     []string{"PLUGIN_ACTION", "GITHUB_RELEASE_ACTION"},
+			Destination: &settings.Action,
+		},
+		&cli.StringFlag{
+			Name:        "result-file",
+			Usage:       "file to write the action result to, defaults to stdout",
+			EnvVars:     []string{"PLUGIN_RESULT_FILE", "GITHUB_RELEASE_RESULT_FILE"},
+			Destination: &settings.ResultFile,
+		},
+		&cli.StringFlag{
+			Name:        "list-draft",
+			Usage:       "only list releases with the given draft state (true or false)",
+			EnvVars:     []string{"PLUGIN_LIST_DRAFT"},
+			Destination: &settings.ListDraft,
+		},
+		&cli.StringFlag{
+			Name:        "list-prerelease",
+			Usage:       "only list releases with the given prerelease state (true or false)",
+			EnvVars:     []string{"PLUGIN_LIST_PRERELEASE"},
+			Destination: &settings.ListPrerelease,
+		},
+		&cli.StringFlag{
+			Name:        "list-tag-pattern",
+			Usage:       "only list releases whose tag matches the given glob pattern",
+			EnvVars:     []string{"PLUGIN_LIST_TAG_PATTERN"},
+			Destination: &settings.ListTagPattern,
+		},
+		&cli.StringFlag{
+			Name:        "list-since",
+			Usage:       "only list releases created at or after the given date (RFC 3339 or YYYY-MM-DD)",
+			EnvVars:     []string{"PLUGIN_LIST_SINCE"},
+			Destination: &settings.ListSince,
+		},
+		&cli.StringFlag{
+			Name:        "compare-base",
+			Usage:       "base tag for the compare action",
+			EnvVars:     []string{"PLUGIN_COMPARE_BASE"},
+			Destination: &settings.CompareBase,
+		},
+		&cli.StringFlag{
+			Name:        "compare-head",
+			Usage:       "head tag for the compare action, defaults to the current tag",
+			EnvVars:     []string{"PLUGIN_COMPARE_HEAD"},
+			Destination: &settings.CompareHead,
+		},
+		&cli.BoolFlag{
+			Name:        "consolidate-drafts",
+			Usage:       "merge duplicate drafts for the tag into one before releasing",
+			EnvVars:     []string{"PLUGIN_CONSOLIDATE_DRAFTS", "GITHUB_RELEASE_CONSOLIDATE_DRAFTS"},
+			Destination: &settings.ConsolidateDrafts,
+		},
+		&cli.BoolFlag{
+			Name:        "import-prereleases",
+			Usage:       "add the notes of all prereleases since the last stable release to a stable release",
+			EnvVars:     []string{"PLUGIN_IMPORT_PRERELEASES", "GITHUB_RELEASE_IMPORT_PRERELEASES"},
+			Destination: &settings.ImportPrereleases,
+		},
+		&cli.BoolFlag{
+			Name:        "promote-assets",
+			Usage:       "copy the assets of the final prerelease to a stable release",
+			EnvVars:     []string{"PLUGIN_PROMOTE_ASSETS", "GITHUB_RELEASE_PROMOTE_ASSETS"},
+			Destination: &settings.PromoteAssets,
+		},
+		&cli.StringFlag{
+			Name:        "bundles",
+			Usage:       "json list of archives to build and upload, each with a name, file globs and a format (tar.gz, tar.zst or zip, defaults to zip for windows and tar.gz otherwise)",
+			EnvVars:     []string{"PLUGIN_BUNDLES", "GITHUB_RELEASE_BUNDLES"},
+			Destination: &settings.Bundles,
+		},
+		&cli.StringSliceFlag{
+			Name:        "bundle-includes",
+			Usage:       "globs of files added to every bundle, e.g. license or readme files",
+			EnvVars:     []string{"PLUGIN_BUNDLE_INCLUDES", "GITHUB_RELEASE_BUNDLE_INCLUDES"},
+			Value:       cli.NewStringSlice("LICENSE*", "NOTICE*", "README*"),
+			Destination: &settings.BundleIncludes,
+		},
+		&cli.StringFlag{
+			Name:        "bundle-permissions",
+			Value:       "preserve",
+			Usage:       "file mode policy for bundle entries, either preserve, normalize or executable",
+			EnvVars:     []string{"PLUGIN_BUNDLE_PERMISSIONS", "GITHUB_RELEASE_BUNDLE_PERMISSIONS"},
+			Destination: &settings.BundlePermissions,
+		},
+		&cli.BoolFlag{
+			Name:        "bundle-reproducible",
+			Usage:       "create byte-reproducible bundles with sorted entries and fixed timestamps and owners",
+			EnvVars:     []string{"PLUGIN_BUNDLE_REPRODUCIBLE", "GITHUB_RELEASE_BUNDLE_REPRODUCIBLE"},
+			Destination: &settings.BundleReproducible,
+		},
+		&cli.Int64Flag{
+			Name:        "bundle-mtime",
+			Value:       315532800,
+			Usage:       "unix timestamp used for all entries of reproducible bundles",
+			EnvVars:     []string{"PLUGIN_BUNDLE_MTIME", "SOURCE_DATE_EPOCH"},
+			Destination: &settings.BundleModTime,
+		},
+		&cli.StringFlag{
+			Name:        "bundle-build-info",
+			Usage:       "name of a json file with commit, tag, build url and go version of the bundled binaries added to the root of every bundle (example: BUILD_INFO.json)",
+			EnvVars:     []string{"PLUGIN_BUNDLE_BUILD_INFO", "GITHUB_RELEASE_BUNDLE_BUILD_INFO"},
+			Destination: &settings.BundleBuildInfo,
+		},
+		&cli.StringFlag{
+			Name:        "bundle-go-version",
+			Usage:       "go version written to the bundle build info, read from the bundled go binaries by default",
+			EnvVars:     []string{"PLUGIN_BUNDLE_GO_VERSION", "GITHUB_RELEASE_BUNDLE_GO_VERSION"},
+			Destination: &settings.BundleGoVersion,
+		},
+		&cli.BoolFlag{
+			Name:        "fips",
+			Usage:       "restrict checksums to fips approved hashing methods and refuse signing and encryption with other algorithms",
+			EnvVars:     []string{"PLUGIN_FIPS", "GITHUB_RELEASE_FIPS"},
+			Destination: &settings.FIPS,
+		},
+		&cli.BoolFlag{
+			Name:        "preflight",
+			Usage:       "check that the api and upload endpoints are reachable before modifying anything",
+			EnvVars:     []string{"PLUGIN_PREFLIGHT", "GITHUB_RELEASE_PREFLIGHT"},
+			Destination: &settings.Preflight,
+		},
+		&cli.StringFlag{
+			Name:        "ip-version",
+			Usage:       "force connections to use ip version 4 or 6",
+			EnvVars:     []string{"PLUGIN_IP_VERSION", "GITHUB_RELEASE_IP_VERSION"},
+			Destination: &settings.IPVersion,
+		},
+		&cli.StringSliceFlag{
+			Name:        "host-overrides",
+			Usage:       "pin host names to ip addresses, e.g. github.example.com=10.0.0.1",
+			EnvVars:     []string{"PLUGIN_HOST_OVERRIDES", "GITHUB_RELEASE_HOST_OVERRIDES"},
+			Destination: &settings.HostOverrides,
+		},
+		&cli.IntFlag{
+			Name:        "max-idle-conns",
+			Usage:       "maximum number of idle connections kept open",
+			EnvVars:     []string{"PLUGIN_MAX_IDLE_CONNS", "GITHUB_RELEASE_MAX_IDLE_CONNS"},
+			Destination: &settings.MaxIdleConns,
+		},
+		&cli.IntFlag{
+			Name:        "max-idle-conns-per-host",
+			Value:       10,
+			Usage:       "maximum number of idle connections kept open per host",
+			EnvVars:     []string{"PLUGIN_MAX_IDLE_CONNS_PER_HOST", "GITHUB_RELEASE_MAX_IDLE_CONNS_PER_HOST"},
+			Destination: &settings.MaxIdleConnsPerHost,
+		},
+		&cli.BoolFlag{
+			Name:        "disable-compression",
+			Usage:       "disable transparent compression, useful for already compressed assets",
+			EnvVars:     []string{"PLUGIN_DISABLE_COMPRESSION", "GITHUB_RELEASE_DISABLE_COMPRESSION"},
+			Destination: &settings.DisableCompression,
+		},
+		&cli.BoolFlag{
+			Name:        "dedup-assets",
+			Usage:       "upload files with identical content only once and list the aliases in the notes",
+			EnvVars:     []string{"PLUGIN_DEDUP_ASSETS", "GITHUB_RELEASE_DEDUP_ASSETS"},
+			Destination: &settings.DedupAssets,
+		},
+		&cli.BoolFlag{
+			Name:        "metadata",
+			Usage:       "store build metadata as a hidden comment in the release body",
+			EnvVars:     []string{"PLUGIN_METADATA", "GITHUB_RELEASE_METADATA"},
+			Destination: &settings.Metadata,
+		},
+		&cli.StringSliceFlag{
+			Name:        "metadata-values",
+			Usage:       "additional metadata stored in the release body, e.g. channel=stable",
+			EnvVars:     []string{"PLUGIN_METADATA_VALUES", "GITHUB_RELEASE_METADATA_VALUES"},
+			Destination: &settings.MetadataValues,
+		},
+		&cli.BoolFlag{
+			Name:        "only-manage-own",
+			Usage:       "refuse to modify releases not created by this plugin",
+			EnvVars:     []string{"PLUGIN_ONLY_MANAGE_OWN", "GITHUB_RELEASE_ONLY_MANAGE_OWN"},
+			Destination: &settings.OnlyManageOwn,
+		},
+		&cli.BoolFlag{
+			Name:        "force",
+			Usage:       "force modifications which are refused by default",
+			EnvVars:     []string{"PLUGIN_FORCE", "GITHUB_RELEASE_FORCE"},
+			Destination: &settings.Force,
+		},
+		&cli.BoolFlag{
+			Name:        "dry-run",
+			Usage:       "only write the planned changes as json to the result file",
+			EnvVars:     []string{"PLUGIN_DRY_RUN", "GITHUB_RELEASE_DRY_RUN"},
+			Destination: &settings.DryRun,
+		},
+		&cli.BoolFlag{
+			Name:        "resume",
+			Usage:       "stage the release as draft until all files are uploaded and resume interrupted drafts on retries",
+			EnvVars:     []string{"PLUGIN_RESUME", "GITHUB_RELEASE_RESUME"},
+			Destination: &settings.Resume,
+		},
+		&cli.IntFlag{
+			Name:        "required-approvals",
+			Usage:       "number of approvals the pull request of the commit requires before publishing",
+			EnvVars:     []string{"PLUGIN_REQUIRED_APPROVALS", "GITHUB_RELEASE_REQUIRED_APPROVALS"},
+			Destination: &settings.RequiredApprovals,
+		},
+		&cli.StringFlag{
+			Name:        "changelog-file",
+			Usage:       "changelog file in the repo the release notes get prepended to",
+			EnvVars:     []string{"PLUGIN_CHANGELOG_FILE", "GITHUB_RELEASE_CHANGELOG_FILE"},
+			Destination: &settings.ChangelogFile,
+		},
+		&cli.StringFlag{
+			Name:        "changelog-branch",
+			Usage:       "branch the changelog gets committed to, defaults to the default branch",
+			EnvVars:     []string{"PLUGIN_CHANGELOG_BRANCH", "GITHUB_RELEASE_CHANGELOG_BRANCH"},
+			Destination: &settings.ChangelogBranch,
+		},
+		&cli.BoolFlag{
+			Name:        "changelog-pr",
+			Usage:       "open a pull request for the changelog update instead of pushing it",
+			EnvVars:     []string{"PLUGIN_CHANGELOG_PR", "GITHUB_RELEASE_CHANGELOG_PR"},
+			Destination: &settings.ChangelogPR,
+		},
+		&cli.StringFlag{
+			Name:        "version-file-repo",
+			Usage:       "repo (owner/name) containing a version file to update after publishing, e.g. a docs site",
+			EnvVars:     []string{"PLUGIN_VERSION_FILE_REPO", "GITHUB_RELEASE_VERSION_FILE_REPO"},
+			Destination: &settings.VersionFileRepo,
+		},
+		&cli.StringFlag{
+			Name:        "version-file-path",
+			Usage:       "path of the version file, json files are treated as a list of versions",
+			EnvVars:     []string{"PLUGIN_VERSION_FILE_PATH", "GITHUB_RELEASE_VERSION_FILE_PATH"},
+			Destination: &settings.VersionFilePath,
+		},
+		&cli.StringFlag{
+			Name:        "version-file-branch",
+			Usage:       "branch the version file gets committed to, defaults to the default branch",
+			EnvVars:     []string{"PLUGIN_VERSION_FILE_BRANCH", "GITHUB_RELEASE_VERSION_FILE_BRANCH"},
+			Destination: &settings.VersionFileBranch,
+		},
+		&cli.BoolFlag{
+			Name:        "version-file-pr",
+			Usage:       "open a pull request for the version file update instead of pushing it",
+			EnvVars:     []string{"PLUGIN_VERSION_FILE_PR", "GITHUB_RELEASE_VERSION_FILE_PR"},
+			Destination: &settings.VersionFilePR,
+		},
+		&cli.StringFlag{
+			Name:        "package-repo",
+			Usage:       "packaging repo (owner/name) the rendered package templates get committed to",
+			EnvVars:     []string{"PLUGIN_PACKAGE_REPO", "GITHUB_RELEASE_PACKAGE_REPO"},
+			Destination: &settings.PackageRepo,
+		},
+		&cli.StringFlag{
+			Name:        "package-branch",
+			Usage:       "branch the package files get committed to, defaults to the default branch",
+			EnvVars:     []string{"PLUGIN_PACKAGE_BRANCH", "GITHUB_RELEASE_PACKAGE_BRANCH"},
+			Destination: &settings.PackageBranch,
+		},
+		&cli.BoolFlag{
+			Name:        "package-pr",
+			Usage:       "open a pull request for the package files instead of pushing them",
+			EnvVars:     []string{"PLUGIN_PACKAGE_PR", "GITHUB_RELEASE_PACKAGE_PR"},
+			Destination: &settings.PackagePR,
+		},
+		&cli.StringSliceFlag{
+			Name:        "package-templates",
+			Usage:       "package templates to render, e.g. pkg/PKGBUILD.tmpl=PKGBUILD",
+			EnvVars:     []string{"PLUGIN_PACKAGE_TEMPLATES", "GITHUB_RELEASE_PACKAGE_TEMPLATES"},
+			Destination: &settings.PackageTemplates,
+		},
+		&cli.BoolFlag{
+			Name:        "artifacts-section",
+			Usage:       "append a section listing the released files and container images to the notes",
+			EnvVars:     []string{"PLUGIN_ARTIFACTS_SECTION", "GITHUB_RELEASE_ARTIFACTS_SECTION"},
+			Destination: &settings.ArtifactsSection,
+		},
+		&cli.StringFlag{
+			Name:        "image-manifest",
+			Usage:       "json file describing the container images built by a previous step",
+			EnvVars:     []string{"PLUGIN_IMAGE_MANIFEST", "GITHUB_RELEASE_IMAGE_MANIFEST"},
+			Destination: &settings.ImageManifest,
+		},
+		&cli.StringFlag{
+			Name:        "tag-sync",
+			Usage:       "sync the annotated tag message with the notes, either to-tag or from-tag, to-tag keeps lightweight tags and refuses signed tags",
+			EnvVars:     []string{"PLUGIN_TAG_SYNC", "GITHUB_RELEASE_TAG_SYNC"},
+			Destination: &settings.TagSync,
+		},
+		&cli.StringFlag{
+			Name:        "scan-command",
+			Usage:       "scanner command run for every file, e.g. clamscan --no-summary",
+			EnvVars:     []string{"PLUGIN_SCAN_COMMAND", "GITHUB_RELEASE_SCAN_COMMAND"},
+			Destination: &settings.ScanCommand,
+		},
+		&cli.StringFlag{
+			Name:        "scan-report",
+			Value:       "scan-report.txt",
+			Usage:       "name of the uploaded scan report",
+			EnvVars:     []string{"PLUGIN_SCAN_REPORT", "GITHUB_RELEASE_SCAN_REPORT"},
+			Destination: &settings.ScanReport,
+		},
+		&cli.StringSliceFlag{
+			Name:        "required-files",
+			Usage:       "file name patterns every uploaded archive has to contain, e.g. LICENSE*",
+			EnvVars:     []string{"PLUGIN_REQUIRED_FILES", "GITHUB_RELEASE_REQUIRED_FILES"},
+			Destination: &settings.RequiredFiles,
+		},
+		&cli.StringSliceFlag{
+			Name:        "required-notes",
+			Usage:       "texts the release notes have to contain, e.g. an export notice",
+			EnvVars:     []string{"PLUGIN_REQUIRED_NOTES", "GITHUB_RELEASE_REQUIRED_NOTES"},
+			Destination: &settings.RequiredNotes,
+		},
+		&cli.StringFlag{
+			Name:        "freeze-windows",
+			Usage:       "semicolon separated cron-like expressions during which releases are only staged as draft, e.g. \"* * 24-31 12 *\"",
+			EnvVars:     []string{"PLUGIN_FREEZE_WINDOWS", "GITHUB_RELEASE_FREEZE_WINDOWS"},
+			Destination: &settings.FreezeWindows,
+		},
+		&cli.StringFlag{
+			Name:        "freeze-timezone",
+			Value:       "UTC",
+			Usage:       "timezone the freeze windows are evaluated in",
+			EnvVars:     []string{"PLUGIN_FREEZE_TIMEZONE", "GITHUB_RELEASE_FREEZE_TIMEZONE"},
+			Destination: &settings.FreezeTimezone,
+		},
+		&cli.BoolFlag{
+			Name:        "canary",
+			Usage:       "publish a canary prerelease first and upgrade it to the full release on the next run",
+			EnvVars:     []string{"PLUGIN_CANARY", "GITHUB_RELEASE_CANARY"},
+			Destination: &settings.Canary,
+		},
+		&cli.StringSliceFlag{
+			Name:        "canary-files",
+			Usage:       "file name patterns uploaded with the canary prerelease",
+			EnvVars:     []string{"PLUGIN_CANARY_FILES", "GITHUB_RELEASE_CANARY_FILES"},
+			Destination: &settings.CanaryFiles,
+		},
+		&cli.StringFlag{
+			Name:        "canary-note",
+			Usage:       "file or string with notes for the canary prerelease",
+			EnvVars:     []string{"PLUGIN_CANARY_NOTE", "GITHUB_RELEASE_CANARY_NOTE"},
+			Destination: &settings.CanaryNote,
+		},
+		&cli.DurationFlag{
+			Name:        "canary-soak",
+			Usage:       "time the canary prerelease has to be published before it gets upgraded",
+			EnvVars:     []string{"PLUGIN_CANARY_SOAK", "GITHUB_RELEASE_CANARY_SOAK"},
+			Destination: &settings.CanarySoak,
+		},
+		&cli.StringFlag{
+			Name:        "canary-signal",
+			Usage:       "file which allows upgrading the canary prerelease once it exists",
+			EnvVars:     []string{"PLUGIN_CANARY_SIGNAL", "GITHUB_RELEASE_CANARY_SIGNAL"},
+			Destination: &settings.CanarySignal,
+		},
+		&cli.StringFlag{
+			Name:        "checksum-aggregate",
+			Usage:       "aggregate checksums across stages, either partial to upload the stage checksums or finalize to merge them",
+			EnvVars:     []string{"PLUGIN_CHECKSUM_AGGREGATE", "GITHUB_RELEASE_CHECKSUM_AGGREGATE"},
+			Destination: &settings.ChecksumAggregate,
+		},
+		&cli.StringFlag{
+			Name:        "checksum-aggregated",
+			Value:       "checksums.txt",
+			Usage:       "name of the merged checksums file written by the finalize stage",
+			EnvVars:     []string{"PLUGIN_CHECKSUM_AGGREGATED", "GITHUB_RELEASE_CHECKSUM_AGGREGATED"},
+			Destination: &settings.ChecksumAggregated,
+		},
+		&cli.StringFlag{
+			Name:        "checksum-sign-command",
+			Usage:       "command printing a detached signature for the merged checksums file",
+			EnvVars:     []string{"PLUGIN_CHECKSUM_SIGN_COMMAND", "GITHUB_RELEASE_CHECKSUM_SIGN_COMMAND"},
+			Destination: &settings.ChecksumSignCommand,
+		},
+		&cli.BoolFlag{
+			Name:        "smoke-test",
+			Usage:       "check that the download urls of all assets are available after publishing",
+			EnvVars:     []string{"PLUGIN_SMOKE_TEST", "GITHUB_RELEASE_SMOKE_TEST"},
+			Destination: &settings.SmokeTest,
+		},
+		&cli.IntFlag{
+			Name:        "smoke-test-retries",
+			Value:       3,
+			Usage:       "number of retries for assets failing the smoke test",
+			EnvVars:     []string{"PLUGIN_SMOKE_TEST_RETRIES", "GITHUB_RELEASE_SMOKE_TEST_RETRIES"},
+			Destination: &settings.SmokeTestRetries,
+		},
+		&cli.DurationFlag{
+			Name:        "smoke-test-delay",
+			Value:       10 * time.Second,
+			Usage:       "delay between smoke test retries",
+			EnvVars:     []string{"PLUGIN_SMOKE_TEST_DELAY", "GITHUB_RELEASE_SMOKE_TEST_DELAY"},
+			Destination: &settings.SmokeTestDelay,
+		},
+		&cli.BoolFlag{
+			Name:        "private-downloads",
+			Usage:       "add authenticated download instructions to the notes of releases in private repositories",
+			EnvVars:     []string{"PLUGIN_PRIVATE_DOWNLOADS", "GITHUB_RELEASE_PRIVATE_DOWNLOADS"},
+			Destination: &settings.PrivateDownloads,
+		},
+		&cli.BoolFlag{
+			Name:        "fork-check",
+			Usage:       "refuse to release on forks or for builds from a different source repository",
+			EnvVars:     []string{"PLUGIN_FORK_CHECK", "GITHUB_RELEASE_FORK_CHECK"},
+			Destination: &settings.ForkCheck,
+		},
+		&cli.StringFlag{
+			Name:        "source-repo",
+			Usage:       "full name of the repository the build was triggered from",
+			EnvVars:     []string{"PLUGIN_SOURCE_REPO", "DRONE_SOURCE_REPO"},
+			Destination: &settings.SourceRepo,
+		},
+		&cli.BoolFlag{
+			Name:        "secret-scan",
+			Usage:       "fail if the title, notes, metadata or text assets contain possible secrets",
+			EnvVars:     []string{"PLUGIN_SECRET_SCAN", "GITHUB_RELEASE_SECRET_SCAN"},
+			Destination: &settings.SecretScan,
+		},
+		&cli.StringFlag{
+			Name:        "max-total-size",
+			Usage:       "budget for the combined size of all assets, like 500MB",
+			EnvVars:     []string{"PLUGIN_MAX_TOTAL_SIZE", "GITHUB_RELEASE_MAX_TOTAL_SIZE"},
+			Destination: &settings.MaxTotalSize,
+		},
+		&cli.StringFlag{
+			Name:        "size-budget",
+			Value:       "fail",
+			Usage:       "what to do if the size budget is exceeded, either fail or warn",
+			EnvVars:     []string{"PLUGIN_SIZE_BUDGET", "GITHUB_RELEASE_SIZE_BUDGET"},
+			Destination: &settings.SizeBudget,
+		},
+		&cli.Float64Flag{
+			Name:        "size-drift-threshold",
+			Usage:       "warn if an asset size changed by more than this percentage compared to the latest release",
+			EnvVars:     []string{"PLUGIN_SIZE_DRIFT_THRESHOLD", "GITHUB_RELEASE_SIZE_DRIFT_THRESHOLD"},
+			Destination: &settings.SizeDriftThreshold,
+		},
+		&cli.BoolFlag{
+			Name:        "yes",
+			Aliases:     []string{"y"},
+			Usage:       "skip the confirmation prompt when running from a terminal",
+			EnvVars:     []string{"PLUGIN_YES", "GITHUB_RELEASE_YES"},
+			Destination: &settings.Yes,
+		},
+		&cli.BoolFlag{
+			Name:        "ci",
+			Usage:       "run without prompts, set within ci",
+			EnvVars:     []string{"PLUGIN_CI", "CI", "DRONE"},
+			Destination: &settings.CI,
+		},
+		&cli.StringFlag{
+			Name:        "template-preset",
+			Usage:       "built-in title and note templates, either go-cli, library or desktop-app",
+			EnvVars:     []string{"PLUGIN_TEMPLATE_PRESET", "GITHUB_RELEASE_TEMPLATE_PRESET"},
+			Destination: &settings.TemplatePreset,
+		},
+		&cli.StringFlag{
+			Name:        "workdir",
+			Usage:       "directory the files and other relative paths are resolved from",
+			EnvVars:     []string{"PLUGIN_WORKDIR", "GITHUB_RELEASE_WORKDIR"},
+			Destination: &settings.WorkDir,
+		},
+		&cli.StringFlag{
+			Name:        "temp-dir",
+			Usage:       "directory for bundles, downloads and other temporary files, defaults to the system temp dir",
+			EnvVars:     []string{"PLUGIN_TEMP_DIR", "GITHUB_RELEASE_TEMP_DIR"},
+			Destination: &settings.TempDir,
+		},
+		&cli.BoolFlag{
+			Name:        "keep-temp-dir",
+			Usage:       "keep the temporary files of the run for debugging",
+			EnvVars:     []string{"PLUGIN_KEEP_TEMP_DIR", "GITHUB_RELEASE_KEEP_TEMP_DIR"},
+			Destination: &settings.KeepTempDir,
+		},
+		&cli.StringFlag{
+			Name:        "verify-files",
+			Usage:       "checksums file from the build the files have to match before uploading",
+			EnvVars:     []string{"PLUGIN_VERIFY_FILES", "GITHUB_RELEASE_VERIFY_FILES"},
+			Destination: &settings.VerifyFiles,
+		},
+		&cli.StringFlag{
+			Name:        "verify-files-method",
+			Usage:       "hashing method of the verify files manifest, guessed from the digest length by default",
+			EnvVars:     []string{"PLUGIN_VERIFY_FILES_METHOD", "GITHUB_RELEASE_VERIFY_FILES_METHOD"},
+			Destination: &settings.VerifyFilesMethod,
+		},
+		&cli.BoolFlag{
+			Name:        "require-checks",
+			Usage:       "wait for all commit statuses and check runs to succeed before publishing",
+			EnvVars:     []string{"PLUGIN_REQUIRE_CHECKS", "GITHUB_RELEASE_REQUIRE_CHECKS"},
+			Destination: &settings.RequireChecks,
+		},
+		&cli.StringSliceFlag{
+			Name:        "checks-ignore",
+			Value:       cli.NewStringSlice("continuous-integration/drone/*"),
+			Usage:       "patterns of statuses and check runs to ignore, like the running build",
+			EnvVars:     []string{"PLUGIN_CHECKS_IGNORE", "GITHUB_RELEASE_CHECKS_IGNORE"},
+			Destination: &settings.ChecksIgnore,
+		},
+		&cli.DurationFlag{
+			Name:        "checks-timeout",
+			Value:       10 * time.Minute,
+			Usage:       "maximum time to wait for pending checks",
+			EnvVars:     []string{"PLUGIN_CHECKS_TIMEOUT", "GITHUB_RELEASE_CHECKS_TIMEOUT"},
+			Destination: &settings.ChecksTimeout,
+		},
+		&cli.DurationFlag{
+			Name:        "checks-interval",
+			Value:       30 * time.Second,
+			Usage:       "interval between polling pending checks",
+			EnvVars:     []string{"PLUGIN_CHECKS_INTERVAL", "GITHUB_RELEASE_CHECKS_INTERVAL"},
+			Destination: &settings.ChecksInterval,
+		},
+		&cli.BoolFlag{
+			Name:        "comment-notes",
+			Usage:       "comment the release notes on the merged pull request of the commit",
+			EnvVars:     []string{"PLUGIN_COMMENT_NOTES", "GITHUB_RELEASE_COMMENT_NOTES"},
+			Destination: &settings.CommentNotes,
+		},
+		&cli.IntFlag{
+			Name:        "comment-issue",
+			Usage:       "issue or pull request to comment on instead of the merged pull request",
+			EnvVars:     []string{"PLUGIN_COMMENT_ISSUE", "GITHUB_RELEASE_COMMENT_ISSUE"},
+			Destination: &settings.CommentIssue,
+		},
+		&cli.StringFlag{
+			Name:        "comment-template",
+			Usage:       "file or string with the template of the release notes comment",
+			EnvVars:     []string{"PLUGIN_COMMENT_TEMPLATE", "GITHUB_RELEASE_COMMENT_TEMPLATE"},
+			Destination: &settings.CommentTemplate,
+		},
+		&cli.StringFlag{
+			Name:        "on-immutable",
+			Value:       "fail",
+			Usage:       "what to do if the published release is immutable, either fail, new-tag or skip",
+			EnvVars:     []string{"PLUGIN_ON_IMMUTABLE", "GITHUB_RELEASE_ON_IMMUTABLE"},
+			Destination: &settings.OnImmutable,
+		},
+		&cli.StringFlag{
+			Name:        "assets-manifest",
+			Usage:       "name of a json asset listing the assets in display order for download pages",
+			EnvVars:     []string{"PLUGIN_ASSETS_MANIFEST", "GITHUB_RELEASE_ASSETS_MANIFEST"},
+			Destination: &settings.AssetsManifest,
+		},
+		&cli.StringSliceFlag{
+			Name:        "assets-order",
+			Usage:       "display order of the assets manifest as group=pattern entries",
+			EnvVars:     []string{"PLUGIN_ASSETS_ORDER", "GITHUB_RELEASE_ASSETS_ORDER"},
+			Destination: &settings.AssetsOrder,
+		},
+		&cli.StringFlag{
+			Name:        "queue",
+			Usage:       "serialize concurrent runs by a lock, either empty or github for a lock ref",
+			EnvVars:     []string{"PLUGIN_QUEUE", "GITHUB_RELEASE_QUEUE"},
+			Destination: &settings.Queue,
+		},
+		&cli.StringFlag{
+			Name:        "queue-repo",
+			Usage:       "repository holding the lock refs, defaults to the released repository and required for queue-scope token",
+			EnvVars:     []string{"PLUGIN_QUEUE_REPO", "GITHUB_RELEASE_QUEUE_REPO"},
+			Destination: &settings.QueueRepo,
+		},
+		&cli.StringFlag{
+			Name:        "queue-scope",
+			Value:       "repo",
+			Usage:       "runs sharing a lock, either repo for one lock per repository or token for one lock overall",
+			EnvVars:     []string{"PLUGIN_QUEUE_SCOPE", "GITHUB_RELEASE_QUEUE_SCOPE"},
+			Destination: &settings.QueueScope,
+		},
+		&cli.DurationFlag{
+			Name:        "queue-timeout",
+			Value:       15 * time.Minute,
+			Usage:       "maximum time to wait for the lock",
+			EnvVars:     []string{"PLUGIN_QUEUE_TIMEOUT", "GITHUB_RELEASE_QUEUE_TIMEOUT"},
+			Destination: &settings.QueueTimeout,
+		},
+		&cli.DurationFlag{
+			Name:        "queue-stale",
+			Value:       time.Hour,
+			Usage:       "age after which a lock left by a crashed run gets removed",
+			EnvVars:     []string{"PLUGIN_QUEUE_STALE", "GITHUB_RELEASE_QUEUE_STALE"},
+			Destination: &settings.QueueStale,
+		},
+		&cli.DurationFlag{
+			Name:        "queue-interval",
+			Value:       15 * time.Second,
+			Usage:       "interval between attempts to acquire the lock",
+			EnvVars:     []string{"PLUGIN_QUEUE_INTERVAL", "GITHUB_RELEASE_QUEUE_INTERVAL"},
+			Destination: &settings.QueueInterval,
+		},
+		&cli.StringFlag{
+			Name:        "sandbox-repo",
+			Usage:       "scratch repository a dry run releases to instead of only planning",
+			EnvVars:     []string{"PLUGIN_SANDBOX_REPO", "GITHUB_RELEASE_SANDBOX_REPO"},
+			Destination: &settings.SandboxRepo,
+		},
+		&cli.StringFlag{
+			Name:        "stats-file",
+			Usage:       "file the api statistics of the run get appended to as json line",
+			EnvVars:     []string{"PLUGIN_STATS_FILE", "GITHUB_RELEASE_STATS_FILE"},
+			Destination: &settings.StatsFile,
+		},
+		&cli.StringFlag{
+			Name:        "stats-endpoint",
+			Usage:       "url the api statistics of the run get posted to as json",
+			EnvVars:     []string{"PLUGIN_STATS_ENDPOINT", "GITHUB_RELEASE_STATS_ENDPOINT"},
+			Destination: &settings.StatsEndpoint,
+		},
+		&cli.IntFlag{
+			Name:        "files-depth",
+			Usage:       "maximum depth directories in files are walked, 0 walks them completely",
+			EnvVars:     []string{"PLUGIN_FILES_DEPTH", "GITHUB_RELEASE_FILES_DEPTH"},
+			Destination: &settings.FilesDepth,
+		},
+		&cli.StringSliceFlag{
+			Name:        "files-exclude",
+			Usage:       "patterns of files to drop from the expanded files, matched against path and name",
+			EnvVars:     []string{"PLUGIN_FILES_EXCLUDE", "GITHUB_RELEASE_FILES_EXCLUDE"},
+			Destination: &settings.FilesExclude,
+		},
+		&cli.StringFlag{
+			Name:        "verify-signature",
+			Usage:       "require a verified signature before publishing, either tag or tag-or-commit",
+			EnvVars:     []string{"PLUGIN_VERIFY_SIGNATURE", "GITHUB_RELEASE_VERIFY_SIGNATURE"},
+			Destination: &settings.VerifySignature,
+		},
+		&cli.StringSliceFlag{
+			Name:        "allowed-signers",
+			Usage:       "emails or logins of the signers allowed to sign the tag or commit",
+			EnvVars:     []string{"PLUGIN_ALLOWED_SIGNERS", "GITHUB_RELEASE_ALLOWED_SIGNERS"},
+			Destination: &settings.AllowedSigners,
+		},
+		&cli.StringFlag{
+			Name:        "on-missing-tag",
+			Value:       "fail",
+			Usage:       "what to do for builds without tag, either fail, derive or skip",
+			EnvVars:     []string{"PLUGIN_ON_MISSING_TAG", "GITHUB_RELEASE_ON_MISSING_TAG"},
+			Destination: &settings.OnMissingTag,
+		},
+		&cli.StringFlag{
+			Name:        "derive-tag-file",
+			Usage:       "file with the version used as tag for builds without tag, defaults to snapshot-<commit>",
+			EnvVars:     []string{"PLUGIN_DERIVE_TAG_FILE", "GITHUB_RELEASE_DERIVE_TAG_FILE"},
+			Destination: &settings.DeriveTagFile,
+		},
+		&cli.StringFlag{
+			Name:        "same-commit",
+			Usage:       "check for releases of other tags of the commit, either warn or link them in the notes",
+			EnvVars:     []string{"PLUGIN_SAME_COMMIT", "GITHUB_RELEASE_SAME_COMMIT"},
+			Destination: &settings.SameCommit,
+		},
+		&cli.StringFlag{
+			Name:        "handoff",
+			Usage:       "hand off a release draft to another pipeline, write in the drafting and read in the publishing pipeline",
+			EnvVars:     []string{"PLUGIN_HANDOFF", "GITHUB_RELEASE_HANDOFF"},
+			Destination: &settings.Handoff,
+		},
+		&cli.StringFlag{
+			Name:        "handoff-file",
+			Value:       ".release/{{tag}}.json",
+			Usage:       "path of the handoff descriptor in the repository, \"{{tag}}\" is replaced with the tag",
+			EnvVars:     []string{"PLUGIN_HANDOFF_FILE", "GITHUB_RELEASE_HANDOFF_FILE"},
+			Destination: &settings.HandoffFile,
+		},
+		&cli.StringFlag{
+			Name:        "handoff-branch",
+			Usage:       "branch holding the handoff descriptor, defaults to the default branch",
+			EnvVars:     []string{"PLUGIN_HANDOFF_BRANCH", "GITHUB_RELEASE_HANDOFF_BRANCH"},
+			Destination: &settings.HandoffBranch,
+		},
+		&cli.StringSliceFlag{
+			Name:        "latest-aliases",
+			Usage:       "patterns of files also uploaded without version to the rolling latest release, a name can be set as pattern=name",
+			EnvVars:     []string{"PLUGIN_LATEST_ALIASES", "GITHUB_RELEASE_LATEST_ALIASES"},
+			Destination: &settings.LatestAliases,
+		},
+		&cli.StringFlag{
+			Name:        "latest-tag",
+			Value:       "latest",
+			Usage:       "tag of the rolling release receiving the latest aliases",
+			EnvVars:     []string{"PLUGIN_LATEST_TAG", "GITHUB_RELEASE_LATEST_TAG"},
+			Destination: &settings.LatestTag,
+		},
+		&cli.StringFlag{
+			Name:        "intoto-layout",
+			Usage:       "signed in-toto layout the artifacts are verified against before uploading",
+			EnvVars:     []string{"PLUGIN_INTOTO_LAYOUT", "GITHUB_RELEASE_INTOTO_LAYOUT"},
+			Destination: &settings.IntotoLayout,
+		},
+		&cli.StringSliceFlag{
+			Name:        "intoto-keys",
+			Usage:       "public keys verifying the in-toto layout signatures",
+			EnvVars:     []string{"PLUGIN_INTOTO_KEYS", "GITHUB_RELEASE_INTOTO_KEYS"},
+			Destination: &settings.IntotoKeys,
+		},
+		&cli.StringFlag{
+			Name:        "intoto-link-dir",
+			Usage:       "directory of the in-toto link metadata, defaults to the working directory",
+			EnvVars:     []string{"PLUGIN_INTOTO_LINK_DIR", "GITHUB_RELEASE_INTOTO_LINK_DIR"},
+			Destination: &settings.IntotoLinkDir,
+		},
+		&cli.StringFlag{
+			Name:        "intoto-command",
+			Value:       "in-toto-verify",
+			Usage:       "command verifying the in-toto layout",
+			EnvVars:     []string{"PLUGIN_INTOTO_COMMAND", "GITHUB_RELEASE_INTOTO_COMMAND"},
+			Destination: &settings.IntotoCommand,
+		},
+		&cli.StringFlag{
+			Name:        "preview-html",
+			Usage:       "file to write the release notes rendered as html to, for reviewing drafts",
+			EnvVars:     []string{"PLUGIN_PREVIEW_HTML", "GITHUB_RELEASE_PREVIEW_HTML"},
+			Destination: &settings.PreviewHTML,
+		},
+		&cli.DurationFlag{
+			Name:        "latency-sla",
+			Usage:       "maximum time between creating the tag and publishing the release before the latency webhook is called",
+			EnvVars:     []string{"PLUGIN_LATENCY_SLA", "GITHUB_RELEASE_LATENCY_SLA"},
+			Destination: &settings.LatencySLA,
+		},
+		&cli.StringFlag{
+			Name:        "latency-webhook",
+			Usage:       "url receiving a json alert when the latency exceeds latency-sla",
+			EnvVars:     []string{"PLUGIN_LATENCY_WEBHOOK", "GITHUB_RELEASE_LATENCY_WEBHOOK"},
+			Destination: &settings.LatencyWebhook,
+		},
+		&cli.StringFlag{
+			Name:        "gpg-key",
+			Usage:       "armored gpg secret key or file used to upload a detached .asc signature for every file",
+			EnvVars:     []string{"PLUGIN_GPG_KEY", "GITHUB_RELEASE_GPG_KEY"},
+			Destination: &settings.GPGKey,
+		},
+		&cli.StringFlag{
+			Name:        "gpg-passphrase",
+			Usage:       "passphrase of the gpg secret key",
+			EnvVars:     []string{"PLUGIN_GPG_PASSPHRASE", "GITHUB_RELEASE_GPG_PASSPHRASE"},
+			Destination: &settings.GPGPassphrase,
+		},
+		&cli.StringFlag{
+			Name:        "minisign-key",
+			Usage:       "minisign secret key or file used to upload a .minisig signature of the checksum files",
+			EnvVars:     []string{"PLUGIN_MINISIGN_KEY", "GITHUB_RELEASE_MINISIGN_KEY"},
+			Destination: &settings.MinisignKey,
+		},
+		&cli.StringFlag{
+			Name:        "minisign-password",
+			Usage:       "password of the minisign secret key",
+			EnvVars:     []string{"PLUGIN_MINISIGN_PASSWORD", "GITHUB_RELEASE_MINISIGN_PASSWORD"},
+			Destination: &settings.MinisignPassword,
+		},
+		&cli.StringFlag{
+			Name:        "cosign",
+			Usage:       "sign every file with cosign, either with a key or keyless, uploading signatures, certificates and bundles",
+			EnvVars:     []string{"PLUGIN_COSIGN", "GITHUB_RELEASE_COSIGN"},
+			Destination: &settings.Cosign,
+		},
+		&cli.StringFlag{
+			Name:        "cosign-key",
+			Usage:       "cosign private key, key file or kms reference",
+			EnvVars:     []string{"PLUGIN_COSIGN_KEY", "GITHUB_RELEASE_COSIGN_KEY"},
+			Destination: &settings.CosignKey,
+		},
+		&cli.StringFlag{
+			Name:        "cosign-password",
+			Usage:       "password of the cosign private key",
+			EnvVars:     []string{"PLUGIN_COSIGN_PASSWORD", "GITHUB_RELEASE_COSIGN_PASSWORD"},
+			Destination: &settings.CosignPassword,
+		},
+		&cli.StringFlag{
+			Name:        "cosign-identity-token",
+			Usage:       "oidc identity token for keyless signing",
+			EnvVars:     []string{"PLUGIN_COSIGN_IDENTITY_TOKEN", "GITHUB_RELEASE_COSIGN_IDENTITY_TOKEN"},
+			Destination: &settings.CosignIdentityToken,
+		},
+		&cli.StringFlag{
+			Name:        "cosign-command",
+			Value:       "cosign",
+			Usage:       "cosign command used for signing",
+			EnvVars:     []string{"PLUGIN_COSIGN_COMMAND", "GITHUB_RELEASE_COSIGN_COMMAND"},
+			Destination: &settings.CosignCommand,
+		},
+		&cli.StringFlag{
+			Name:        "discussion-category",
+			Usage:       "discussion category to create a discussion for new releases in",
+			EnvVars:     []string{"PLUGIN_DISCUSSION_CATEGORY", "GITHUB_RELEASE_DISCUSSION_CATEGORY"},
+			Destination: &settings.DiscussionCategory,
+		},
+		&cli.BoolFlag{
+			Name:        "discussion-digests",
+			Usage:       "comment the checksums of the uploaded files on the release discussion once all are uploaded",
+			EnvVars:     []string{"PLUGIN_DISCUSSION_DIGESTS", "GITHUB_RELEASE_DISCUSSION_DIGESTS"},
+			Destination: &settings.DiscussionDigests,
+		},
+		&cli.StringFlag{
+			Name:        "sbom",
+			Usage:       "pre-built SPDX or CycloneDX sbom file to upload",
+			EnvVars:     []string{"PLUGIN_SBOM", "GITHUB_RELEASE_SBOM"},
+			Destination: &settings.SBOM,
+		},
+		&cli.StringFlag{
+			Name:        "sbom-command",
+			Usage:       "command writing a SPDX or CycloneDX sbom of the repository to stdout, which gets uploaded (example: syft dir:. -o spdx-json)",
+			EnvVars:     []string{"PLUGIN_SBOM_COMMAND", "GITHUB_RELEASE_SBOM_COMMAND"},
+			Destination: &settings.SBOMCommand,
+		},
+		&cli.BoolFlag{
+			Name:        "check-rulesets",
+			Usage:       "fail early if the token cannot create the release or rulesets block the tags to create or update",
+			EnvVars:     []string{"PLUGIN_CHECK_RULESETS", "GITHUB_RELEASE_CHECK_RULESETS"},
+			Destination: &settings.CheckRulesets,
+		},
+		&cli.BoolFlag{
+			Name:        "provenance",
+			Usage:       "upload a SLSA v1 provenance statement of the files as provenance.intoto.jsonl",
+			EnvVars:     []string{"PLUGIN_PROVENANCE", "GITHUB_RELEASE_PROVENANCE"},
+			Destination: &settings.Provenance,
+		},
+		&cli.StringSliceFlag{
+			Name:        "attestations",
+			Usage:       "in-toto attestation files to bind to the digests of the files and upload",
+			EnvVars:     []string{"PLUGIN_ATTESTATIONS", "GITHUB_RELEASE_ATTESTATIONS"},
+			Destination: &settings.Attestations,
+		},
+		&cli.StringFlag{
+			Name:        "ssh-key",
+			Usage:       "ssh private key to sign the checksums with ssh-keygen -Y sign",
+			EnvVars:     []string{"PLUGIN_SSH_KEY", "GITHUB_RELEASE_SSH_KEY"},
+			Destination: &settings.SSHKey,
+		},
+		&cli.StringFlag{
+			Name:        "ssh-namespace",
+			Usage:       "namespace of the ssh signatures",
+			Value:       "file",
+			EnvVars:     []string{"PLUGIN_SSH_NAMESPACE", "GITHUB_RELEASE_SSH_NAMESPACE"},
+			Destination: &settings.SSHNamespace,
+		},
+		&cli.StringFlag{
+			Name:        "ssh-allowed-signers",
+			Usage:       "allowed signers file to verify the ssh signatures against",
+			EnvVars:     []string{"PLUGIN_SSH_ALLOWED_SIGNERS", "GITHUB_RELEASE_SSH_ALLOWED_SIGNERS"},
+			Destination: &settings.SSHAllowedSigners,
+		},
+		&cli.StringFlag{
+			Name:        "encrypt",
+			Usage:       "encrypt the files before upload with age or gpg, publishing only the ciphertext",
+			EnvVars:     []string{"PLUGIN_ENCRYPT", "GITHUB_RELEASE_ENCRYPT"},
+			Destination: &settings.Encrypt,
+		},
+		&cli.StringSliceFlag{
+			Name:        "encrypt-recipients",
+			Usage:       "age recipients or armored gpg public keys to encrypt for",
+			EnvVars:     []string{"PLUGIN_ENCRYPT_RECIPIENTS", "GITHUB_RELEASE_ENCRYPT_RECIPIENTS"},
+			Destination: &settings.EncryptRecipients,
+		},
+		&cli.StringFlag{
+			Name:        "note-template",
+			Usage:       "file or string with the template of the release notes, with the notes as .Notes and the notes of the previous release as .PreviousNotes",
+			EnvVars:     []string{"PLUGIN_NOTE_TEMPLATE", "GITHUB_RELEASE_NOTE_TEMPLATE"},
+			Destination: &settings.NoteTemplate,
+		},
+		&cli.BoolFlag{
+			Name:        "commit-comment",
+			Usage:       "comment the release link and the released files on the released commit",
+			EnvVars:     []string{"PLUGIN_COMMIT_COMMENT", "GITHUB_RELEASE_COMMIT_COMMENT"},
+			Destination: &settings.CommitComment,
+		},
+		&cli.StringFlag{
+			Name:        "commit-comment-template",
+			Usage:       "file or string with the template of the commit comment",
+			EnvVars:     []string{"PLUGIN_COMMIT_COMMENT_TEMPLATE", "GITHUB_RELEASE_COMMIT_COMMENT_TEMPLATE"},
+			Destination: &settings.CommitTemplate,
+		},
+		&cli.BoolFlag{
+			Name:        "alias-tags",
+			Usage:       "move the major and minor version tags like v1 and v1.2 forward to a stable release",
+			EnvVars:     []string{"PLUGIN_ALIAS_TAGS", "GITHUB_RELEASE_ALIAS_TAGS"},
+			Destination: &settings.AliasTags,
+		},
+		&cli.StringSliceFlag{
+			Name:        "notes-redactions",
+			Usage:       "regular expressions redacted from the notes before publishing, or @file with one per line",
+			EnvVars:     []string{"PLUGIN_NOTES_REDACTIONS", "GITHUB_RELEASE_NOTES_REDACTIONS"},
+			Destination: &settings.NotesRedactions,
+		},
+		&cli.StringFlag{
+			Name:        "log-format",
+			Value:       "text",
+			Usage:       "format of the log output, either text or json",
+			EnvVars:     []string{"PLUGIN_LOG_FORMAT", "GITHUB_RELEASE_LOG_FORMAT"},
+			Destination: &settings.LogFormat,
+		},
+		&cli.StringFlag{
+			Name:        "inline-assets",
+			Usage:       "json list of small files to render from templates and upload, each with a name and a template",
+			EnvVars:     []string{"PLUGIN_INLINE_ASSETS", "GITHUB_RELEASE_INLINE_ASSETS"},
+			Destination: &settings.InlineAssets,
+		},
+		&cli.BoolFlag{
+			Name:        "quiet",
+			Usage:       "only print warnings, errors and the release url",
+			EnvVars:     []string{"PLUGIN_QUIET", "GITHUB_RELEASE_QUIET"},
+			Destination: &settings.Quiet,
+		},
+		&cli.BoolFlag{
+			Name:        "protect-downloaded",
+			Usage:       "refuse to overwrite assets which were already downloaded, unless forced",
+			EnvVars:     []string{"PLUGIN_PROTECT_DOWNLOADED", "GITHUB_RELEASE_PROTECT_DOWNLOADED"},
+			Destination: &settings.ProtectDownloaded,
+		},
+		&cli.StringFlag{
+			Name:        "env-file",
+			Usage:       "file to append RELEASE_URL, RELEASE_ID and UPLOAD_URL to for later steps",
+			EnvVars:     []string{"PLUGIN_ENV_FILE", "GITHUB_RELEASE_ENV_FILE"},
+			Destination: &settings.EnvFile,
+		},
+		&cli.BoolFlag{
+			Name:        "notes-stats",
+			Usage:       "append the number of commits, pull requests and contributors and the asset size since the previous release to the notes",
+			EnvVars:     []string{"PLUGIN_NOTES_STATS", "GITHUB_RELEASE_NOTES_STATS"},
+			Destination: &settings.NotesStats,
+		},
+		&cli.BoolFlag{
+			Name:        "own-assets",
+			Usage:       "only replace assets uploaded by this plugin, failing for assets attached by hand unless forced",
+			EnvVars:     []string{"PLUGIN_OWN_ASSETS", "GITHUB_RELEASE_OWN_ASSETS"},
+			Destination: &settings.OwnAssets,
+		},
+		&cli.StringFlag{
+			Name:        "output-file",
+			Usage:       "write the final release including its assets as json to this file",
+			EnvVars:     []string{"PLUGIN_OUTPUT_FILE", "GITHUB_RELEASE_OUTPUT_FILE"},
+			Destination: &settings.OutputFile,
+		},
+		&cli.StringFlag{
+			Name:        "card-path",
+			Usage:       "file to write the drone card summarizing the release to",
+			EnvVars:     []string{"PLUGIN_CARD_PATH", "DRONE_CARD_PATH"},
+			Destination: &settings.CardPath,
+		},
+		&cli.StringFlag{
+			Name:        "stream-max-size",
+			Value:       "256MB",
+			Usage:       "maximum size of an asset read from stdin or a named pipe, which is held in memory",
+			EnvVars:     []string{"PLUGIN_STREAM_MAX_SIZE", "GITHUB_RELEASE_STREAM_MAX_SIZE"},
+			Destination: &settings.StreamMaxSize,
+		},
+	})
+
+	// failures are reported by the validation of the plugin
+	if err != nil {
+		settings.LoadError = fmt.Errorf("failed to load settings: %w", err)
 	}
+
+	flags = append(flags, completionFlag)
+
+	if shell := completionValue(os.Args[1:]); shell != "" {
+		script, err := completion(shell, append(flags, urfave.Flags()...))
+
+		if err != nil {
+			settings.LoadError = fmt.Errorf("failed to generate completion: %w", err)
+		}
+
+		settings.Completion = script
+	}
+
+	return flags
 }
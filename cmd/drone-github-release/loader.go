@@ -0,0 +1,251 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/drone-plugins/drone-github-release/plugin"
+	"github.com/urfave/cli/v2"
+)
+
+// configFileFlag is read before the flags get parsed, so it can only be
+// provided by flag or environment.
+var configFileFlag = &cli.StringFlag{
+	Name:    "config-file",
+	Usage:   "json file with settings keyed by flag name, overriding the environment and overridden by flags",
+	EnvVars: []string{"PLUGIN_CONFIG_FILE", "GITHUB_RELEASE_CONFIG_FILE"},
+}
+
+// loadSettings completes the flags so every setting is available as flag,
+// PLUGIN_ environment variable and config file entry. Settings are layered
+// from environment to config file to flags, each overriding the one before.
+// Config file entries replace the defaults of the flags and hide their
+// environment variables. The source of every setting gets recorded in the
+// settings for debugging. All flags are returned even if loading fails, so
+// the failure gets reported by the validation instead of the flag parsing.
+func loadSettings(settings *plugin.Settings, args []string, flags []cli.Flag) ([]cli.Flag, error) {
+	flags = append(flags, configFileFlag)
+	values, err := readConfigFile(configFileValue(args))
+
+	if err != nil {
+		return flags, err
+	}
+
+	settings.Sources = make(map[string]string)
+
+	for _, flag := range flags {
+		name := flag.Names()[0]
+		env := envVars(flag)
+		value, inFile := values[name]
+
+		if !inFile {
+			value, inFile = values[strings.ReplaceAll(name, "-", "_")]
+		}
+
+		switch {
+		case hasArg(args, flag.Names()):
+			settings.Sources[name] = "flag"
+		case inFile && flag != configFileFlag:
+			if err := setDefault(flag, value); err != nil {
+				return flags, fmt.Errorf("invalid value for %s in config file: %w", name, err)
+			}
+
+			settings.Sources[name] = "file"
+
+			if overridden := lookupEnv(*env); overridden != "" {
+				settings.Sources[name] = "file, overriding env " + overridden
+			}
+
+			*env = nil
+		case lookupEnv(*env) != "":
+			settings.Sources[name] = "env " + lookupEnv(*env)
+		}
+	}
+
+	return flags, nil
+}
+
+// setDefault replaces the default value of the flag, lists are separated by
+// commas like within environment variables.
+func setDefault(flag cli.Flag, value string) error {
+	var err error
+
+	switch f := flag.(type) {
+	case *cli.StringFlag:
+		f.Value = value
+	case *cli.BoolFlag:
+		f.Value, err = strconv.ParseBool(value)
+	case *cli.IntFlag:
+		f.Value, err = strconv.Atoi(value)
+	case *cli.Int64Flag:
+		f.Value, err = strconv.ParseInt(value, 10, 64)
+	case *cli.Float64Flag:
+		f.Value, err = strconv.ParseFloat(value, 64)
+	case *cli.DurationFlag:
+		f.Value, err = time.ParseDuration(value)
+	case *cli.StringSliceFlag:
+		parts := strings.Split(value, ",")
+
+		for i := range parts {
+			parts[i] = strings.TrimSpace(parts[i])
+		}
+
+		f.Value = cli.NewStringSlice(parts...)
+	case *cli.GenericFlag:
+		err = f.Value.Set(value)
+	default:
+		err = fmt.Errorf("unsupported flag type %T", flag)
+	}
+
+	return err
+}
+
+// envVars returns the environment variables of the flag, adding the
+// PLUGIN_ variable derived from the flag name if it is missing.
+func envVars(flag cli.Flag) *[]string {
+	var env *[]string
+
+	switch f := flag.(type) {
+	case *cli.StringFlag:
+		env = &f.EnvVars
+	case *cli.BoolFlag:
+		env = &f.EnvVars
+	case *cli.IntFlag:
+		env = &f.EnvVars
+	case *cli.Int64Flag:
+		env = &f.EnvVars
+	case *cli.Float64Flag:
+		env = &f.EnvVars
+	case *cli.DurationFlag:
+		env = &f.EnvVars
+	case *cli.StringSliceFlag:
+		env = &f.EnvVars
+	case *cli.GenericFlag:
+		env = &f.EnvVars
+	default:
+		env = &[]string{}
+	}
+
+	name := "PLUGIN_" + strings.ToUpper(strings.ReplaceAll(flag.Names()[0], "-", "_"))
+
+	for _, e := range *env {
+		if e == name {
+			return env
+		}
+	}
+
+	*env = append([]string{name}, *env...)
+	return env
+}
+
+// lookupEnv returns the first environment variable which is set.
+func lookupEnv(names []string) string {
+	for _, name := range names {
+		if _, ok := os.LookupEnv(name); ok {
+			return name
+		}
+	}
+
+	return ""
+}
+
+func hasArg(args, names []string) bool {
+	for _, arg := range args {
+		for _, name := range names {
+			prefix := "--" + name
+
+			if len(name) == 1 {
+				prefix = "-" + name
+			}
+
+			if arg == prefix || strings.HasPrefix(arg, prefix+"=") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func configFileValue(args []string) string {
+	for i, arg := range args {
+		if arg == "--config-file" && i+1 < len(args) {
+			return args[i+1]
+		}
+
+		if strings.HasPrefix(arg, "--config-file=") {
+			return strings.TrimPrefix(arg, "--config-file=")
+		}
+	}
+
+	if name := lookupEnv(configFileFlag.EnvVars); name != "" {
+		return os.Getenv(name)
+	}
+
+	return ""
+}
+
+// readConfigFile reads the settings as strings, lists are joined by commas
+// like within environment variables.
+func readConfigFile(file string) (map[string]string, error) {
+	values := make(map[string]string)
+
+	if file == "" {
+		return values, nil
+	}
+
+	content, err := os.ReadFile(file)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var raw map[string]interface{}
+
+	if err := json.Unmarshal(content, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+
+	for key, value := range raw {
+		switch v := value.(type) {
+		case []interface{}:
+			if parts, ok := scalars(v); ok {
+				values[key] = strings.Join(parts, ",")
+			} else {
+				b, _ := json.Marshal(v)
+				values[key] = string(b)
+			}
+		case string:
+			values[key] = v
+		default:
+			b, _ := json.Marshal(v)
+			values[key] = string(b)
+		}
+	}
+
+	return values, nil
+}
+
+func scalars(values []interface{}) ([]string, bool) {
+	parts := make([]string, 0, len(values))
+
+	for _, value := range values {
+		switch value.(type) {
+		case map[string]interface{}, []interface{}:
+			return nil, false
+		}
+
+		parts = append(parts, fmt.Sprint(value))
+	}
+
+	return parts, true
+}
@@ -0,0 +1,122 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/drone-plugins/drone-github-release/plugin"
+	"github.com/urfave/cli/v2"
+)
+
+func TestLoadSettings(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.json")
+	config := `{"title": "from file", "note": "from file", "retries": 3, "files": ["dist/*", "docs/*"], "draft": true}`
+
+	if err := os.WriteFile(file, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("PLUGIN_CONFIG_FILE", file)
+	t.Setenv("PLUGIN_NOTE", "from env")
+	t.Setenv("PLUGIN_TAG", "from env")
+
+	var (
+		title, note string
+		tag         string
+		retries     int
+		draft       bool
+		files       cli.StringSlice
+	)
+
+	args := []string{"app", "--title", "from flag"}
+	settings := &plugin.Settings{}
+
+	flags, err := loadSettings(settings, args[1:], []cli.Flag{
+		&cli.StringFlag{Name: "title", Destination: &title},
+		&cli.StringFlag{Name: "note", Destination: &note},
+		&cli.StringFlag{Name: "tag", Destination: &tag},
+		&cli.IntFlag{Name: "retries", Destination: &retries},
+		&cli.BoolFlag{Name: "draft", Destination: &draft},
+		&cli.StringSliceFlag{Name: "files", Destination: &files},
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := os.LookupEnv("PLUGIN_TITLE"); ok {
+		t.Error("Unexpected environment variable set from the config file")
+	}
+
+	app := &cli.App{Flags: flags, Action: func(*cli.Context) error { return nil }}
+
+	if err := app.Run(args); err != nil {
+		t.Fatal(err)
+	}
+
+	if title != "from flag" || note != "from file" || tag != "from env" || retries != 3 || !draft {
+		t.Errorf("Unexpected settings (Got: %q, %q, %q, %d, %t)", title, note, tag, retries, draft)
+	}
+
+	if expected := []string{"dist/*", "docs/*"}; !reflect.DeepEqual(files.Value(), expected) {
+		t.Errorf("Unexpected files (Got: %v, Expected: %v)", files.Value(), expected)
+	}
+
+	expected := map[string]string{
+		"title":       "flag",
+		"note":        "file, overriding env PLUGIN_NOTE",
+		"tag":         "env PLUGIN_TAG",
+		"retries":     "file",
+		"draft":       "file",
+		"files":       "file",
+		"config-file": "env PLUGIN_CONFIG_FILE",
+	}
+
+	if !reflect.DeepEqual(settings.Sources, expected) {
+		t.Errorf("Unexpected sources (Got: %v, Expected: %v)", settings.Sources, expected)
+	}
+}
+
+func TestLoadSettingsInvalid(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.json")
+
+	if err := os.WriteFile(file, []byte(`{"retries": "many"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("PLUGIN_CONFIG_FILE", file)
+
+	var retries int
+
+	flags, err := loadSettings(&plugin.Settings{}, nil, []cli.Flag{
+		&cli.IntFlag{Name: "retries", Destination: &retries},
+	})
+
+	if err == nil {
+		t.Error("Expected an error for an invalid value")
+	}
+
+	// the flags are still defined, so the load error gets reported
+	t.Setenv("PLUGIN_CONFIG_FILE", filepath.Join(t.TempDir(), "missing.json"))
+
+	flags, err = loadSettings(&plugin.Settings{}, nil, []cli.Flag{
+		&cli.IntFlag{Name: "retries", Destination: &retries},
+	})
+
+	if err == nil {
+		t.Error("Expected an error for a missing config file")
+	}
+
+	app := &cli.App{Flags: flags, Action: func(*cli.Context) error { return nil }}
+
+	if err := app.Run([]string{"app", "--config-file", "missing.json", "--retries", "2"}); err != nil {
+		t.Errorf("Unexpected error for the flags: %s", err)
+	}
+}
@@ -6,7 +6,11 @@ require (
 	github.com/drone-plugins/drone-plugin-lib v0.4.0
 	github.com/google/go-github/v44 v44.1.0
 	github.com/joho/godotenv v1.4.0
+	github.com/klauspost/compress v1.15.9
+	github.com/santhosh-tekuri/jsonschema/v5 v5.0.0
+	github.com/sirupsen/logrus v1.9.0
 	github.com/urfave/cli/v2 v2.11.1
+	golang.org/x/crypto v0.0.0-20220722155217-630584e8d5aa
 	golang.org/x/oauth2 v0.0.0-20220808172628-8227340efae7
 	honnef.co/go/tools v0.3.3 // required for staticcheck build step
 	golang.org/x/lint v0.0.0-20210508222113-6edffad5e616 // required for lint build step
@@ -18,9 +22,7 @@ require (
 	github.com/golang/protobuf v1.5.2 // indirect
 	github.com/google/go-querystring v1.1.0 // indirect
 	github.com/russross/blackfriday/v2 v2.1.0 // indirect
-	github.com/sirupsen/logrus v1.9.0 // indirect
 	github.com/xrash/smetrics v0.0.0-20201216005158-039620a65673 // indirect
-	golang.org/x/crypto v0.0.0-20220722155217-630584e8d5aa // indirect
 	golang.org/x/exp/typeparams v0.0.0-20220722155223-a9213eeb770e // indirect
 	golang.org/x/mod v0.6.0-dev.0.20220419223038-86c51ed26bb4 // indirect
 	golang.org/x/net v0.0.0-20220811182439-13a9a731de15 // indirect
@@ -0,0 +1,117 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package release
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+
+	"github.com/google/go-github/v44/github"
+)
+
+// Actions of a planned release or asset.
+const (
+	ActionCreate  = "create"
+	ActionUpdate  = "update"
+	ActionUpload  = "upload"
+	ActionReplace = "replace"
+	ActionSkip    = "skip"
+)
+
+// Plan describes the changes CreateOrUpdate and UploadAssets would apply.
+type Plan struct {
+	Tag       string                    `json:"tag"`
+	Action    string                    `json:"action"`
+	ReleaseID int64                     `json:"release_id,omitempty"`
+	Payload   *github.RepositoryRelease `json:"payload"`
+	Assets    []PlannedAsset            `json:"assets"`
+}
+
+// PlannedAsset describes what happens to a single file.
+type PlannedAsset struct {
+	File    string `json:"file"`
+	Name    string `json:"name"`
+	Action  string `json:"action"`
+	AssetID int64  `json:"asset_id,omitempty"`
+}
+
+// Plan computes the changes of a release without applying them.
+func (c *Client) Plan(ctx context.Context, opts Options, files []string, fileExists string) (*Plan, error) {
+	if opts.Tag == "" {
+		return nil, errors.New("missing tag of the release")
+	}
+
+	existing, err := c.GetByTag(ctx, opts.Tag)
+
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Plan{
+		Tag:    opts.Tag,
+		Assets: []PlannedAsset{},
+	}
+
+	var assets []*github.ReleaseAsset
+
+	if existing == nil {
+		p.Action = ActionCreate
+		p.Payload = CreatePayload(opts)
+	} else {
+		p.Action = ActionUpdate
+		p.ReleaseID = existing.GetID()
+		p.Payload = EditPayload(opts, existing)
+
+		if assets, err = c.ListAssets(ctx, existing.GetID()); err != nil {
+			return nil, err
+		}
+	}
+
+	planned, err := PlanAssets(files, assets, fileExists)
+
+	if err != nil {
+		return nil, err
+	}
+
+	p.Assets = append(p.Assets, planned...)
+	return p, nil
+}
+
+// PlanAssets decides for every file whether it gets uploaded, replaces an
+// existing asset or is skipped.
+func PlanAssets(files []string, assets []*github.ReleaseAsset, fileExists string) ([]PlannedAsset, error) {
+	var planned []PlannedAsset
+
+	for _, file := range files {
+		pa := PlannedAsset{
+			File:   file,
+			Name:   path.Base(file),
+			Action: ActionUpload,
+		}
+
+		for _, asset := range assets {
+			if asset.GetName() == pa.Name {
+				switch fileExists {
+				case FileExistsOverwrite:
+					pa.Action = ActionReplace
+					pa.AssetID = asset.GetID()
+				case FileExistsFail:
+					return nil, fmt.Errorf("asset file %s already exists", pa.Name)
+				case FileExistsSkip:
+					pa.Action = ActionSkip
+				default:
+					return nil, fmt.Errorf("internal error, unknown file_exist value %s", fileExists)
+				}
+			}
+		}
+
+		planned = append(planned, pa)
+	}
+
+	return planned, nil
+}
@@ -0,0 +1,156 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package release
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-github/v44/github"
+)
+
+// newTestClient returns a client for the octocat/hello repository talking to
+// a fake github api.
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client := github.NewClient(server.Client())
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	client.UploadURL, _ = url.Parse(server.URL + "/")
+
+	return New(client, "octocat", "hello")
+}
+
+func TestEditPayload(t *testing.T) {
+	opts := Options{Tag: "v1.0.0", Title: "v1.0.0", Note: "notes"}
+
+	published := EditPayload(opts, &github.RepositoryRelease{Draft: github.Bool(false)})
+
+	if published.Draft != nil || published.Body != nil {
+		t.Errorf("Unexpected changes to a published release (Got: %v)", published)
+	}
+
+	opts.Overwrite = true
+	draft := EditPayload(opts, &github.RepositoryRelease{Draft: github.Bool(true)})
+
+	if draft.GetDraft() || draft.GetBody() != "notes" {
+		t.Errorf("Unexpected changes to a draft (Got: %v)", draft)
+	}
+}
+
+func TestCreatePayload(t *testing.T) {
+	rr := CreatePayload(Options{Tag: "v1.0.0", KeepDraft: true, Target: "main"})
+
+	if !rr.GetDraft() || rr.GetTargetCommitish() != "main" || rr.DiscussionCategoryName != nil {
+		t.Errorf("Unexpected payload (Got: %v)", rr)
+	}
+
+	edit := EditPayload(Options{KeepDraft: true, UpdatePrerelease: true, Prerelease: true}, &github.RepositoryRelease{Draft: github.Bool(true)})
+
+	if edit.Draft != nil || !edit.GetPrerelease() {
+		t.Errorf("Unexpected changes to a kept draft (Got: %v)", edit)
+	}
+}
+
+func TestPlanAssets(t *testing.T) {
+	assets := []*github.ReleaseAsset{{ID: github.Int64(1), Name: github.String("app")}}
+
+	planned, err := PlanAssets([]string{"dist/app", "dist/lib"}, assets, FileExistsOverwrite)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(planned) != 2 || planned[0].Action != ActionReplace || planned[0].AssetID != 1 || planned[1].Action != ActionUpload {
+		t.Errorf("Unexpected plan (Got: %v)", planned)
+	}
+
+	if _, err := PlanAssets([]string{"dist/app"}, assets, FileExistsFail); err == nil {
+		t.Error("Expected an error for an existing asset")
+	}
+}
+
+func TestUploadAssets(t *testing.T) {
+	var requests []string
+
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/octocat/hello/releases/1/assets":
+			fmt.Fprint(w, `[{"id": 2, "name": "app"}]`)
+		case r.Method == http.MethodDelete:
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodPost:
+			fmt.Fprint(w, `{}`)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "app")
+
+	if err := os.WriteFile(file, []byte("content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.UploadAssets(context.Background(), 1, []string{file, filepath.Join(dir, "missing")}, FileExistsOverwrite); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+
+	if err := c.UploadAssets(context.Background(), 1, []string{file}, "replace"); err == nil {
+		t.Error("Expected an error for an invalid file_exists value")
+	}
+
+	if len(requests) != 0 {
+		t.Errorf("Unexpected requests before the checks (Got: %v)", requests)
+	}
+
+	if err := c.UploadAssets(context.Background(), 1, []string{file}, FileExistsOverwrite); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	expected := []string{"GET /repos/octocat/hello/releases/1/assets", "DELETE /repos/octocat/hello/releases/assets/2", "POST /repos/octocat/hello/releases/1/assets"}
+
+	if fmt.Sprint(requests) != fmt.Sprint(expected) {
+		t.Errorf("Unexpected requests (Got: %v, Expected: %v)", requests, expected)
+	}
+}
+
+func TestCreateOrUpdate(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/octocat/hello/releases":
+			fmt.Fprint(w, `[{"id": 1, "tag_name": "v0.9.0"}]`)
+		case r.Method == http.MethodPost && r.URL.Path == "/repos/octocat/hello/releases":
+			fmt.Fprint(w, `{"id": 2, "tag_name": "v1.0.0"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	if _, err := c.CreateOrUpdate(context.Background(), Options{}); err == nil {
+		t.Error("Expected an error for a missing tag")
+	}
+
+	release, err := c.CreateOrUpdate(context.Background(), Options{Tag: "v1.0.0"})
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if release.GetID() != 2 {
+		t.Errorf("Unexpected release (Got: %v)", release)
+	}
+}
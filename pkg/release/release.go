@@ -0,0 +1,268 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+// Package release creates GitHub releases and uploads their assets. It is
+// the core of the drone-github-release plugin and can be used by other
+// tools without running the plugin container.
+package release
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/google/go-github/v44/github"
+)
+
+// FileExists policies for assets which are already part of a release.
+const (
+	FileExistsOverwrite = "overwrite"
+	FileExistsFail      = "fail"
+	FileExistsSkip      = "skip"
+)
+
+var fileExistsValues = map[string]bool{
+	FileExistsOverwrite: true,
+	FileExistsFail:      true,
+	FileExistsSkip:      true,
+}
+
+// Client manages the releases of a single repository.
+type Client struct {
+	GitHub *github.Client
+	Owner  string
+	Repo   string
+}
+
+// Options describe the desired state of a release.
+type Options struct {
+	// Tag of the release.
+	Tag string
+
+	// Title and Note of the release.
+	Title string
+	Note  string
+
+	// Draft and Prerelease flag the release. A published release is never
+	// turned back into a draft.
+	Draft      bool
+	Prerelease bool
+
+	// KeepDraft keeps an existing draft unpublished, and creates a new
+	// release as draft.
+	KeepDraft bool
+
+	// Overwrite replaces the title and note of an existing release.
+	Overwrite bool
+
+	// UpdatePrerelease applies Prerelease to an existing release.
+	UpdatePrerelease bool
+
+	// GenerateReleaseNotes lets GitHub generate the notes of a new release.
+	GenerateReleaseNotes bool
+
+	// Target is the commitish a new tag is created from.
+	Target string
+
+	// DiscussionCategory creates a discussion for a new release.
+	DiscussionCategory string
+}
+
+// New returns a client for the releases of owner/repo.
+func New(client *github.Client, owner, repo string) *Client {
+	return &Client{
+		GitHub: client,
+		Owner:  owner,
+		Repo:   repo,
+	}
+}
+
+// GetByTag returns the release for the tag, including drafts, or nil if
+// there is none.
+func (c *Client) GetByTag(ctx context.Context, tag string) (*github.RepositoryRelease, error) {
+	listOpts := &github.ListOptions{PerPage: 100}
+
+	for {
+		releases, resp, err := c.GitHub.Repositories.ListReleases(ctx, c.Owner, c.Repo, listOpts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list releases: %w", err)
+		}
+
+		for _, release := range releases {
+			if release.GetTagName() == tag {
+				return release, nil
+			}
+		}
+
+		// stop iteration if there is no next page
+		if resp.NextPage == 0 {
+			return nil, nil
+		}
+
+		listOpts.Page = resp.NextPage
+	}
+}
+
+// CreateOrUpdate creates the release for the tag of the options, or updates
+// the existing one.
+func (c *Client) CreateOrUpdate(ctx context.Context, opts Options) (*github.RepositoryRelease, error) {
+	if opts.Tag == "" {
+		return nil, errors.New("missing tag of the release")
+	}
+
+	existing, err := c.GetByTag(ctx, opts.Tag)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if existing == nil {
+		release, _, err := c.GitHub.Repositories.CreateRelease(ctx, c.Owner, c.Repo, CreatePayload(opts))
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to create release: %w", err)
+		}
+
+		return release, nil
+	}
+
+	release, _, err := c.GitHub.Repositories.EditRelease(ctx, c.Owner, c.Repo, existing.GetID(), EditPayload(opts, existing))
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to update release: %w", err)
+	}
+
+	return release, nil
+}
+
+// CreatePayload builds the request for a new release.
+func CreatePayload(opts Options) *github.RepositoryRelease {
+	rr := &github.RepositoryRelease{
+		TagName:              github.String(opts.Tag),
+		Draft:                github.Bool(opts.Draft || opts.KeepDraft),
+		Prerelease:           github.Bool(opts.Prerelease),
+		Name:                 github.String(opts.Title),
+		Body:                 github.String(opts.Note),
+		GenerateReleaseNotes: github.Bool(opts.GenerateReleaseNotes),
+	}
+
+	if opts.Target != "" {
+		rr.TargetCommitish = github.String(opts.Target)
+	}
+
+	if opts.DiscussionCategory != "" {
+		rr.DiscussionCategoryName = github.String(opts.DiscussionCategory)
+	}
+
+	return rr
+}
+
+// EditPayload builds the changes applied to an existing release.
+func EditPayload(opts Options, existing *github.RepositoryRelease) *github.RepositoryRelease {
+	rr := &github.RepositoryRelease{}
+
+	if opts.Overwrite {
+		rr.Name = github.String(opts.Title)
+		rr.Body = github.String(opts.Note)
+	}
+
+	if opts.UpdatePrerelease {
+		rr.Prerelease = github.Bool(opts.Prerelease)
+	}
+
+	// only potentially change the draft value, if it's a draft right now
+	// i.e. a drafted release will be published, but a release won't be unpublished
+	if existing.GetDraft() && !opts.KeepDraft {
+		rr.Draft = github.Bool(opts.Draft)
+	}
+
+	return rr
+}
+
+// ListAssets returns all assets of a release.
+func (c *Client) ListAssets(ctx context.Context, id int64) ([]*github.ReleaseAsset, error) {
+	var assets []*github.ReleaseAsset
+	listOpts := &github.ListOptions{PerPage: 100}
+
+	for {
+		a, resp, err := c.GitHub.Repositories.ListReleaseAssets(ctx, c.Owner, c.Repo, id, listOpts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch existing assets: %w", err)
+		}
+
+		assets = append(assets, a...)
+
+		// stop iteration if there is no next page
+		if resp.NextPage == 0 {
+			return assets, nil
+		}
+
+		listOpts.Page = resp.NextPage
+	}
+}
+
+// UploadAssets uploads the files to a release by their base name, applying
+// the fileExists policy to assets which already exist. All files are checked
+// before the first existing asset gets replaced.
+func (c *Client) UploadAssets(ctx context.Context, id int64, files []string, fileExists string) error {
+	if !fileExistsValues[fileExists] {
+		return fmt.Errorf("invalid value for file_exists %s", fileExists)
+	}
+
+	for _, file := range files {
+		if info, err := os.Stat(file); err != nil {
+			return fmt.Errorf("failed to read %s artifact: %w", file, err)
+		} else if info.IsDir() {
+			return fmt.Errorf("%s artifact is a directory", file)
+		}
+	}
+
+	assets, err := c.ListAssets(ctx, id)
+
+	if err != nil {
+		return err
+	}
+
+	planned, err := PlanAssets(files, assets, fileExists)
+
+	if err != nil {
+		return err
+	}
+
+	for _, pa := range planned {
+		if pa.Action == ActionSkip {
+			continue
+		}
+
+		if err := c.uploadAsset(ctx, id, pa); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *Client) uploadAsset(ctx context.Context, id int64, pa PlannedAsset) error {
+	handle, err := os.Open(pa.File)
+
+	if err != nil {
+		return fmt.Errorf("failed to read %s artifact: %w", pa.File, err)
+	}
+
+	defer handle.Close()
+
+	if pa.Action == ActionReplace {
+		if _, err := c.GitHub.Repositories.DeleteReleaseAsset(ctx, c.Owner, c.Repo, pa.AssetID); err != nil {
+			return fmt.Errorf("failed to delete %s artifact: %w", pa.File, err)
+		}
+	}
+
+	if _, _, err := c.GitHub.Repositories.UploadReleaseAsset(ctx, c.Owner, c.Repo, id, &github.UploadOptions{Name: pa.Name}, handle); err != nil {
+		return fmt.Errorf("failed to upload %s artifact: %w", pa.File, err)
+	}
+
+	return nil
+}
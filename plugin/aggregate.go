@@ -0,0 +1,201 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const partialChecksumsPrefix = "partial-checksums-"
+
+var (
+	stageNameInvalid = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+	checksumAggregateValues = map[string]bool{
+		"":         true,
+		"partial":  true,
+		"finalize": true,
+	}
+)
+
+// writePartialChecksums writes the sha256 checksums of the files to a
+// partial checksums file for the stage. Every stage uses its own asset, so
+// parallel stages don't overwrite each other.
+func writePartialChecksums(files []string, stage, dir string) (string, error) {
+	var lines []string
+
+	for _, file := range files {
+		handle, err := os.Open(file)
+
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s artifact: %w", file, err)
+		}
+
+		hash, err := checksum(handle, "sha256")
+		handle.Close()
+
+		if err != nil {
+			return "", err
+		}
+
+		lines = append(lines, fmt.Sprintf("%s  %s", hash, path.Base(file)))
+	}
+
+	name := filepath.Join(dir, partialChecksumsPrefix+stageNameInvalid.ReplaceAllString(stage, "-")+".txt")
+
+	if err := ioutil.WriteFile(name, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		return "", err
+	}
+
+	return name, nil
+}
+
+// mergeChecksums combines checksum files, sorted by file name without
+// duplicates. Conflicting checksums for the same file are an error.
+func mergeChecksums(contents []string) (string, error) {
+	hashes := make(map[string]string)
+
+	for _, content := range contents {
+		for _, line := range strings.Split(content, "\n") {
+			fields := strings.Fields(line)
+
+			if len(fields) != 2 {
+				continue
+			}
+
+			if existing, ok := hashes[fields[1]]; ok && existing != fields[0] {
+				return "", fmt.Errorf("conflicting checksums for %s", fields[1])
+			}
+
+			hashes[fields[1]] = fields[0]
+		}
+	}
+
+	names := make([]string, 0, len(hashes))
+	for name := range hashes {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	var sb strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&sb, "%s  %s\n", hashes[name], name)
+	}
+
+	return sb.String(), nil
+}
+
+// signChecksums runs the sign command with the checksums file as last
+// argument and writes its output to a detached signature next to it.
+func signChecksums(command, file string) (string, error) {
+	args := strings.Fields(command)
+
+	if len(args) == 0 {
+		return "", errors.New("empty sign command")
+	}
+
+	out, err := exec.Command(args[0], append(args[1:], file)...).Output()
+
+	if err != nil {
+		return "", fmt.Errorf("failed to sign %s: %w", file, err)
+	}
+
+	signature := file + ".sig"
+
+	if err := ioutil.WriteFile(signature, out, 0644); err != nil {
+		return "", err
+	}
+
+	return signature, nil
+}
+
+// finalizeChecksums merges the partial checksums of all stages into the
+// target file, signs it if a sign command is given and deletes the partial
+// assets.
+func (rc *releaseClient) finalizeChecksums(id int64, target, signCommand string) error {
+	assets, err := rc.listAssets(id)
+
+	if err != nil {
+		return err
+	}
+
+	var contents []string
+
+	for _, asset := range assets {
+		if !strings.HasPrefix(asset.GetName(), partialChecksumsPrefix) {
+			continue
+		}
+
+		handle, err := rc.downloadAsset(asset)
+
+		if err != nil {
+			return err
+		}
+
+		b, err := ioutil.ReadAll(handle)
+		handle.Close()
+		os.Remove(handle.Name())
+
+		if err != nil {
+			return err
+		}
+
+		contents = append(contents, string(b))
+	}
+
+	if len(contents) == 0 {
+		return fmt.Errorf("no partial checksums found")
+	}
+
+	merged, err := mergeChecksums(contents)
+
+	if err != nil {
+		return err
+	}
+
+	if err := ioutil.WriteFile(target, []byte(merged), 0644); err != nil {
+		return err
+	}
+
+	files := []string{target}
+
+	if signCommand != "" {
+		signature, err := signChecksums(signCommand, target)
+
+		if err != nil {
+			return err
+		}
+
+		files = append(files, signature)
+	}
+
+	if err := rc.uploadFiles(id, files); err != nil {
+		return err
+	}
+
+	for _, asset := range assets {
+		if !strings.HasPrefix(asset.GetName(), partialChecksumsPrefix) {
+			continue
+		}
+
+		if _, err := rc.Client.Repositories.DeleteReleaseAsset(rc.Context, rc.Owner, rc.Repo, asset.GetID()); err != nil {
+			return fmt.Errorf("failed to delete %s artifact: %w", asset.GetName(), err)
+		}
+	}
+
+	fmt.Fprintf(rc.Out, "Successfully merged %d partial checksums into %s\n", len(contents), target)
+	return nil
+}
@@ -0,0 +1,162 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+
+	"github.com/google/go-github/v44/github"
+)
+
+var stableVersion = regexp.MustCompile(`^(v?)(\d+)\.(\d+)\.(\d+)$`)
+
+// aliasTags returns the major and minor version tags of a stable version
+// tag, keeping its v prefix. Prereleases don't move any alias.
+func aliasTags(tag string) []string {
+	m := stableVersion.FindStringSubmatch(tag)
+
+	if m == nil {
+		return nil
+	}
+
+	return []string{
+		m[1] + m[2],
+		m[1] + m[2] + "." + m[3],
+	}
+}
+
+// moveAliasTag points the alias tag to the commit. Existing aliases are only
+// moved to newer versions than the one they point to, or, without a version
+// tag at their commit, to commits their current commit is an ancestor of.
+// Aliases that can't be moved are skipped with a warning.
+func (rc *releaseClient) moveAliasTag(alias string) error {
+	ref, resp, err := rc.Client.Git.GetRef(rc.Context, rc.Owner, rc.Repo, "tags/"+alias)
+
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		ref = &github.Reference{
+			Ref:    github.String("refs/tags/" + alias),
+			Object: &github.GitObject{SHA: github.String(rc.Commit)},
+		}
+
+		if _, _, err := rc.Client.Git.CreateRef(rc.Context, rc.Owner, rc.Repo, ref); err != nil {
+			return fmt.Errorf("failed to create tag %s: %w", alias, err)
+		}
+
+		fmt.Fprintf(rc.Out, "Successfully created alias tag %s\n", alias)
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to get tag %s: %w", alias, err)
+	}
+
+	current := ref.GetObject().GetSHA()
+
+	// annotated tags point to a tag object instead of the commit
+	if ref.GetObject().GetType() == "tag" {
+		tag, _, err := rc.Client.Git.GetTag(rc.Context, rc.Owner, rc.Repo, current)
+
+		if err != nil {
+			return fmt.Errorf("failed to get tag %s: %w", alias, err)
+		}
+
+		current = tag.GetObject().GetSHA()
+	}
+
+	if current == rc.Commit {
+		fmt.Fprintf(rc.Out, "Alias tag %s already points to %s\n", alias, rc.Commit)
+		return nil
+	}
+
+	version, err := rc.aliasedVersion(alias, current)
+
+	if err != nil {
+		return err
+	}
+
+	if version != "" {
+		if compareVersions(rc.Tag, version) <= 0 {
+			fmt.Fprintf(rc.Out, "Warning: skipping alias tag %s, which points to %s, not older than %s\n", alias, version, rc.Tag)
+			return nil
+		}
+	} else {
+		comparison, _, err := rc.Client.Repositories.CompareCommits(rc.Context, rc.Owner, rc.Repo, current, rc.Commit, nil)
+
+		if err != nil {
+			return fmt.Errorf("failed to compare %s with %s: %w", alias, rc.Commit, err)
+		}
+
+		if comparison.GetStatus() != "ahead" {
+			fmt.Fprintf(rc.Out, "Warning: skipping alias tag %s, %s is %s of its commit %s\n", alias, rc.Commit, comparison.GetStatus(), current)
+			return nil
+		}
+	}
+
+	ref.Object = &github.GitObject{SHA: github.String(rc.Commit)}
+
+	if _, _, err := rc.Client.Git.UpdateRef(rc.Context, rc.Owner, rc.Repo, ref, true); err != nil {
+		return fmt.Errorf("failed to move tag %s: %w", alias, err)
+	}
+
+	fmt.Fprintf(rc.Out, "Successfully moved alias tag %s to %s\n", alias, rc.Commit)
+	return nil
+}
+
+// aliasedVersion returns the highest version tag at the commit that has the
+// alias, or an empty string if there is none.
+func (rc *releaseClient) aliasedVersion(alias, commit string) (string, error) {
+	var version string
+	listOpts := &github.ListOptions{PerPage: 100}
+
+	for {
+		page, resp, err := rc.Client.Repositories.ListTags(rc.Context, rc.Owner, rc.Repo, listOpts)
+		if err != nil {
+			return "", fmt.Errorf("failed to list tags: %w", err)
+		}
+
+		for _, tag := range page {
+			if tag.GetCommit().GetSHA() != commit {
+				continue
+			}
+
+			for _, a := range aliasTags(tag.GetName()) {
+				if a == alias && (version == "" || compareVersions(tag.GetName(), version) > 0) {
+					version = tag.GetName()
+				}
+			}
+		}
+
+		// stop iteration if there is no next page
+		if resp.NextPage == 0 {
+			break
+		}
+
+		listOpts.Page = resp.NextPage
+	}
+
+	return version, nil
+}
+
+// compareVersions compares two stable version tags, returning a negative
+// number if a is older than b, zero if they are equal and a positive number
+// otherwise.
+func compareVersions(a, b string) int {
+	ma, mb := stableVersion.FindStringSubmatch(a), stableVersion.FindStringSubmatch(b)
+
+	for i := 2; i <= 4; i++ {
+		x, _ := strconv.Atoi(ma[i])
+		y, _ := strconv.Atoi(mb[i])
+
+		if x != y {
+			return x - y
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestAliasTags(t *testing.T) {
+	tests := map[string][]string{
+		"v1.2.3":     {"v1", "v1.2"},
+		"2.0.10":     {"2", "2.0"},
+		"v1.2.3-rc1": nil,
+		"v1.2":       nil,
+		"snapshot":   nil,
+	}
+
+	for tag, expected := range tests {
+		if actual := aliasTags(tag); !reflect.DeepEqual(actual, expected) {
+			t.Errorf("Unexpected aliases of %s (Got: %v, Expected: %v)", tag, actual, expected)
+		}
+	}
+}
+
+func TestMoveAliasTag(t *testing.T) {
+	tests := []struct {
+		name     string
+		alias    string
+		tags     string
+		status   string
+		expected string
+	}{
+		{name: "missing", alias: "v2", expected: "POST"},
+		{name: "ahead", alias: "v1", tags: `[]`, status: "ahead", expected: "PATCH"},
+		{name: "behind", alias: "v1", tags: `[]`, status: "behind"},
+		{name: "diverged", alias: "v1", tags: `[]`, status: "diverged"},
+		{name: "newer version", alias: "v1.2", tags: `[{"name": "v1.2.3", "commit": {"sha": "old"}}]`, status: "diverged", expected: "PATCH"},
+		{name: "older version", alias: "v1", tags: `[{"name": "v1.3.0", "commit": {"sha": "old"}}]`, status: "ahead"},
+	}
+
+	for _, test := range tests {
+		var written string
+
+		rc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case r.URL.Path == "/repos/octocat/hello/git/ref/tags/v2":
+				http.NotFound(w, r)
+			case r.Method == http.MethodGet && r.URL.Path == "/repos/octocat/hello/git/ref/tags/"+test.alias:
+				fmt.Fprintf(w, `{"ref": "refs/tags/%s", "object": {"sha": "old", "type": "commit"}}`, test.alias)
+			case r.URL.Path == "/repos/octocat/hello/tags":
+				fmt.Fprint(w, test.tags)
+			case r.URL.Path == "/repos/octocat/hello/compare/old...new":
+				fmt.Fprintf(w, `{"status": %q}`, test.status)
+			case r.Method == http.MethodPost && r.URL.Path == "/repos/octocat/hello/git/refs",
+				r.Method == http.MethodPatch && r.URL.Path == "/repos/octocat/hello/git/refs/tags/"+test.alias:
+				written = r.Method
+				fmt.Fprintf(w, `{"ref": "refs/tags/%s", "object": {"sha": "new"}}`, test.alias)
+			default:
+				http.NotFound(w, r)
+			}
+		})
+
+		rc.Tag = "v1.2.4"
+		rc.Commit = "new"
+
+		if err := rc.moveAliasTag(test.alias); err != nil {
+			t.Errorf("Unexpected error of %s: %s", test.name, err)
+		}
+
+		if written != test.expected {
+			t.Errorf("Unexpected write of %s (Got: %q, Expected: %q)", test.name, written, test.expected)
+		}
+	}
+}
@@ -0,0 +1,392 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"debug/buildinfo"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+var (
+	bundleFormats = map[string]bool{
+		"tar.gz":  true,
+		"tar.zst": true,
+		"zip":     true,
+	}
+
+	bundlePermissionsValues = map[string]bool{
+		"preserve":   true,
+		"normalize":  true,
+		"executable": true,
+	}
+)
+
+// archiveOptions control how archive entries are written.
+type archiveOptions struct {
+	// Permissions is the policy for the file modes of the entries.
+	Permissions string
+
+	// Reproducible sorts the entries and strips owner and timestamp data.
+	Reproducible bool
+
+	// ModTime is used for all entries of a reproducible archive.
+	ModTime time.Time
+
+	// BuildInfo is a file added to the root of every archive.
+	BuildInfo string
+}
+
+// buildInfo describes the build which produced the archive.
+type buildInfo struct {
+	Commit    string `json:"commit"`
+	Tag       string `json:"tag"`
+	BuildURL  string `json:"build_url"`
+	GoVersion string `json:"go_version,omitempty"`
+}
+
+// writeBuildInfo writes the build info as json.
+func writeBuildInfo(file string, info buildInfo) error {
+	b, err := json.MarshalIndent(info, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(file, append(b, '\n'), 0644)
+}
+
+// binariesGoVersion returns the go versions the go binaries among the files
+// were built with, joined by commas if they differ.
+func binariesGoVersion(files []string) string {
+	var versions []string
+	seen := make(map[string]bool)
+
+	for _, file := range files {
+		info, err := buildinfo.ReadFile(file)
+
+		// not a go binary
+		if err != nil || seen[info.GoVersion] {
+			continue
+		}
+
+		seen[info.GoVersion] = true
+		versions = append(versions, info.GoVersion)
+	}
+
+	sort.Strings(versions)
+	return strings.Join(versions, ", ")
+}
+
+// bundle describes an archive built from a set of files.
+type bundle struct {
+	Name         string   `json:"name"`
+	Files        []string `json:"files"`
+	Format       string   `json:"format"`
+	SkipIncludes bool     `json:"skip_includes"`
+}
+
+func parseBundles(input string) ([]bundle, error) {
+	var bundles []bundle
+
+	if err := json.Unmarshal([]byte(input), &bundles); err != nil {
+		return nil, err
+	}
+
+	for i, b := range bundles {
+		if b.Name == "" {
+			return nil, fmt.Errorf("bundle %d has no name", i)
+		}
+
+		if b.Format == "" {
+			bundles[i].Format = defaultBundleFormat(b.Name)
+		} else if !bundleFormats[b.Format] {
+			return nil, fmt.Errorf("invalid format %s for bundle %s", b.Format, b.Name)
+		}
+	}
+
+	return bundles, nil
+}
+
+// defaultBundleFormat follows the conventions of the platform of the bundle,
+// which is zip for windows and tar.gz otherwise.
+func defaultBundleFormat(name string) string {
+	if platform(name) == "Windows" {
+		return "zip"
+	}
+
+	return "tar.gz"
+}
+
+// writeBundles creates an archive for each bundle within dir and returns the
+// paths of the created archives. Files matching the includes are added to
+// every bundle unless they are skipped or already part of it.
+func writeBundles(out io.Writer, bundles []bundle, includes []string, dir string, opts archiveOptions) ([]string, error) {
+	var archives []string
+
+	extra, err := globRegularFiles(includes)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, b := range bundles {
+		files, err := globRegularFiles(b.Files)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if len(files) == 0 {
+			return nil, fmt.Errorf("failed to find any file for bundle %s", b.Name)
+		}
+
+		if !b.SkipIncludes {
+			names := make(map[string]bool)
+			for _, file := range files {
+				names[filepath.Base(file)] = true
+			}
+
+			for _, file := range extra {
+				if !names[filepath.Base(file)] {
+					names[filepath.Base(file)] = true
+					files = append(files, file)
+				}
+			}
+		}
+
+		if opts.BuildInfo != "" {
+			files = append(files, opts.BuildInfo)
+		}
+
+		target := filepath.Join(dir, b.Name+"."+b.Format)
+
+		if err := writeArchive(target, b.Format, files, opts); err != nil {
+			return nil, fmt.Errorf("failed to create bundle %s: %w", b.Name, err)
+		}
+
+		fmt.Fprintf(out, "Successfully created %s bundle with %d files\n", target, len(files))
+		archives = append(archives, target)
+	}
+
+	return archives, nil
+}
+
+func globRegularFiles(globs []string) ([]string, error) {
+	var files []string
+
+	for _, glob := range globs {
+		globed, err := filepath.Glob(glob)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to glob %s: %w", glob, err)
+		}
+
+		for _, file := range globed {
+			if info, err := os.Stat(file); err == nil && info.Mode().IsRegular() {
+				files = append(files, file)
+			}
+		}
+	}
+
+	return files, nil
+}
+
+// writeArchive stores the files by their base name in a new archive.
+func writeArchive(target, format string, files []string, opts archiveOptions) error {
+	names := make(map[string]bool)
+
+	for _, file := range files {
+		name := filepath.Base(file)
+
+		if names[name] {
+			return fmt.Errorf("duplicate file name %s", name)
+		}
+
+		names[name] = true
+	}
+
+	if opts.Reproducible {
+		files = append([]string(nil), files...)
+		sort.Slice(files, func(i, j int) bool {
+			return filepath.Base(files[i]) < filepath.Base(files[j])
+		})
+	}
+
+	f, err := os.Create(target)
+
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	switch format {
+	case "tar.gz":
+		err = writeTarGz(f, files, opts)
+	case "tar.zst":
+		err = writeTarZst(f, files, opts)
+	case "zip":
+		err = writeZip(f, files, opts)
+	default:
+		err = fmt.Errorf("archive format %s is not supported", format)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return f.Close()
+}
+
+func writeTarGz(w io.Writer, files []string, opts archiveOptions) error {
+	gw := gzip.NewWriter(w)
+
+	if err := writeTar(gw, files, opts); err != nil {
+		return err
+	}
+
+	return gw.Close()
+}
+
+func writeTarZst(w io.Writer, files []string, opts archiveOptions) error {
+	zw, err := zstd.NewWriter(w)
+
+	if err != nil {
+		return err
+	}
+
+	if err := writeTar(zw, files, opts); err != nil {
+		zw.Close()
+		return err
+	}
+
+	return zw.Close()
+}
+
+func writeTar(w io.Writer, files []string, opts archiveOptions) error {
+	tw := tar.NewWriter(w)
+
+	for _, file := range files {
+		info, err := os.Stat(file)
+
+		if err != nil {
+			return err
+		}
+
+		header, err := tar.FileInfoHeader(info, "")
+
+		if err != nil {
+			return err
+		}
+
+		header.Name = filepath.Base(file)
+		header.Mode = int64(entryMode(info.Mode(), opts.Permissions))
+
+		if opts.Reproducible {
+			header.ModTime = opts.ModTime
+			header.AccessTime = time.Time{}
+			header.ChangeTime = time.Time{}
+			header.Uid = 0
+			header.Gid = 0
+			header.Uname = ""
+			header.Gname = ""
+		}
+
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+
+		if err := copyFile(tw, file); err != nil {
+			return err
+		}
+	}
+
+	return tw.Close()
+}
+
+func writeZip(w io.Writer, files []string, opts archiveOptions) error {
+	zw := zip.NewWriter(w)
+
+	for _, file := range files {
+		info, err := os.Stat(file)
+
+		if err != nil {
+			return err
+		}
+
+		header, err := zip.FileInfoHeader(info)
+
+		if err != nil {
+			return err
+		}
+
+		header.Name = filepath.Base(file)
+		header.Method = zip.Deflate
+		header.SetMode(entryMode(info.Mode(), opts.Permissions))
+
+		if opts.Reproducible {
+			header.Modified = opts.ModTime
+		}
+
+		fw, err := zw.CreateHeader(header)
+
+		if err != nil {
+			return err
+		}
+
+		if err := copyFile(fw, file); err != nil {
+			return err
+		}
+	}
+
+	if err := zw.SetComment("permissions: " + opts.Permissions); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+// entryMode applies the permission policy to the mode of a regular file.
+func entryMode(mode os.FileMode, policy string) os.FileMode {
+	switch policy {
+	case "normalize":
+		if mode&0111 != 0 {
+			return 0755
+		}
+
+		return 0644
+	case "executable":
+		return 0755
+	}
+
+	return mode.Perm()
+}
+
+func copyFile(w io.Writer, file string) error {
+	handle, err := os.Open(file)
+
+	if err != nil {
+		return err
+	}
+
+	defer handle.Close()
+
+	_, err = io.Copy(w, handle)
+	return err
+}
@@ -0,0 +1,234 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestParseBundles(t *testing.T) {
+	bundles, err := parseBundles(`[{"name": "linux-amd64", "files": ["dist/linux-amd64/*"]}, {"name": "windows-amd64", "files": ["dist/windows-amd64/*"]}, {"name": "darwin-arm64", "files": ["dist/darwin-arm64/*"], "format": "tar.zst"}]`)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(bundles) != 3 {
+		t.Fatalf("Unexpected bundle count (Got: %d, Expected: 3)", len(bundles))
+	}
+
+	if bundles[0].Format != "tar.gz" {
+		t.Errorf("Unexpected default format (Got: %s, Expected: tar.gz)", bundles[0].Format)
+	}
+
+	if bundles[1].Format != "zip" {
+		t.Errorf("Unexpected windows default format (Got: %s, Expected: zip)", bundles[1].Format)
+	}
+
+	if bundles[2].Format != "tar.zst" {
+		t.Errorf("Unexpected format (Got: %s, Expected: tar.zst)", bundles[2].Format)
+	}
+
+	if _, err := parseBundles(`[{"name": "linux-amd64", "format": "rar"}]`); err == nil {
+		t.Error("Expected an error for an unsupported format")
+	}
+}
+
+func TestWriteBundles(t *testing.T) {
+	dir := t.TempDir()
+
+	for _, name := range []string{"bin/app", "bin/app.exe", "LICENSE"} {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	archives, err := writeBundles(
+		ioutil.Discard,
+		[]bundle{{Name: "linux-amd64", Files: []string{filepath.Join(dir, "bin", "app")}, Format: "zip"}},
+		[]string{filepath.Join(dir, "LICENSE*")},
+		dir,
+		archiveOptions{Permissions: "executable"},
+	)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := zip.OpenReader(archives[0])
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer r.Close()
+
+	if len(r.File) != 2 {
+		t.Errorf("Unexpected file count (Got: %d, Expected: 2)", len(r.File))
+	}
+
+	for _, f := range r.File {
+		if f.Mode().Perm() != 0755 {
+			t.Errorf("Unexpected mode for %s (Got: %o, Expected: 755)", f.Name, f.Mode().Perm())
+		}
+	}
+}
+
+func TestWriteBuildInfo(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "BUILD_INFO.json")
+
+	err := writeBuildInfo(file, buildInfo{
+		Commit:    "0123456789abcdef",
+		Tag:       "v1.0.0",
+		BuildURL:  "https://drone.example.com/o/r/1",
+		GoVersion: "go1.18",
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(file)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "{\n  \"commit\": \"0123456789abcdef\",\n  \"tag\": \"v1.0.0\",\n  \"build_url\": \"https://drone.example.com/o/r/1\",\n  \"go_version\": \"go1.18\"\n}\n"
+	if string(content) != expected {
+		t.Errorf("Unexpected build info (Got: %s, Expected: %s)", content, expected)
+	}
+}
+
+func TestBinariesGoVersion(t *testing.T) {
+	binary, err := os.Executable()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	text := filepath.Join(t.TempDir(), "README.md")
+
+	if err := os.WriteFile(text, []byte("readme"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if actual := binariesGoVersion([]string{binary, text, binary}); actual != runtime.Version() {
+		t.Errorf("Unexpected go version (Got: %s, Expected: %s)", actual, runtime.Version())
+	}
+
+	if actual := binariesGoVersion([]string{text}); actual != "" {
+		t.Errorf("Unexpected go version (Got: %s, Expected: empty)", actual)
+	}
+}
+
+func TestEntryMode(t *testing.T) {
+	tests := []struct {
+		mode     os.FileMode
+		policy   string
+		expected os.FileMode
+	}{
+		{0700, "preserve", 0700},
+		{0700, "normalize", 0755},
+		{0600, "normalize", 0644},
+		{0600, "executable", 0755},
+	}
+
+	for _, test := range tests {
+		if actual := entryMode(test.mode, test.policy); actual != test.expected {
+			t.Errorf("Unexpected mode for %o with %s (Got: %o, Expected: %o)", test.mode, test.policy, actual, test.expected)
+		}
+	}
+}
+
+func TestWriteArchiveReproducible(t *testing.T) {
+	dir := t.TempDir()
+	files := []string{filepath.Join(dir, "b"), filepath.Join(dir, "a")}
+
+	for _, file := range files {
+		if err := os.WriteFile(file, []byte(file), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	opts := archiveOptions{Permissions: "preserve", Reproducible: true, ModTime: time.Unix(315532800, 0).UTC()}
+	first := filepath.Join(dir, "first.tar.gz")
+	second := filepath.Join(dir, "second.tar.gz")
+
+	if err := writeArchive(first, "tar.gz", files, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Chtimes(files[0], time.Now(), time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writeArchive(second, "tar.gz", []string{files[1], files[0]}, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	a, _ := os.ReadFile(first)
+	b, _ := os.ReadFile(second)
+
+	if !bytes.Equal(a, b) {
+		t.Error("Expected reproducible archives to be identical")
+	}
+}
+
+func TestWriteArchiveZst(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "app")
+
+	if err := os.WriteFile(file, []byte("binary"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	target := filepath.Join(dir, "app.tar.zst")
+
+	if err := writeArchive(target, "tar.zst", []string{file}, archiveOptions{Permissions: "preserve"}); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(target)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer f.Close()
+
+	zr, err := zstd.NewReader(f)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer zr.Close()
+
+	header, err := tar.NewReader(zr).Next()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if header.Name != "app" {
+		t.Errorf("Unexpected entry (Got: %s, Expected: app)", header.Name)
+	}
+}
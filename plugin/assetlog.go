@@ -0,0 +1,41 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+// logMu serializes the writes of the asset logs, so lines of different
+// assets never interleave within a line.
+var logMu sync.Mutex
+
+// assetLog prefixes the log lines of a single asset with its name.
+type assetLog struct {
+	out    io.Writer
+	prefix string
+}
+
+func newAssetLog(out io.Writer, name string) *assetLog {
+	return &assetLog{
+		out:    out,
+		prefix: "[" + name + "] ",
+	}
+}
+
+// Printf writes a line with the prefix of the asset.
+func (l *assetLog) Printf(format string, a ...interface{}) {
+	logMu.Lock()
+	defer logMu.Unlock()
+
+	fmt.Fprint(l.out, l.prefix+fmt.Sprintf(format, a...))
+}
+
+func (rc *releaseClient) assetLog(name string) *assetLog {
+	return newAssetLog(rc.Out, name)
+}
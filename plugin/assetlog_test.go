@@ -0,0 +1,22 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestAssetLog(t *testing.T) {
+	var out bytes.Buffer
+
+	log := newAssetLog(&out, "app")
+	log.Printf("Uploading %s\n", "dist/app")
+
+	if got, expected := out.String(), "[app] Uploading dist/app\n"; got != expected {
+		t.Errorf("Unexpected log (Got: %q, Expected: %q)", got, expected)
+	}
+}
@@ -0,0 +1,245 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+const intotoPayloadType = "application/vnd.in-toto+json"
+
+// envelope is a DSSE envelope wrapping a signed statement.
+type envelope struct {
+	PayloadType string            `json:"payloadType"`
+	Payload     string            `json:"payload"`
+	Signatures  []json.RawMessage `json:"signatures"`
+}
+
+// bindAttestation binds the statements of an attestation file to the
+// digests of the assets. Subjects without a digest get the digest of the
+// asset with the same name, an empty subject list gets all assets. Signed
+// statements can't be changed, so their subjects must match as they are.
+func bindAttestation(content []byte, assets []subject) ([]byte, error) {
+	digests := make(map[string]string)
+
+	for _, asset := range assets {
+		digests[asset.Name] = asset.Digest["sha256"]
+	}
+
+	var (
+		out   bytes.Buffer
+		lines int
+	)
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(nil, 16*1024*1024)
+
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+
+		if len(line) == 0 {
+			continue
+		}
+
+		lines++
+
+		var probe struct {
+			PayloadType string `json:"payloadType"`
+		}
+
+		if err := json.Unmarshal(line, &probe); err != nil {
+			return nil, fmt.Errorf("line %d is no valid json: %w", lines, err)
+		}
+
+		if probe.PayloadType != "" {
+			if err := checkEnvelope(line, digests); err != nil {
+				return nil, fmt.Errorf("line %d: %w", lines, err)
+			}
+
+			out.Write(line)
+			out.WriteByte('\n')
+			continue
+		}
+
+		b, err := bindStatement(line, assets, digests)
+
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lines, err)
+		}
+
+		out.Write(b)
+		out.WriteByte('\n')
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if lines == 0 {
+		return nil, fmt.Errorf("no statements found")
+	}
+
+	return out.Bytes(), nil
+}
+
+// bindStatement adds the missing subject digests to an unsigned statement.
+// The statement is patched as a generic document, so fields unknown to the
+// plugin are kept.
+func bindStatement(line []byte, assets []subject, digests map[string]string) ([]byte, error) {
+	var doc map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(line))
+	decoder.UseNumber()
+
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("no statement: %w", err)
+	}
+
+	subjects, _ := doc["subject"].([]interface{})
+
+	if len(subjects) == 0 {
+		for _, asset := range assets {
+			subjects = append(subjects, map[string]interface{}{
+				"name":   asset.Name,
+				"digest": asset.Digest,
+			})
+		}
+	}
+
+	for _, entry := range subjects {
+		sub, ok := entry.(map[string]interface{})
+
+		if !ok {
+			return nil, fmt.Errorf("subject is no object")
+		}
+
+		if digest, _ := sub["digest"].(map[string]interface{}); len(digest) > 0 {
+			continue
+		}
+
+		name, _ := sub["name"].(string)
+		digest, ok := digests[filepath.Base(name)]
+
+		if !ok {
+			return nil, fmt.Errorf("subject %s is not a released asset", name)
+		}
+
+		sub["digest"] = map[string]string{"sha256": digest}
+	}
+
+	doc["subject"] = subjects
+	b, err := json.Marshal(doc)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var s statement
+
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, fmt.Errorf("no statement: %w", err)
+	}
+
+	if err := checkSubjects(s, digests); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// checkEnvelope validates the subjects of a signed statement.
+func checkEnvelope(line []byte, digests map[string]string) error {
+	var e envelope
+
+	if err := json.Unmarshal(line, &e); err != nil {
+		return err
+	}
+
+	if e.PayloadType != intotoPayloadType {
+		return fmt.Errorf("unsupported payload type %s", e.PayloadType)
+	}
+
+	payload, err := base64.StdEncoding.DecodeString(e.Payload)
+
+	if err != nil {
+		return fmt.Errorf("failed to decode payload: %w", err)
+	}
+
+	var s statement
+
+	if err := json.Unmarshal(payload, &s); err != nil {
+		return fmt.Errorf("payload is no statement: %w", err)
+	}
+
+	return checkSubjects(s, digests)
+}
+
+// checkSubjects ensures every subject is a released asset with the same
+// sha256 digest.
+func checkSubjects(s statement, digests map[string]string) error {
+	if len(s.Subject) == 0 {
+		return fmt.Errorf("statement has no subjects")
+	}
+
+	for _, sub := range s.Subject {
+		name := filepath.Base(sub.Name)
+		expected, ok := digests[name]
+
+		if !ok {
+			return fmt.Errorf("subject %s is not a released asset", sub.Name)
+		}
+
+		digest, ok := sub.Digest["sha256"]
+
+		if !ok {
+			return fmt.Errorf("subject %s has no sha256 digest", sub.Name)
+		}
+
+		if !strings.EqualFold(digest, expected) {
+			return fmt.Errorf("digest of subject %s doesn't match the asset (Got: %s, Expected: %s)", sub.Name, digest, expected)
+		}
+	}
+
+	return nil
+}
+
+// writeAttestations binds the attestation files to the assets and writes
+// them into the directory.
+func writeAttestations(out io.Writer, files []string, assets []subject, dir string) ([]string, error) {
+	var written []string
+
+	for _, file := range files {
+		content, err := ioutil.ReadFile(file)
+
+		if err != nil {
+			return nil, err
+		}
+
+		bound, err := bindAttestation(content, assets)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid attestation %s: %w", file, err)
+		}
+
+		target := filepath.Join(dir, filepath.Base(file))
+
+		if err := ioutil.WriteFile(target, bound, 0644); err != nil {
+			return nil, err
+		}
+
+		fmt.Fprintf(out, "Successfully bound attestation %s to the assets\n", file)
+		written = append(written, target)
+	}
+
+	return written, nil
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestBindAttestation(t *testing.T) {
+	assets := []subject{
+		{Name: "app", Digest: map[string]string{"sha256": "aaaa"}},
+		{Name: "app.exe", Digest: map[string]string{"sha256": "bbbb"}},
+	}
+
+	content := `{"_type":"https://in-toto.io/Statement/v1","subject":[{"name":"dist/app","uri":"pkg:generic/app","annotations":{"os":"linux"}}],"predicateType":"https://example.com/test/v1","predicate":{"passed":true,"count":12345678901234567890},"x-build":"nightly"}`
+
+	bound, err := bindAttestation([]byte(content), assets)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var s statement
+
+	if err := json.Unmarshal(bound, &s); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := s.Subject[0].Digest["sha256"]; got != "aaaa" {
+		t.Errorf("Unexpected digest (Got: %s, Expected: %s)", got, "aaaa")
+	}
+
+	if !strings.Contains(string(bound), "12345678901234567890") {
+		t.Errorf("Unexpected predicate (Got: %s)", bound)
+	}
+
+	for _, field := range []string{`"uri":"pkg:generic/app"`, `"annotations":{"os":"linux"}`, `"x-build":"nightly"`} {
+		if !strings.Contains(string(bound), field) {
+			t.Errorf("Unexpected statement without %s (Got: %s)", field, bound)
+		}
+	}
+
+	mismatch := `{"_type":"https://in-toto.io/Statement/v1","subject":[{"name":"app.exe","digest":{"sha256":"cccc"}}],"predicateType":"https://example.com/test/v1","predicate":{}}`
+
+	if _, err := bindAttestation([]byte(mismatch), assets); err == nil {
+		t.Error("Expected an error for a mismatched digest")
+	}
+
+	payload := base64.StdEncoding.EncodeToString([]byte(`{"_type":"https://in-toto.io/Statement/v1","subject":[{"name":"app.exe","digest":{"sha256":"bbbb"}}],"predicateType":"https://example.com/test/v1","predicate":{}}`))
+	signed := `{"payloadType":"application/vnd.in-toto+json","payload":"` + payload + `","signatures":[{"sig":"c2ln"}]}`
+
+	bound, err = bindAttestation([]byte(signed+"\n"), assets)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := strings.TrimSpace(string(bound)); got != signed {
+		t.Errorf("Unexpected envelope (Got: %s, Expected: %s)", got, signed)
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"time"
+)
+
+// canaryStage prepares the release client for the stage of a canary
+// release. The first run publishes a prerelease with a subset of the files,
+// the next run upgrades it to the full release once the soak time passed or
+// the signal file exists. It returns the files to upload.
+func (p *Plugin) canaryStage(rc *releaseClient, files []string) ([]string, error) {
+	release, err := rc.getRelease()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve a release: %w", err)
+	}
+
+	stage := ""
+	if release != nil {
+		stage = readMetadata(release.GetBody())["stage"]
+	}
+
+	switch stage {
+	case "":
+		fmt.Fprintf(p.out(), "Publishing canary prerelease for %s\n", rc.Tag)
+
+		rc.Prerelease = true
+		rc.UpdatePrerelease = true
+		rc.Metadata["stage"] = "canary"
+
+		if p.settings.CanaryNote != "" {
+			rc.Note = p.settings.CanaryNote
+		}
+
+		var canary []string
+
+		for _, file := range files {
+			for _, pattern := range p.settings.CanaryFiles.Value() {
+				if ok, _ := path.Match(pattern, path.Base(file)); ok {
+					canary = append(canary, file)
+					break
+				}
+			}
+		}
+
+		return canary, nil
+	case "canary":
+		if !p.canaryReady(release.GetPublishedAt().Time) {
+			return nil, fmt.Errorf("canary release %s is still soaking", rc.Tag)
+		}
+
+		fmt.Fprintf(p.out(), "Upgrading canary release %s to the full release\n", rc.Tag)
+
+		rc.Metadata["stage"] = "full"
+		rc.Overwrite = true
+		rc.UpdatePrerelease = true
+
+		return files, nil
+	}
+
+	return files, nil
+}
+
+// canaryReady checks whether the soak time passed or the signal file exists.
+func (p *Plugin) canaryReady(published time.Time) bool {
+	if p.settings.CanarySignal != "" {
+		if _, err := os.Stat(p.settings.CanarySignal); err == nil {
+			fmt.Fprintf(p.out(), "Found canary signal file %s\n", p.settings.CanarySignal)
+			return true
+		}
+	}
+
+	if p.settings.CanarySoak > 0 && time.Since(published) >= p.settings.CanarySoak {
+		fmt.Fprintf(p.out(), "Canary soaked for %s\n", time.Since(published).Round(time.Second))
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/google/go-github/v44/github"
+)
+
+// cardSchema is the adaptive card template rendering the card data, served
+// from the card.json at the root of the default branch.
+const cardSchema = "https://raw.githubusercontent.com/drone-plugins/drone-github-release/master/card.json"
+
+// releaseCard is the data of the card shown in the drone ui.
+type releaseCard struct {
+	Tag    string `json:"tag"`
+	Title  string `json:"title"`
+	Assets int    `json:"assets"`
+	Size   string `json:"size"`
+	URL    string `json:"url"`
+}
+
+func buildCard(release *github.RepositoryRelease, assets []*github.ReleaseAsset) releaseCard {
+	var size int64
+
+	for _, asset := range assets {
+		size += int64(asset.GetSize())
+	}
+
+	title := release.GetName()
+	if title == "" {
+		title = release.GetTagName()
+	}
+
+	return releaseCard{
+		Tag:    release.GetTagName(),
+		Title:  title,
+		Assets: len(assets),
+		Size:   humanSize(size),
+		URL:    release.GetHTMLURL(),
+	}
+}
+
+// writeCard writes the card to the path, which drone may point to stdout to
+// parse the card from the logs.
+func writeCard(path string, stdout io.Writer, card releaseCard) error {
+	b, err := json.Marshal(map[string]interface{}{
+		"schema": cardSchema,
+		"data":   card,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if path == "/dev/stdout" {
+		_, err := fmt.Fprintf(stdout, "\u001B]1338;%s\u001B]0m\n", base64.StdEncoding.EncodeToString(b))
+		return err
+	}
+
+	return ioutil.WriteFile(path, b, 0644)
+}
+
+// writeCard summarizes the release in a card for the drone ui, bypassing the
+// output filter so quiet and json logs keep the card intact.
+func (p *Plugin) writeCard(rc *releaseClient, release *github.RepositoryRelease) error {
+	assets, err := rc.listAssets(release.GetID())
+
+	if err != nil {
+		return err
+	}
+
+	return writeCard(p.settings.CardPath, p.results(), buildCard(release, assets))
+}
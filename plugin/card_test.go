@@ -0,0 +1,64 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v44/github"
+)
+
+func TestWriteCard(t *testing.T) {
+	release := &github.RepositoryRelease{
+		TagName: github.String("v1.0.0"),
+		HTMLURL: github.String("https://github.com/octocat/hello/releases/tag/v1.0.0"),
+	}
+
+	card := buildCard(release, []*github.ReleaseAsset{
+		{Size: github.Int(1024)},
+		{Size: github.Int(1024)},
+	})
+
+	expected := releaseCard{Tag: "v1.0.0", Title: "v1.0.0", Assets: 2, Size: "2.0 KiB", URL: release.GetHTMLURL()}
+
+	if card != expected {
+		t.Errorf("Unexpected card (Got: %+v, Expected: %+v)", card, expected)
+	}
+
+	file := filepath.Join(t.TempDir(), "card.json")
+
+	if err := writeCard(file, nil, card); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	b, _ := ioutil.ReadFile(file)
+	written := struct {
+		Schema string      `json:"schema"`
+		Data   releaseCard `json:"data"`
+	}{}
+
+	if err := json.Unmarshal(b, &written); err != nil || written.Schema != cardSchema || written.Data != card {
+		t.Errorf("Unexpected card file (Got: %s)", b)
+	}
+
+	var out bytes.Buffer
+
+	if err := writeCard("/dev/stdout", &out, card); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	encoded := strings.TrimSuffix(strings.TrimPrefix(out.String(), "\u001B]1338;"), "\u001B]0m\n")
+
+	if decoded, _ := base64.StdEncoding.DecodeString(encoded); !bytes.Equal(decoded, b) {
+		t.Errorf("Unexpected card output (Got: %q)", out.String())
+	}
+}
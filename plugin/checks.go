@@ -0,0 +1,232 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v44/github"
+)
+
+// checkApprovals verifies that a merged pull request containing the commit
+// got at least the required number of approvals.
+func (rc *releaseClient) checkApprovals(required int) error {
+	pulls, _, err := rc.Client.PullRequests.ListPullRequestsWithCommit(rc.Context, rc.Owner, rc.Repo, rc.Commit, nil)
+
+	if err != nil {
+		return fmt.Errorf("failed to list pull requests for commit %s: %w", rc.Commit, err)
+	}
+
+	for _, pull := range pulls {
+		if pull.MergedAt == nil {
+			continue
+		}
+
+		approvals, err := rc.countApprovals(pull.GetNumber())
+
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(rc.Out, "Pull request #%d has %d of %d required approvals\n", pull.GetNumber(), approvals, required)
+
+		if approvals >= required {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("no merged pull request with %d approvals found for commit %s", required, rc.Commit)
+}
+
+// countApprovals counts the reviewers whose latest review is an approval.
+func (rc *releaseClient) countApprovals(number int) (int, error) {
+	states := make(map[string]string)
+	listOpts := &github.ListOptions{PerPage: 100}
+
+	for {
+		reviews, resp, err := rc.Client.PullRequests.ListReviews(rc.Context, rc.Owner, rc.Repo, number, listOpts)
+		if err != nil {
+			return 0, fmt.Errorf("failed to list reviews of pull request #%d: %w", number, err)
+		}
+
+		// reviews are returned in chronological order
+		for _, review := range reviews {
+			if review.GetState() != "COMMENTED" {
+				states[review.GetUser().GetLogin()] = review.GetState()
+			}
+		}
+
+		// stop iteration if there is no next page
+		if resp.NextPage == 0 {
+			break
+		}
+
+		listOpts.Page = resp.NextPage
+	}
+
+	approvals := 0
+	for _, state := range states {
+		if state == "APPROVED" {
+			approvals++
+		}
+	}
+
+	return approvals, nil
+}
+
+// checkForkSafety refuses to release on forks and when the build was
+// triggered from a different source repository than the release target.
+func (rc *releaseClient) checkForkSafety(sourceRepo string) error {
+	repo, _, err := rc.Client.Repositories.Get(rc.Context, rc.Owner, rc.Repo)
+
+	if err != nil {
+		return fmt.Errorf("failed to retrieve repository: %w", err)
+	}
+
+	if repo.GetFork() {
+		return fmt.Errorf("repository %s is a fork of %s", repo.GetFullName(), repo.GetParent().GetFullName())
+	}
+
+	if sourceRepo != "" && !strings.EqualFold(sourceRepo, repo.GetFullName()) {
+		return fmt.Errorf("source repository %s differs from release repository %s", sourceRepo, repo.GetFullName())
+	}
+
+	fmt.Fprintf(rc.Out, "Repository %s passed the fork safety check\n", repo.GetFullName())
+	return nil
+}
+
+// waitForChecks polls the combined status and check runs of the commit
+// until all of them succeeded, one failed or the timeout is reached.
+func (rc *releaseClient) waitForChecks(ignore []string, timeout, interval time.Duration) error {
+	deadline := time.Now().Add(timeout)
+
+	for {
+		pending, failed, err := rc.commitChecks(ignore)
+
+		if err != nil {
+			return err
+		}
+
+		if len(failed) > 0 {
+			return fmt.Errorf("checks failed for commit %s: %s", rc.Commit, strings.Join(failed, ", "))
+		}
+
+		if len(pending) == 0 {
+			fmt.Fprintf(rc.Out, "All checks passed for commit %s\n", rc.Commit)
+			return nil
+		}
+
+		if time.Now().Add(interval).After(deadline) {
+			return fmt.Errorf("timed out waiting for checks of commit %s: %s", rc.Commit, strings.Join(pending, ", "))
+		}
+
+		fmt.Fprintf(rc.Out, "Waiting for pending checks: %s\n", strings.Join(pending, ", "))
+		time.Sleep(interval)
+	}
+}
+
+// commitChecks lists the pending and failed statuses and check runs.
+func (rc *releaseClient) commitChecks(ignore []string) ([]string, []string, error) {
+	var (
+		statuses []*github.RepoStatus
+		runs     []*github.CheckRun
+	)
+
+	listOpts := &github.ListOptions{PerPage: 100}
+
+	for {
+		combined, resp, err := rc.Client.Repositories.GetCombinedStatus(rc.Context, rc.Owner, rc.Repo, rc.Commit, listOpts)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get status of commit %s: %w", rc.Commit, err)
+		}
+
+		statuses = append(statuses, combined.Statuses...)
+
+		// stop iteration if there is no next page
+		if resp.NextPage == 0 {
+			break
+		}
+
+		listOpts.Page = resp.NextPage
+	}
+
+	checkOpts := &github.ListCheckRunsOptions{ListOptions: github.ListOptions{PerPage: 100}}
+
+	for {
+		result, resp, err := rc.Client.Checks.ListCheckRunsForRef(rc.Context, rc.Owner, rc.Repo, rc.Commit, checkOpts)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to list check runs of commit %s: %w", rc.Commit, err)
+		}
+
+		runs = append(runs, result.CheckRuns...)
+
+		// stop iteration if there is no next page
+		if resp.NextPage == 0 {
+			break
+		}
+
+		checkOpts.Page = resp.NextPage
+	}
+
+	pending, failed := evaluateChecks(statuses, runs, ignore)
+	return pending, failed, nil
+}
+
+// evaluateChecks sorts the statuses and check runs into pending and failed
+// ones, skipping those matching an ignore pattern like the running build.
+func evaluateChecks(statuses []*github.RepoStatus, runs []*github.CheckRun, ignore []string) ([]string, []string) {
+	var pending, failed []string
+
+	ignored := func(name string) bool {
+		for _, pattern := range ignore {
+			if ok, _ := path.Match(pattern, name); ok {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	for _, status := range statuses {
+		if ignored(status.GetContext()) {
+			continue
+		}
+
+		switch status.GetState() {
+		case "success":
+		case "pending":
+			pending = append(pending, status.GetContext())
+		default:
+			failed = append(failed, status.GetContext())
+		}
+	}
+
+	for _, run := range runs {
+		if ignored(run.GetName()) {
+			continue
+		}
+
+		if run.GetStatus() != "completed" {
+			pending = append(pending, run.GetName())
+			continue
+		}
+
+		switch run.GetConclusion() {
+		case "success", "neutral", "skipped":
+		default:
+			failed = append(failed, run.GetName())
+		}
+	}
+
+	sort.Strings(pending)
+	sort.Strings(failed)
+
+	return pending, failed
+}
@@ -0,0 +1,38 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/go-github/v44/github"
+)
+
+func TestEvaluateChecks(t *testing.T) {
+	statuses := []*github.RepoStatus{
+		{Context: github.String("continuous-integration/drone/tag"), State: github.String("pending")},
+		{Context: github.String("coverage"), State: github.String("success")},
+		{Context: github.String("security"), State: github.String("failure")},
+	}
+
+	runs := []*github.CheckRun{
+		{Name: github.String("lint"), Status: github.String("completed"), Conclusion: github.String("success")},
+		{Name: github.String("e2e"), Status: github.String("in_progress")},
+		{Name: github.String("docs"), Status: github.String("completed"), Conclusion: github.String("skipped")},
+		{Name: github.String("unit"), Status: github.String("completed"), Conclusion: github.String("timed_out")},
+	}
+
+	pending, failed := evaluateChecks(statuses, runs, []string{"continuous-integration/drone/*"})
+
+	if expected := []string{"e2e"}; !reflect.DeepEqual(pending, expected) {
+		t.Errorf("Unexpected pending checks (Got: %v, Expected: %v)", pending, expected)
+	}
+
+	if expected := []string{"security", "unit"}; !reflect.DeepEqual(failed, expected) {
+		t.Errorf("Unexpected failed checks (Got: %v, Expected: %v)", failed, expected)
+	}
+}
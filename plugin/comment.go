@@ -0,0 +1,149 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"fmt"
+
+	"github.com/google/go-github/v44/github"
+)
+
+const defaultCommentTemplate = `Released in [{{.Tag}}]({{.ReleaseURL}}) :rocket:
+{{if .Notes}}
+<details>
+<summary>Release notes</summary>
+
+{{.Notes}}
+
+</details>
+{{end}}`
+
+const defaultCommitCommentTemplate = `Released in [{{.Tag}}]({{.ReleaseURL}}) :rocket:
+{{if .Assets}}
+| File | Size | SHA256 |
+| --- | --- | --- |
+{{range .Assets}}| [{{.Name}}]({{.URL}}) | {{humanSize .Size}} | ` + "`{{.SHA256}}`" + ` |
+{{end}}{{end}}`
+
+// mergedPull returns the number of the merged pull request containing the
+// commit, or 0 if there is none.
+func (rc *releaseClient) mergedPull() (int, error) {
+	pulls, _, err := rc.Client.PullRequests.ListPullRequestsWithCommit(rc.Context, rc.Owner, rc.Repo, rc.Commit, nil)
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to list pull requests for commit %s: %w", rc.Commit, err)
+	}
+
+	for _, pull := range pulls {
+		if pull.MergedAt != nil {
+			return pull.GetNumber(), nil
+		}
+	}
+
+	return 0, nil
+}
+
+// markComment adds a metadata block to the comment, so a later run for the
+// same tag updates it instead of adding another one.
+func markComment(body, kind, tag string) string {
+	return writeMetadata(body, map[string]string{"comment": kind, "tag": tag})
+}
+
+// isMarkedComment reports whether the comment was posted for the tag.
+func isMarkedComment(body, kind, tag string) bool {
+	metadata := readMetadata(body)
+	return metadata["comment"] == kind && metadata["tag"] == tag
+}
+
+// commentNotes posts the rendered comment on the issue or pull request,
+// updating the comment of a previous run.
+func (rc *releaseClient) commentNotes(number int, body string) error {
+	body = markComment(body, "notes", rc.Tag)
+	listOpts := &github.IssueListCommentsOptions{ListOptions: github.ListOptions{PerPage: 100}}
+
+	for {
+		comments, resp, err := rc.Client.Issues.ListComments(rc.Context, rc.Owner, rc.Repo, number, listOpts)
+		if err != nil {
+			return fmt.Errorf("failed to list comments of #%d: %w", number, err)
+		}
+
+		for _, comment := range comments {
+			if !isMarkedComment(comment.GetBody(), "notes", rc.Tag) {
+				continue
+			}
+
+			comment, _, err := rc.Client.Issues.EditComment(rc.Context, rc.Owner, rc.Repo, comment.GetID(), &github.IssueComment{Body: &body})
+
+			if err != nil {
+				return fmt.Errorf("failed to update comment on #%d: %w", number, err)
+			}
+
+			fmt.Fprintf(rc.Out, "Successfully updated release notes comment %s\n", comment.GetHTMLURL())
+			return nil
+		}
+
+		// stop iteration if there is no next page
+		if resp.NextPage == 0 {
+			break
+		}
+
+		listOpts.Page = resp.NextPage
+	}
+
+	comment, _, err := rc.Client.Issues.CreateComment(rc.Context, rc.Owner, rc.Repo, number, &github.IssueComment{Body: &body})
+
+	if err != nil {
+		return fmt.Errorf("failed to comment on #%d: %w", number, err)
+	}
+
+	fmt.Fprintf(rc.Out, "Successfully commented release notes on %s\n", comment.GetHTMLURL())
+	return nil
+}
+
+// commentCommit posts the rendered comment on the released commit, updating
+// the comment of a previous run.
+func (rc *releaseClient) commentCommit(body string) error {
+	body = markComment(body, "commit", rc.Tag)
+	listOpts := &github.ListOptions{PerPage: 100}
+
+	for {
+		comments, resp, err := rc.Client.Repositories.ListCommitComments(rc.Context, rc.Owner, rc.Repo, rc.Commit, listOpts)
+		if err != nil {
+			return fmt.Errorf("failed to list comments of commit %s: %w", rc.Commit, err)
+		}
+
+		for _, comment := range comments {
+			if !isMarkedComment(comment.GetBody(), "commit", rc.Tag) {
+				continue
+			}
+
+			comment, _, err := rc.Client.Repositories.UpdateComment(rc.Context, rc.Owner, rc.Repo, comment.GetID(), &github.RepositoryComment{Body: &body})
+
+			if err != nil {
+				return fmt.Errorf("failed to update comment on commit %s: %w", rc.Commit, err)
+			}
+
+			fmt.Fprintf(rc.Out, "Successfully updated release comment %s\n", comment.GetHTMLURL())
+			return nil
+		}
+
+		// stop iteration if there is no next page
+		if resp.NextPage == 0 {
+			break
+		}
+
+		listOpts.Page = resp.NextPage
+	}
+
+	comment, _, err := rc.Client.Repositories.CreateComment(rc.Context, rc.Owner, rc.Repo, rc.Commit, &github.RepositoryComment{Body: &body})
+
+	if err != nil {
+		return fmt.Errorf("failed to comment on commit %s: %w", rc.Commit, err)
+	}
+
+	fmt.Fprintf(rc.Out, "Successfully commented release on %s\n", comment.GetHTMLURL())
+	return nil
+}
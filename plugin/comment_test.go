@@ -0,0 +1,88 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestCommentNotes(t *testing.T) {
+	var edited, created []string
+
+	rc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/octocat/hello/issues/7/comments":
+			comments := []map[string]interface{}{
+				{"id": 1, "body": "Looks good"},
+				{"id": 2, "body": markComment("Released in v0.9.0", "notes", "v0.9.0")},
+				{"id": 3, "body": markComment("Released in v1.0.0", "notes", "v1.0.0")},
+			}
+
+			json.NewEncoder(w).Encode(comments)
+		case r.Method == http.MethodPatch:
+			edited = append(edited, r.URL.Path)
+			fmt.Fprint(w, `{}`)
+		case r.Method == http.MethodPost:
+			created = append(created, r.URL.Path)
+			fmt.Fprint(w, `{}`)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	rc.Tag = "v1.0.0"
+
+	if err := rc.commentNotes(7, "Released in v1.0.0"); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if len(edited) != 1 || edited[0] != "/repos/octocat/hello/issues/comments/3" || len(created) != 0 {
+		t.Errorf("Unexpected comments (Edited: %v, Created: %v)", edited, created)
+	}
+
+	rc.Tag = "v1.1.0"
+	edited = nil
+
+	if err := rc.commentNotes(7, "Released in v1.1.0"); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if len(edited) != 0 || len(created) != 1 {
+		t.Errorf("Unexpected comments (Edited: %v, Created: %v)", edited, created)
+	}
+}
+
+func TestCommentCommit(t *testing.T) {
+	var edited []string
+
+	rc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/octocat/hello/commits/abc/comments":
+			json.NewEncoder(w).Encode([]map[string]interface{}{
+				{"id": 4, "body": markComment("Released in v1.0.0", "commit", "v1.0.0")},
+			})
+		case r.Method == http.MethodPatch:
+			edited = append(edited, r.URL.Path)
+			fmt.Fprint(w, `{}`)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	rc.Tag = "v1.0.0"
+	rc.Commit = "abc"
+
+	if err := rc.commentCommit("Released in v1.0.0"); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if len(edited) != 1 || edited[0] != "/repos/octocat/hello/comments/4" {
+		t.Errorf("Unexpected comments (Edited: %v)", edited)
+	}
+}
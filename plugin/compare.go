@@ -0,0 +1,303 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v44/github"
+)
+
+// compareReport is the JSON representation of the compare output.
+type compareReport struct {
+	SchemaVersion int              `json:"schema_version"`
+	Base          string           `json:"base"`
+	Head          string           `json:"head"`
+	URL           string           `json:"url"`
+	Commits       []comparedCommit `json:"commits"`
+	PullRequests  []int            `json:"pull_requests"`
+	Contributors  []string         `json:"contributors"`
+	Assets        assetDiff        `json:"assets"`
+}
+
+// comparedCommit is a commit between the compared tags.
+type comparedCommit struct {
+	SHA     string `json:"sha"`
+	Message string `json:"message"`
+	Author  string `json:"author"`
+}
+
+// assetDiff lists the differences between the assets of two releases.
+type assetDiff struct {
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+	Changed []string `json:"changed"`
+}
+
+// compareTags reports the changes between two tags.
+func (rc *releaseClient) compareTags(base, head string) (*compareReport, error) {
+	report := &compareReport{
+		SchemaVersion: resultSchemaVersion,
+		Base:          base,
+		Head:          head,
+		Commits:       []comparedCommit{},
+		PullRequests:  []int{},
+		Contributors:  []string{},
+	}
+
+	pulls := make(map[int]bool)
+	contributors := make(map[string]bool)
+	listOpts := &github.ListOptions{PerPage: 100}
+
+	for {
+		comparison, resp, err := rc.Client.Repositories.CompareCommits(rc.Context, rc.Owner, rc.Repo, base, head, listOpts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compare %s...%s: %w", base, head, err)
+		}
+
+		report.URL = comparison.GetHTMLURL()
+
+		for _, commit := range comparison.Commits {
+			message := strings.SplitN(commit.GetCommit().GetMessage(), "\n", 2)[0]
+			author := commit.GetAuthor().GetLogin()
+
+			if author == "" {
+				author = commit.GetCommit().GetAuthor().GetName()
+			}
+
+			report.Commits = append(report.Commits, comparedCommit{
+				SHA:     commit.GetSHA(),
+				Message: message,
+				Author:  author,
+			})
+
+			if m := pullRequestRef.FindStringSubmatch(message); m != nil {
+				number, _ := strconv.Atoi(m[1])
+				pulls[number] = true
+			}
+
+			if author != "" {
+				contributors[author] = true
+			}
+		}
+
+		// stop iteration if there is no next page
+		if resp.NextPage == 0 {
+			break
+		}
+
+		listOpts.Page = resp.NextPage
+	}
+
+	for number := range pulls {
+		report.PullRequests = append(report.PullRequests, number)
+	}
+
+	for contributor := range contributors {
+		report.Contributors = append(report.Contributors, contributor)
+	}
+
+	sort.Ints(report.PullRequests)
+	sort.Strings(report.Contributors)
+
+	baseAssets, err := rc.releaseAssetsByTag(base)
+
+	if err != nil {
+		return nil, err
+	}
+
+	headAssets, err := rc.releaseAssetsByTag(head)
+
+	if err != nil {
+		return nil, err
+	}
+
+	report.Assets = diffAssets(baseAssets, headAssets)
+
+	fmt.Fprintf(rc.Out, "Found %d commits between %s and %s\n", len(report.Commits), base, head)
+	return report, nil
+}
+
+// releaseAssetsByTag returns the assets of the release for a tag, which are
+// empty if there is no release.
+func (rc *releaseClient) releaseAssetsByTag(tag string) ([]*github.ReleaseAsset, error) {
+	release, resp, err := rc.Client.Repositories.GetReleaseByTag(rc.Context, rc.Owner, rc.Repo, tag)
+
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("failed to get release for tag %s: %w", tag, err)
+	}
+
+	return rc.listAssets(release.GetID())
+}
+
+// diffAssets compares assets by name, with a size difference counting as
+// a change.
+func diffAssets(base, head []*github.ReleaseAsset) assetDiff {
+	diff := assetDiff{
+		Added:   []string{},
+		Removed: []string{},
+		Changed: []string{},
+	}
+
+	sizes := make(map[string]int)
+	for _, asset := range base {
+		sizes[asset.GetName()] = asset.GetSize()
+	}
+
+	for _, asset := range head {
+		size, ok := sizes[asset.GetName()]
+
+		if !ok {
+			diff.Added = append(diff.Added, asset.GetName())
+		} else if size != asset.GetSize() {
+			diff.Changed = append(diff.Changed, asset.GetName())
+		}
+
+		delete(sizes, asset.GetName())
+	}
+
+	for name := range sizes {
+		diff.Removed = append(diff.Removed, name)
+	}
+
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Strings(diff.Changed)
+
+	return diff
+}
+
+// checkSizeDrift warns about files whose size changed by more than the
+// threshold percentage compared to the assets of the latest release.
+func (rc *releaseClient) checkSizeDrift(files []string, threshold float64) error {
+	latest, resp, err := rc.Client.Repositories.GetLatestRelease(rc.Context, rc.Owner, rc.Repo)
+
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			fmt.Fprintln(rc.Out, "No previous release found, skipping size drift check")
+			return nil
+		}
+
+		return fmt.Errorf("failed to get latest release: %w", err)
+	}
+
+	if latest.GetTagName() == rc.Tag {
+		fmt.Fprintln(rc.Out, "Latest release is the current release, skipping size drift check")
+		return nil
+	}
+
+	assets, err := rc.listAssets(latest.GetID())
+
+	if err != nil {
+		return err
+	}
+
+	sizes := make(map[string]int64)
+	for _, file := range files {
+		info, err := os.Stat(file)
+
+		if err != nil {
+			return err
+		}
+
+		sizes[path.Base(file)] = info.Size()
+	}
+
+	for _, warning := range sizeDrift(sizes, assets, threshold) {
+		fmt.Fprintf(rc.Out, "Warning: %s compared to %s\n", warning, latest.GetTagName())
+	}
+
+	return nil
+}
+
+// sizeDrift lists the files whose size differs from the asset with the same
+// name by more than the threshold percentage.
+func sizeDrift(sizes map[string]int64, assets []*github.ReleaseAsset, threshold float64) []string {
+	var warnings []string
+
+	for _, asset := range assets {
+		size, ok := sizes[asset.GetName()]
+
+		if !ok || asset.GetSize() == 0 {
+			continue
+		}
+
+		change := float64(size-int64(asset.GetSize())) / float64(asset.GetSize()) * 100
+
+		switch {
+		case change > threshold:
+			warnings = append(warnings, fmt.Sprintf("%s grew by %.1f%%", asset.GetName(), change))
+		case -change > threshold:
+			warnings = append(warnings, fmt.Sprintf("%s shrank by %.1f%%", asset.GetName(), -change))
+		}
+	}
+
+	sort.Strings(warnings)
+	return warnings
+}
+
+// statsSection summarizes the changes since the previous release and the
+// total size of the files compared to its assets.
+func (rc *releaseClient) statsSection(previous *github.RepositoryRelease, files []string) (string, error) {
+	report, err := rc.compareTags(previous.GetTagName(), rc.Commit)
+
+	if err != nil {
+		return "", err
+	}
+
+	assets, err := rc.listAssets(previous.GetID())
+
+	if err != nil {
+		return "", err
+	}
+
+	var size, previousSize int64
+
+	for _, file := range files {
+		info, err := os.Stat(file)
+
+		if err != nil {
+			return "", err
+		}
+
+		size += info.Size()
+	}
+
+	for _, asset := range assets {
+		previousSize += int64(asset.GetSize())
+	}
+
+	return renderStats(previous.GetTagName(), report, size, previousSize), nil
+}
+
+// renderStats renders the stats section of the notes.
+func renderStats(tag string, report *compareReport, size, previousSize int64) string {
+	var sb strings.Builder
+
+	sb.WriteString("## Release stats\n\n")
+	fmt.Fprintf(&sb, "- %d commits, %d pull requests and %d contributors since %s\n", len(report.Commits), len(report.PullRequests), len(report.Contributors), tag)
+
+	delta := size - previousSize
+	sign := "+"
+
+	if delta < 0 {
+		sign = "-"
+		delta = -delta
+	}
+
+	fmt.Fprintf(&sb, "- Total asset size %s (%s%s)\n", humanSize(size), sign, humanSize(delta))
+	return sb.String()
+}
@@ -0,0 +1,50 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/go-github/v44/github"
+)
+
+func TestSizeDrift(t *testing.T) {
+	assets := []*github.ReleaseAsset{
+		{Name: github.String("app-linux"), Size: github.Int(1000)},
+		{Name: github.String("app-darwin"), Size: github.Int(1000)},
+		{Name: github.String("app.exe"), Size: github.Int(1000)},
+		{Name: github.String("removed"), Size: github.Int(1000)},
+	}
+
+	sizes := map[string]int64{
+		"app-linux":  1050,
+		"app-darwin": 400,
+		"app.exe":    1500,
+		"added":      10,
+	}
+
+	actual := sizeDrift(sizes, assets, 10)
+	expected := []string{"app-darwin shrank by 60.0%", "app.exe grew by 50.0%"}
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Unexpected warnings (Got: %v, Expected: %v)", actual, expected)
+	}
+}
+
+func TestRenderStats(t *testing.T) {
+	report := &compareReport{
+		Commits:      []comparedCommit{{SHA: "a"}, {SHA: "b"}, {SHA: "c"}},
+		PullRequests: []int{12, 13},
+		Contributors: []string{"octocat"},
+	}
+
+	expected := "## Release stats\n\n- 3 commits, 2 pull requests and 1 contributors since v1.0.0\n- Total asset size 2.0 KiB (-1.0 KiB)\n"
+
+	if actual := renderStats("v1.0.0", report, 2048, 3072); actual != expected {
+		t.Errorf("Unexpected stats (Got: %q, Expected: %q)", actual, expected)
+	}
+}
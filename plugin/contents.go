@@ -0,0 +1,262 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/google/go-github/v44/github"
+)
+
+var (
+	branchNameInvalid = regexp.MustCompile(`[^A-Za-z0-9._/-]+`)
+)
+
+// fileUpdate describes a change of a single file within a repository.
+type fileUpdate struct {
+	Owner   string
+	Repo    string
+	Branch  string
+	Path    string
+	Message string
+
+	// PullRequest commits to a new branch and opens a pull request instead
+	// of pushing to the branch directly.
+	PullRequest bool
+
+	// Update returns the new content for the current content, which is
+	// empty if the file does not exist yet.
+	Update func(content string) (string, error)
+}
+
+// commitFile applies the update through the contents api.
+func (rc *releaseClient) commitFile(fu fileUpdate) error {
+	if fu.Branch == "" {
+		repo, _, err := rc.Client.Repositories.Get(rc.Context, fu.Owner, fu.Repo)
+
+		if err != nil {
+			return fmt.Errorf("failed to get default branch of %s/%s: %w", fu.Owner, fu.Repo, err)
+		}
+
+		fu.Branch = repo.GetDefaultBranch()
+	}
+
+	current, sha, err := rc.getFile(fu.Owner, fu.Repo, fu.Path, fu.Branch)
+
+	if err != nil {
+		return err
+	}
+
+	content, err := fu.Update(current)
+
+	if err != nil {
+		return err
+	}
+
+	if content == current {
+		fmt.Fprintf(rc.Out, "No changes for %s in %s/%s\n", fu.Path, fu.Owner, fu.Repo)
+		return nil
+	}
+
+	target := fu.Branch
+
+	if fu.PullRequest {
+		target = branchNameInvalid.ReplaceAllString("release/"+rc.Tag+"/"+fu.Path, "-")
+
+		// the branch of a previous run gets reused
+		if err := rc.createBranch(fu.Owner, fu.Repo, target, fu.Branch); err != nil {
+			return err
+		}
+
+		var existing string
+
+		if existing, sha, err = rc.getFile(fu.Owner, fu.Repo, fu.Path, target); err != nil {
+			return err
+		}
+
+		if existing == content {
+			fmt.Fprintf(rc.Out, "Branch %s already contains the changes for %s\n", target, fu.Path)
+			return rc.openPullRequest(fu, target)
+		}
+	}
+
+	opts := &github.RepositoryContentFileOptions{
+		Message: github.String(fu.Message),
+		Content: []byte(content),
+		SHA:     sha,
+		Branch:  github.String(target),
+	}
+
+	if sha == nil {
+		_, _, err = rc.Client.Repositories.CreateFile(rc.Context, fu.Owner, fu.Repo, fu.Path, opts)
+	} else {
+		_, _, err = rc.Client.Repositories.UpdateFile(rc.Context, fu.Owner, fu.Repo, fu.Path, opts)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to commit %s: %w", fu.Path, err)
+	}
+
+	fmt.Fprintf(rc.Out, "Successfully committed %s to %s/%s@%s\n", fu.Path, fu.Owner, fu.Repo, target)
+
+	if !fu.PullRequest {
+		return nil
+	}
+
+	return rc.openPullRequest(fu, target)
+}
+
+// getFile returns the content and the blob sha of the file, which are
+// empty if the file does not exist yet.
+func (rc *releaseClient) getFile(owner, repo, file, ref string) (string, *string, error) {
+	content, _, resp, err := rc.Client.Repositories.GetContents(rc.Context, owner, repo, file, &github.RepositoryContentGetOptions{Ref: ref})
+
+	if err != nil {
+		if resp == nil || resp.StatusCode != http.StatusNotFound {
+			return "", nil, fmt.Errorf("failed to get %s: %w", file, err)
+		}
+
+		return "", nil, nil
+	}
+
+	if content == nil {
+		return "", nil, fmt.Errorf("%s is not a file", file)
+	}
+
+	current, err := content.GetContent()
+
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to decode %s: %w", file, err)
+	}
+
+	return current, content.SHA, nil
+}
+
+// createBranch creates the branch from the head of the base branch, keeping
+// it if it exists already.
+func (rc *releaseClient) createBranch(owner, repo, branch, baseBranch string) error {
+	base, _, err := rc.Client.Git.GetRef(rc.Context, owner, repo, "heads/"+baseBranch)
+
+	if err != nil {
+		return fmt.Errorf("failed to get branch %s: %w", baseBranch, err)
+	}
+
+	ref := &github.Reference{
+		Ref:    github.String("refs/heads/" + branch),
+		Object: &github.GitObject{SHA: base.Object.SHA},
+	}
+
+	_, resp, err := rc.Client.Git.CreateRef(rc.Context, owner, repo, ref)
+
+	if resp != nil && resp.StatusCode == http.StatusUnprocessableEntity {
+		fmt.Fprintf(rc.Out, "Branch %s already exists, reusing it\n", branch)
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to create branch %s: %w", branch, err)
+	}
+
+	return nil
+}
+
+// openPullRequest opens a pull request for the branch, unless one is open
+// already.
+func (rc *releaseClient) openPullRequest(fu fileUpdate, branch string) error {
+	pulls, _, err := rc.Client.PullRequests.List(rc.Context, fu.Owner, fu.Repo, &github.PullRequestListOptions{
+		State: "open",
+		Head:  fu.Owner + ":" + branch,
+		Base:  fu.Branch,
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to list pull requests: %w", err)
+	}
+
+	if len(pulls) > 0 {
+		fmt.Fprintf(rc.Out, "Pull request %s is already open\n", pulls[0].GetHTMLURL())
+		return nil
+	}
+
+	pull, _, err := rc.Client.PullRequests.Create(rc.Context, fu.Owner, fu.Repo, &github.NewPullRequest{
+		Title: github.String(fu.Message),
+		Head:  github.String(branch),
+		Base:  github.String(fu.Branch),
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to open pull request: %w", err)
+	}
+
+	fmt.Fprintf(rc.Out, "Successfully opened pull request %s\n", pull.GetHTMLURL())
+	return nil
+}
+
+// prependChangelog adds a section for the tag at the top of a changelog,
+// below its title if it has one. Changelogs with a section for the tag are
+// kept as they are.
+func prependChangelog(out io.Writer, content, tag, notes string) (string, error) {
+	for _, line := range strings.Split(content, "\n") {
+		if !strings.HasPrefix(line, "## ") {
+			continue
+		}
+
+		heading := strings.TrimSpace(strings.TrimPrefix(line, "## "))
+
+		if heading == tag || strings.HasPrefix(heading, tag+" ") || strings.HasPrefix(heading, "["+tag+"]") {
+			fmt.Fprintf(out, "Changelog already contains a section for %s\n", tag)
+			return content, nil
+		}
+	}
+
+	section := "## " + tag + "\n\n" + strings.TrimSpace(notes) + "\n\n"
+
+	if strings.HasPrefix(content, "# ") {
+		if i := strings.Index(content, "\n"); i >= 0 {
+			return content[:i+1] + "\n" + section + strings.TrimLeft(content[i+1:], "\n"), nil
+		}
+
+		return content + "\n\n" + section, nil
+	}
+
+	return section + content, nil
+}
+
+// bumpVersionFile adds the tag to a json list of versions, any other file
+// just gets replaced by the tag.
+func bumpVersionFile(file, content, tag string) (string, error) {
+	if path.Ext(file) != ".json" {
+		return tag + "\n", nil
+	}
+
+	var versions []string
+
+	if strings.TrimSpace(content) != "" {
+		if err := json.Unmarshal([]byte(content), &versions); err != nil {
+			return "", fmt.Errorf("%s is not a json list of versions: %w", file, err)
+		}
+	}
+
+	for _, version := range versions {
+		if version == tag {
+			return content, nil
+		}
+	}
+
+	b, err := json.MarshalIndent(append([]string{tag}, versions...), "", "  ")
+
+	if err != nil {
+		return "", err
+	}
+
+	return string(b) + "\n", nil
+}
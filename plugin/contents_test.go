@@ -0,0 +1,114 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+func TestPrependChangelog(t *testing.T) {
+	actual, err := prependChangelog(ioutil.Discard, "# Changelog\n\n## v1.0.0\n\n- Initial\n", "v1.1.0", "- Feature\n")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "# Changelog\n\n## v1.1.0\n\n- Feature\n\n## v1.0.0\n\n- Initial\n"
+	if actual != expected {
+		t.Errorf("Unexpected changelog (Got: %q, Expected: %q)", actual, expected)
+	}
+
+	for _, content := range []string{actual, "## [v1.1.0] - 2020-01-01\n\n- Feature\n", "## v1.1.0"} {
+		if again, _ := prependChangelog(ioutil.Discard, content, "v1.1.0", "- Feature"); again != content {
+			t.Errorf("Unexpected changelog for an existing section (Got: %q, Expected: %q)", again, content)
+		}
+	}
+
+	if again, _ := prependChangelog(ioutil.Discard, "## v1.1.0-rc1\n", "v1.1.0", "- Feature"); again == "## v1.1.0-rc1\n" {
+		t.Error("Expected a new section next to a prerelease section")
+	}
+}
+
+func TestCommitFilePullRequest(t *testing.T) {
+	var (
+		committed bool
+		opened    bool
+	)
+
+	rc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/repos/octocat/hello/contents/CHANGELOG.md" && r.Method == http.MethodGet:
+			content := "# Changelog\n"
+
+			if r.URL.Query().Get("ref") != "main" {
+				content = "# Changelog\n\n## v0.9.0\n"
+			}
+
+			fmt.Fprintf(w, `{"type": "file", "sha": "abc", "encoding": "base64", "content": %q}`, base64.StdEncoding.EncodeToString([]byte(content)))
+		case r.URL.Path == "/repos/octocat/hello/contents/CHANGELOG.md" && r.Method == http.MethodPut:
+			committed = true
+			fmt.Fprint(w, `{}`)
+		case r.URL.Path == "/repos/octocat/hello/git/ref/heads/main":
+			fmt.Fprint(w, `{"ref": "refs/heads/main", "object": {"sha": "def"}}`)
+		case r.URL.Path == "/repos/octocat/hello/git/refs":
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			fmt.Fprint(w, `{"message": "Reference already exists"}`)
+		case r.URL.Path == "/repos/octocat/hello/pulls" && r.Method == http.MethodGet:
+			fmt.Fprint(w, `[{"html_url": "https://github.com/octocat/hello/pull/1"}]`)
+		case r.URL.Path == "/repos/octocat/hello/pulls" && r.Method == http.MethodPost:
+			opened = true
+			fmt.Fprint(w, `{}`)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	rc.Tag = "v1.0.0"
+
+	err := rc.commitFile(fileUpdate{
+		Owner:       "octocat",
+		Repo:        "hello",
+		Branch:      "main",
+		Path:        "CHANGELOG.md",
+		PullRequest: true,
+		Update: func(content string) (string, error) {
+			return prependChangelog(ioutil.Discard, content, "v1.0.0", "- Initial")
+		},
+	})
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if !committed {
+		t.Error("Expected a commit to the existing branch")
+	}
+
+	if opened {
+		t.Error("Unexpected pull request next to the open one")
+	}
+}
+
+func TestBumpVersionFile(t *testing.T) {
+	actual, err := bumpVersionFile("versions.json", `["v1.0.0"]`, "v1.1.0")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "[\n  \"v1.1.0\",\n  \"v1.0.0\"\n]\n"
+	if actual != expected {
+		t.Errorf("Unexpected versions (Got: %q, Expected: %q)", actual, expected)
+	}
+
+	if actual, _ := bumpVersionFile("VERSION", "v1.0.0\n", "v1.1.0"); actual != "v1.1.0\n" {
+		t.Errorf("Unexpected version (Got: %q, Expected: %q)", actual, "v1.1.0\n")
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	cosignValues = map[string]bool{
+		"":        true,
+		"key":     true,
+		"keyless": true,
+	}
+)
+
+// cosignSigner signs blobs with the cosign cli, either with a key or
+// keyless through the OIDC identity token of the build.
+type cosignSigner struct {
+	Command       string
+	Key           string
+	Password      string
+	IdentityToken string
+}
+
+// cosignArgs builds the sign-blob arguments writing the signature, the
+// certificate of keyless signing and the bundle next to each other.
+func (s cosignSigner) cosignArgs(file, dir string) ([]string, []string, error) {
+	args := strings.Fields(s.Command)
+
+	if len(args) == 0 {
+		return nil, nil, errors.New("empty cosign command")
+	}
+
+	base := filepath.Join(dir, filepath.Base(file))
+	outputs := []string{base + ".sig"}
+
+	args = append(args, "sign-blob", "--yes", "--output-signature", base+".sig")
+
+	if s.Key != "" {
+		args = append(args, "--key", s.Key)
+	} else {
+		outputs = append(outputs, base+".pem")
+		args = append(args, "--output-certificate", base+".pem")
+	}
+
+	outputs = append(outputs, base+".bundle")
+	args = append(args, "--bundle", base+".bundle", file)
+
+	return args, outputs, nil
+}
+
+// cosignEnv passes the secrets by environment, keeping them out of the
+// process list.
+func (s cosignSigner) cosignEnv() []string {
+	env := []string{"COSIGN_PASSWORD=" + s.Password}
+
+	if s.Key == "" && s.IdentityToken != "" {
+		env = append(env, "SIGSTORE_ID_TOKEN="+s.IdentityToken)
+	}
+
+	return env
+}
+
+// signFiles signs every file and returns the written signatures,
+// certificates and bundles.
+func (s cosignSigner) signFiles(out io.Writer, files []string, dir string) ([]string, error) {
+	var outputs []string
+
+	for _, file := range files {
+		args, written, err := s.cosignArgs(file, dir)
+
+		if err != nil {
+			return nil, err
+		}
+
+		cmd := exec.Command(args[0], args[1:]...)
+		cmd.Env = append(os.Environ(), s.cosignEnv()...)
+
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return nil, fmt.Errorf("failed to sign %s: %w: %s", file, err, strings.TrimSpace(string(out)))
+		}
+
+		fmt.Fprintf(out, "Successfully signed %s artifact with cosign\n", file)
+		outputs = append(outputs, written...)
+	}
+
+	return outputs, nil
+}
@@ -0,0 +1,59 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCosignArgs(t *testing.T) {
+	tests := []struct {
+		signer   cosignSigner
+		expected string
+		outputs  int
+	}{
+		{
+			cosignSigner{Command: "cosign", Key: "cosign.key"},
+			"cosign sign-blob --yes --output-signature out/app.zip.sig --key cosign.key --bundle out/app.zip.bundle dist/app.zip",
+			2,
+		},
+		{
+			cosignSigner{Command: "cosign", IdentityToken: "token"},
+			"cosign sign-blob --yes --output-signature out/app.zip.sig --output-certificate out/app.zip.pem --bundle out/app.zip.bundle dist/app.zip",
+			3,
+		},
+	}
+
+	for _, test := range tests {
+		args, outputs, err := test.signer.cosignArgs(filepath.Join("dist", "app.zip"), "out")
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if actual := filepath.ToSlash(strings.Join(args, " ")); actual != test.expected {
+			t.Errorf("Unexpected args (Got: %s, Expected: %s)", actual, test.expected)
+		}
+
+		if len(outputs) != test.outputs {
+			t.Errorf("Unexpected outputs (Got: %v, Expected: %d files)", outputs, test.outputs)
+		}
+	}
+}
+
+func TestCosignEnv(t *testing.T) {
+	env := cosignSigner{IdentityToken: "token"}.cosignEnv()
+
+	if expected := []string{"COSIGN_PASSWORD=", "SIGSTORE_ID_TOKEN=token"}; strings.Join(env, " ") != strings.Join(expected, " ") {
+		t.Errorf("Unexpected env (Got: %v, Expected: %v)", env, expected)
+	}
+
+	if env := (cosignSigner{Key: "cosign.key", IdentityToken: "token"}).cosignEnv(); len(env) != 1 {
+		t.Errorf("Unexpected identity token for key signing (Got: %v)", env)
+	}
+}
@@ -0,0 +1,173 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// discussionNumber returns the number of the discussion linked to the
+// release, or 0 if there is none. The field is missing from go-github.
+func (rc *releaseClient) discussionNumber(id int64) (int, error) {
+	req, err := rc.Client.NewRequest(http.MethodGet, fmt.Sprintf("repos/%s/%s/releases/%d", rc.Owner, rc.Repo, id), nil)
+
+	if err != nil {
+		return 0, err
+	}
+
+	var release struct {
+		DiscussionURL string `json:"discussion_url"`
+	}
+
+	if _, err := rc.Client.Do(rc.Context, req, &release); err != nil {
+		return 0, fmt.Errorf("failed to retrieve release %d: %w", id, err)
+	}
+
+	if release.DiscussionURL == "" {
+		return 0, nil
+	}
+
+	return strconv.Atoi(path.Base(release.DiscussionURL))
+}
+
+// graphQL runs a query against the graphql endpoint next to the rest api.
+func (rc *releaseClient) graphQL(query string, variables map[string]interface{}, result interface{}) error {
+	endpoint := "graphql"
+
+	// enterprise servers serve graphql at /api/graphql instead of /api/v3
+	if strings.HasSuffix(rc.Client.BaseURL.Path, "/api/v3/") {
+		endpoint = strings.TrimSuffix(rc.Client.BaseURL.Path, "v3/") + "graphql"
+	}
+
+	req, err := rc.Client.NewRequest(http.MethodPost, endpoint, map[string]interface{}{
+		"query":     query,
+		"variables": variables,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	var response struct {
+		Data   interface{} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+
+	response.Data = result
+
+	if _, err := rc.Client.Do(rc.Context, req, &response); err != nil {
+		return err
+	}
+
+	if len(response.Errors) > 0 {
+		return fmt.Errorf("graphql error: %s", response.Errors[0].Message)
+	}
+
+	return nil
+}
+
+// commentDiscussion adds a comment to the discussion of the repository,
+// updating the comment of a previous run. Only the first 100 comments are
+// searched for it.
+func (rc *releaseClient) commentDiscussion(number int, body string) error {
+	body = markComment(body, "digests", rc.Tag)
+
+	var repo struct {
+		Repository struct {
+			Discussion struct {
+				ID       string `json:"id"`
+				Comments struct {
+					Nodes []struct {
+						ID   string `json:"id"`
+						Body string `json:"body"`
+					} `json:"nodes"`
+				} `json:"comments"`
+			} `json:"discussion"`
+		} `json:"repository"`
+	}
+
+	err := rc.graphQL(
+		`query($owner: String!, $name: String!, $number: Int!) { repository(owner: $owner, name: $name) { discussion(number: $number) { id comments(first: 100) { nodes { id body } } } } }`,
+		map[string]interface{}{"owner": rc.Owner, "name": rc.Repo, "number": number},
+		&repo,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to get discussion #%d: %w", number, err)
+	}
+
+	for _, comment := range repo.Repository.Discussion.Comments.Nodes {
+		if !isMarkedComment(comment.Body, "digests", rc.Tag) {
+			continue
+		}
+
+		var updated struct {
+			UpdateDiscussionComment struct {
+				Comment struct {
+					URL string `json:"url"`
+				} `json:"comment"`
+			} `json:"updateDiscussionComment"`
+		}
+
+		err = rc.graphQL(
+			`mutation($id: ID!, $body: String!) { updateDiscussionComment(input: {commentId: $id, body: $body}) { comment { url } } }`,
+			map[string]interface{}{"id": comment.ID, "body": body},
+			&updated,
+		)
+
+		if err != nil {
+			return fmt.Errorf("failed to update comment on discussion #%d: %w", number, err)
+		}
+
+		fmt.Fprintf(rc.Out, "Successfully updated asset digests comment %s\n", updated.UpdateDiscussionComment.Comment.URL)
+		return nil
+	}
+
+	var added struct {
+		AddDiscussionComment struct {
+			Comment struct {
+				URL string `json:"url"`
+			} `json:"comment"`
+		} `json:"addDiscussionComment"`
+	}
+
+	err = rc.graphQL(
+		`mutation($id: ID!, $body: String!) { addDiscussionComment(input: {discussionId: $id, body: $body}) { comment { url } } }`,
+		map[string]interface{}{"id": repo.Repository.Discussion.ID, "body": body},
+		&added,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to comment on discussion #%d: %w", number, err)
+	}
+
+	fmt.Fprintf(rc.Out, "Successfully commented asset digests on %s\n", added.AddDiscussionComment.Comment.URL)
+	return nil
+}
+
+// digestsComment lists the final checksums of the assets with instructions
+// to verify a download.
+func digestsComment(tag string, assets []templateAsset) string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "All assets of %s are uploaded.\n\n| File | SHA256 |\n| --- | --- |\n", tag)
+
+	for _, asset := range assets {
+		fmt.Fprintf(&sb, "| %s | `%s` |\n", asset.Name, asset.SHA256)
+	}
+
+	if len(assets) > 0 {
+		fmt.Fprintf(&sb, "\nVerify a download by comparing its checksum:\n\n```sh\necho \"%s  %s\" | sha256sum -c\n```\n", assets[0].SHA256, assets[0].Name)
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,19 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"testing"
+)
+
+func TestDigestsComment(t *testing.T) {
+	actual := digestsComment("v1.0.0", []templateAsset{{Name: "app.zip", SHA256: "abc"}})
+	expected := "All assets of v1.0.0 are uploaded.\n\n| File | SHA256 |\n| --- | --- |\n| app.zip | `abc` |\n\nVerify a download by comparing its checksum:\n\n```sh\necho \"abc  app.zip\" | sha256sum -c\n```\n"
+
+	if actual != expected {
+		t.Errorf("Unexpected comment (Got: %s, Expected: %s)", actual, expected)
+	}
+}
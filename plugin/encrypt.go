@@ -0,0 +1,112 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	encryptValues = map[string]bool{
+		"":    true,
+		"age": true,
+		"gpg": true,
+	}
+)
+
+// encrypter encrypts files for the recipients with age or gpg.
+type encrypter struct {
+	Mode       string
+	Recipients []string
+	Home       string
+}
+
+// args returns the command encrypting the file into the output. Age takes
+// the recipients as they are, gpg takes them as files of armored public
+// keys, so no keyring is needed.
+func (e *encrypter) args(file, output string) []string {
+	switch e.Mode {
+	case "age":
+		args := []string{"age", "--encrypt"}
+
+		for _, recipient := range e.Recipients {
+			args = append(args, "--recipient", recipient)
+		}
+
+		return append(args, "--output", output, file)
+	default:
+		args := []string{"gpg", "--batch", "--yes", "--homedir", e.Home, "--trust-model", "always"}
+
+		for _, recipient := range e.Recipients {
+			args = append(args, "--recipient-file", recipient)
+		}
+
+		return append(args, "--output", output, "--encrypt", file)
+	}
+}
+
+// encryptFiles writes the ciphertext of every file into the directory and
+// returns the encrypted files in place of the plain ones.
+func (e *encrypter) encryptFiles(out io.Writer, files []string, dir string) ([]string, error) {
+	if len(e.Recipients) == 0 {
+		return nil, fmt.Errorf("no recipients to encrypt for")
+	}
+
+	var encrypted []string
+
+	for _, file := range files {
+		output := filepath.Join(dir, filepath.Base(file)+"."+e.Mode)
+		args := e.args(file, output)
+
+		if out, err := exec.Command(args[0], args[1:]...).CombinedOutput(); err != nil {
+			return nil, fmt.Errorf("failed to encrypt %s: %w: %s", file, err, strings.TrimSpace(string(out)))
+		}
+
+		fmt.Fprintf(out, "Successfully encrypted %s artifact\n", file)
+		encrypted = append(encrypted, output)
+	}
+
+	return encrypted, nil
+}
+
+// newEncrypter prepares the recipients, writing the public keys of gpg
+// recipients into files within the directory.
+func newEncrypter(mode string, recipients []string, dir string) (*encrypter, error) {
+	e := &encrypter{Mode: mode, Home: filepath.Join(dir, "gnupg")}
+
+	if mode == "age" {
+		e.Recipients = recipients
+		return e, nil
+	}
+
+	if err := os.MkdirAll(e.Home, 0700); err != nil {
+		return nil, err
+	}
+
+	for i, recipient := range recipients {
+		key, err := readStringOrFile(recipient)
+
+		if err != nil {
+			return nil, fmt.Errorf("error while reading gpg recipient: %w", err)
+		}
+
+		file := filepath.Join(dir, fmt.Sprintf("recipient-%d.asc", i))
+
+		if err := ioutil.WriteFile(file, []byte(key), 0644); err != nil {
+			return nil, err
+		}
+
+		e.Recipients = append(e.Recipients, file)
+	}
+
+	return e, nil
+}
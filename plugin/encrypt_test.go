@@ -0,0 +1,95 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestEncrypterArgs(t *testing.T) {
+	e := encrypter{Mode: "age", Recipients: []string{"age1abc", "age1def"}}
+
+	expected := []string{"age", "--encrypt", "--recipient", "age1abc", "--recipient", "age1def", "--output", "app.age", "app"}
+
+	if got := e.args("app", "app.age"); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Unexpected args (Got: %v, Expected: %v)", got, expected)
+	}
+}
+
+func TestEncryptFilesGPG(t *testing.T) {
+	if _, err := exec.LookPath("gpg"); err != nil {
+		t.Skip("gpg is not installed")
+	}
+
+	dir := t.TempDir()
+	keyring := filepath.Join(dir, "keyring")
+
+	if err := os.Mkdir(keyring, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		for _, home := range []string{keyring, filepath.Join(dir, "encrypted", "gnupg")} {
+			exec.Command("gpgconf", "--homedir", home, "--kill", "gpg-agent").Run()
+		}
+	})
+
+	generate := exec.Command("gpg", "--batch", "--homedir", keyring, "--passphrase", "", "--pinentry-mode", "loopback",
+		"--quick-gen-key", "Release <release@example.com>", "default", "default", "never")
+
+	if out, err := generate.CombinedOutput(); err != nil {
+		t.Skipf("failed to generate a gpg key: %s", out)
+	}
+
+	public, err := exec.Command("gpg", "--batch", "--homedir", keyring, "--armor", "--export", "release@example.com").Output()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file := filepath.Join(dir, "app")
+
+	if err := ioutil.WriteFile(file, []byte("secret build\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	output := filepath.Join(dir, "encrypted")
+
+	if err := os.Mkdir(output, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	e, err := newEncrypter("gpg", []string{string(public)}, output)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encrypted, err := e.encryptFiles(ioutil.Discard, []string{file}, output)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, expected := filepath.Base(encrypted[0]), "app.gpg"; got != expected {
+		t.Errorf("Unexpected name (Got: %s, Expected: %s)", got, expected)
+	}
+
+	plain, err := exec.Command("gpg", "--batch", "--homedir", keyring, "--decrypt", encrypted[0]).Output()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(plain) != "secret build\n" {
+		t.Errorf("Unexpected decrypted content (Got: %q)", plain)
+	}
+}
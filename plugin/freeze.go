@@ -0,0 +1,134 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// freezeWindow is a cron-like expression (minute, hour, day of month, month,
+// day of week) matching the times a release freeze is active.
+type freezeWindow struct {
+	expr   string
+	fields [5]map[int]bool
+}
+
+var cronRanges = [5][2]int{
+	{0, 59},
+	{0, 23},
+	{1, 31},
+	{1, 12},
+	{0, 6},
+}
+
+func parseFreezeWindow(expr string) (freezeWindow, error) {
+	w := freezeWindow{expr: expr}
+	parts := strings.Fields(expr)
+
+	if len(parts) != 5 {
+		return w, fmt.Errorf("invalid freeze window %q, expected 5 fields", expr)
+	}
+
+	for i, part := range parts {
+		if part == "*" {
+			continue
+		}
+
+		values, err := parseCronField(part, cronRanges[i][0], cronRanges[i][1])
+
+		if err != nil {
+			return w, fmt.Errorf("invalid freeze window %q: %w", expr, err)
+		}
+
+		w.fields[i] = values
+	}
+
+	return w, nil
+}
+
+// parseCronField supports lists, ranges and steps, e.g. 1-5,10-20/2.
+func parseCronField(field string, min, max int) (map[int]bool, error) {
+	values := make(map[int]bool)
+
+	for _, item := range strings.Split(field, ",") {
+		step := 1
+
+		if i := strings.Index(item, "/"); i >= 0 {
+			var err error
+
+			if step, err = strconv.Atoi(item[i+1:]); err != nil || step < 1 {
+				return nil, fmt.Errorf("invalid step in %s", item)
+			}
+
+			item = item[:i]
+		}
+
+		lo, hi := min, max
+
+		if item != "*" {
+			bounds := strings.SplitN(item, "-", 2)
+
+			var err error
+
+			if lo, err = strconv.Atoi(bounds[0]); err != nil {
+				return nil, fmt.Errorf("invalid value %s", item)
+			}
+
+			hi = lo
+
+			if len(bounds) == 2 {
+				if hi, err = strconv.Atoi(bounds[1]); err != nil {
+					return nil, fmt.Errorf("invalid value %s", item)
+				}
+			}
+		}
+
+		if lo < min || hi > max || lo > hi {
+			return nil, fmt.Errorf("value %s out of range %d-%d", item, min, max)
+		}
+
+		for v := lo; v <= hi; v += step {
+			values[v] = true
+		}
+	}
+
+	return values, nil
+}
+
+// active reports whether the window matches the time. Like cron, if both
+// day of month and day of week are restricted, matching either is enough.
+func (w freezeWindow) active(t time.Time) bool {
+	matches := func(i, v int) bool {
+		return w.fields[i] == nil || w.fields[i][v]
+	}
+
+	if !matches(0, t.Minute()) || !matches(1, t.Hour()) || !matches(3, int(t.Month())) {
+		return false
+	}
+
+	dom := matches(2, t.Day())
+	dow := matches(4, int(t.Weekday()))
+
+	if w.fields[2] != nil && w.fields[4] != nil {
+		return dom || dow
+	}
+
+	return dom && dow
+}
+
+// activeFreeze returns the first window matching the time.
+func activeFreeze(windows []freezeWindow, t time.Time) (string, bool) {
+	for _, w := range windows {
+		if w.active(t) {
+			return w.expr, true
+		}
+	}
+
+	return "", false
+}
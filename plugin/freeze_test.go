@@ -0,0 +1,42 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFreezeWindow(t *testing.T) {
+	tests := []struct {
+		expr     string
+		time     time.Time
+		expected bool
+	}{
+		{"* * 24-31 12 *", time.Date(2022, 12, 27, 10, 0, 0, 0, time.UTC), true},
+		{"* * 24-31 12 *", time.Date(2022, 11, 27, 10, 0, 0, 0, time.UTC), false},
+		{"* 17-23 * * 5", time.Date(2022, 9, 2, 18, 30, 0, 0, time.UTC), true},
+		{"* 17-23 * * 5", time.Date(2022, 9, 1, 18, 30, 0, 0, time.UTC), false},
+		{"*/15 * * * *", time.Date(2022, 9, 1, 18, 30, 0, 0, time.UTC), true},
+		{"*/15 * * * *", time.Date(2022, 9, 1, 18, 31, 0, 0, time.UTC), false},
+	}
+
+	for _, test := range tests {
+		w, err := parseFreezeWindow(test.expr)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if actual := w.active(test.time); actual != test.expected {
+			t.Errorf("Unexpected result for %q at %s (Got: %t, Expected: %t)", test.expr, test.time, actual, test.expected)
+		}
+	}
+
+	if _, err := parseFreezeWindow("* * 32 * *"); err == nil {
+		t.Error("Expected an error for an out of range value")
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// gpgSigner creates detached signatures with a key imported into its own
+// keyring, keeping the keyring of the host untouched.
+type gpgSigner struct {
+	home       string
+	passphrase string
+}
+
+// newGPGSigner imports the armored secret key into a keyring within home.
+func newGPGSigner(key, passphrase, home string) (*gpgSigner, error) {
+	if err := os.MkdirAll(home, 0700); err != nil {
+		return nil, err
+	}
+
+	s := &gpgSigner{home: home, passphrase: passphrase}
+
+	if err := s.run(key, "--import"); err != nil {
+		return nil, fmt.Errorf("failed to import gpg key: %w", err)
+	}
+
+	return s, nil
+}
+
+func (s *gpgSigner) run(stdin string, args ...string) error {
+	cmd := exec.Command("gpg", append([]string{"--batch", "--yes", "--homedir", s.home}, args...)...)
+	cmd.Stdin = strings.NewReader(stdin)
+
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+
+	return nil
+}
+
+// signFiles writes an armored detached signature <name>.asc for every file
+// into the directory.
+func (s *gpgSigner) signFiles(out io.Writer, files []string, dir string) ([]string, error) {
+	var signatures []string
+
+	for _, file := range files {
+		signature := filepath.Join(dir, filepath.Base(file)+".asc")
+
+		err := s.run(s.passphrase+"\n", "--pinentry-mode", "loopback", "--passphrase-fd", "0",
+			"--armor", "--detach-sign", "--output", signature, file)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign %s: %w", file, err)
+		}
+
+		fmt.Fprintf(out, "Successfully signed %s artifact\n", file)
+		signatures = append(signatures, signature)
+	}
+
+	return signatures, nil
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGPGSigner(t *testing.T) {
+	if _, err := exec.LookPath("gpg"); err != nil {
+		t.Skip("gpg is not installed")
+	}
+
+	dir := t.TempDir()
+	keyring := filepath.Join(dir, "keyring")
+
+	if err := os.Mkdir(keyring, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		for _, home := range []string{keyring, filepath.Join(dir, "gnupg")} {
+			exec.Command("gpgconf", "--homedir", home, "--kill", "gpg-agent").Run()
+		}
+	})
+
+	generate := exec.Command("gpg", "--batch", "--homedir", keyring, "--passphrase", "secret", "--pinentry-mode", "loopback",
+		"--quick-gen-key", "Release <release@example.com>", "ed25519", "sign", "never")
+
+	if out, err := generate.CombinedOutput(); err != nil {
+		t.Skipf("failed to generate a gpg key: %s", out)
+	}
+
+	key, err := exec.Command("gpg", "--batch", "--homedir", keyring, "--passphrase", "secret", "--pinentry-mode", "loopback",
+		"--armor", "--export-secret-keys").Output()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file := filepath.Join(dir, "app.zip")
+
+	if err := os.WriteFile(file, []byte("content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	signer, err := newGPGSigner(string(key), "secret", filepath.Join(dir, "gnupg"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signatures, err := signer.signFiles(ioutil.Discard, []string{file}, dir)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(signatures[0])
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(string(content), "-----BEGIN PGP SIGNATURE-----") {
+		t.Errorf("Unexpected signature (Got: %s, Expected: an armored signature)", content)
+	}
+
+	if out, err := exec.Command("gpg", "--batch", "--homedir", keyring, "--verify", signatures[0], file).CombinedOutput(); err != nil {
+		t.Errorf("Unexpected verification failure: %s", out)
+	}
+}
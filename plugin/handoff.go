@@ -0,0 +1,177 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v44/github"
+)
+
+var (
+	handoffValues = map[string]bool{
+		"":      true,
+		"write": true,
+		"read":  true,
+	}
+)
+
+// handoff describes a release draft to be finished by another pipeline.
+type handoff struct {
+	ReleaseID      int64    `json:"release_id"`
+	Tag            string   `json:"tag"`
+	UploadURL      string   `json:"upload_url"`
+	HTMLURL        string   `json:"html_url"`
+	ExpectedAssets []string `json:"expected_assets"`
+}
+
+// handoffPath replaces the tag placeholder of the handoff file.
+func handoffPath(file, tag string) string {
+	return strings.ReplaceAll(file, "{{tag}}", tag)
+}
+
+// writeHandoff commits the descriptor of the draft to the repository.
+func (rc *releaseClient) writeHandoff(release *github.RepositoryRelease, file, branch string) error {
+	assets, err := rc.listAssets(release.GetID())
+
+	if err != nil {
+		return err
+	}
+
+	descriptor := handoff{
+		ReleaseID: release.GetID(),
+		Tag:       release.GetTagName(),
+		UploadURL: release.GetUploadURL(),
+		HTMLURL:   release.GetHTMLURL(),
+	}
+
+	for _, asset := range assets {
+		descriptor.ExpectedAssets = append(descriptor.ExpectedAssets, asset.GetName())
+	}
+
+	sort.Strings(descriptor.ExpectedAssets)
+
+	b, err := json.MarshalIndent(descriptor, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	return rc.commitFile(fileUpdate{
+		Owner:   rc.Owner,
+		Repo:    rc.Repo,
+		Branch:  branch,
+		Path:    handoffPath(file, rc.Tag),
+		Message: fmt.Sprintf("Hand off release draft %s [skip ci]", rc.Tag),
+		Update: func(string) (string, error) {
+			return string(b) + "\n", nil
+		},
+	})
+}
+
+// readHandoff reads the descriptor written by the upstream pipeline and
+// checks that its draft still exists with all expected assets.
+func (rc *releaseClient) readHandoff(file, branch string) (*handoff, error) {
+	name := handoffPath(file, rc.Tag)
+
+	content, _, _, err := rc.Client.Repositories.GetContents(rc.Context, rc.Owner, rc.Repo, name, &github.RepositoryContentGetOptions{Ref: branch})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s: %w", name, err)
+	}
+
+	if content == nil {
+		return nil, fmt.Errorf("%s is not a file", name)
+	}
+
+	raw, err := content.GetContent()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", name, err)
+	}
+
+	var descriptor handoff
+
+	if err := json.Unmarshal([]byte(raw), &descriptor); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+
+	if descriptor.Tag != rc.Tag {
+		return nil, fmt.Errorf("handoff %s is for tag %s, not %s", name, descriptor.Tag, rc.Tag)
+	}
+
+	release, _, err := rc.Client.Repositories.GetRelease(rc.Context, rc.Owner, rc.Repo, descriptor.ReleaseID)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve release %d: %w", descriptor.ReleaseID, err)
+	}
+
+	if !release.GetDraft() {
+		return nil, fmt.Errorf("release %d is already published", descriptor.ReleaseID)
+	}
+
+	assets, err := rc.listAssets(descriptor.ReleaseID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if missing := missingAssets(descriptor.ExpectedAssets, assets); len(missing) > 0 {
+		return nil, fmt.Errorf("release %d is missing expected assets: %s", descriptor.ReleaseID, strings.Join(missing, ", "))
+	}
+
+	fmt.Fprintf(rc.Out, "Taking over release draft %d with %d assets\n", descriptor.ReleaseID, len(assets))
+	return &descriptor, nil
+}
+
+// removeHandoff deletes the consumed descriptor.
+func (rc *releaseClient) removeHandoff(file, branch string) error {
+	name := handoffPath(file, rc.Tag)
+
+	content, _, _, err := rc.Client.Repositories.GetContents(rc.Context, rc.Owner, rc.Repo, name, &github.RepositoryContentGetOptions{Ref: branch})
+
+	if err != nil {
+		return fmt.Errorf("failed to get %s: %w", name, err)
+	}
+
+	opts := &github.RepositoryContentFileOptions{
+		Message: github.String(fmt.Sprintf("Finish release handoff %s [skip ci]", rc.Tag)),
+		SHA:     content.SHA,
+	}
+
+	if branch != "" {
+		opts.Branch = github.String(branch)
+	}
+
+	if _, _, err := rc.Client.Repositories.DeleteFile(rc.Context, rc.Owner, rc.Repo, name, opts); err != nil {
+		return fmt.Errorf("failed to delete %s: %w", name, err)
+	}
+
+	fmt.Fprintf(rc.Out, "Successfully removed handoff %s\n", name)
+	return nil
+}
+
+// missingAssets lists the expected asset names not present in the assets.
+func missingAssets(expected []string, assets []*github.ReleaseAsset) []string {
+	present := make(map[string]bool)
+
+	for _, asset := range assets {
+		present[asset.GetName()] = true
+	}
+
+	var missing []string
+
+	for _, name := range expected {
+		if !present[name] {
+			missing = append(missing, name)
+		}
+	}
+
+	return missing
+}
@@ -0,0 +1,55 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v44/github"
+)
+
+func TestMissingAssets(t *testing.T) {
+	assets := []*github.ReleaseAsset{
+		{Name: github.String("app.zip")},
+		{Name: github.String("app.tar.gz")},
+	}
+
+	actual := strings.Join(missingAssets([]string{"app.zip", "app.exe", "app.tar.gz", "sha256sum.txt"}, assets), ",")
+	expected := "app.exe,sha256sum.txt"
+
+	if actual != expected {
+		t.Errorf("Unexpected missing assets (Got: %s, Expected: %s)", actual, expected)
+	}
+}
+
+func TestHandoffRelease(t *testing.T) {
+	rc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/octocat/hello/releases":
+			fmt.Fprint(w, `[{"id": 4, "tag_name": "v1.0.0", "draft": true}]`)
+		case "/repos/octocat/hello/releases/5":
+			fmt.Fprint(w, `{"id": 5, "tag_name": "v1.0.0", "draft": true}`)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	rc.Tag = "v1.0.0"
+	rc.ReleaseID = 5
+
+	release, err := rc.getRelease()
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if release.GetID() != 5 {
+		t.Errorf("Unexpected release (Got: %d, Expected: %d)", release.GetID(), 5)
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"fmt"
+	"net/http"
+)
+
+var (
+	onImmutableValues = map[string]bool{
+		"fail":    true,
+		"new-tag": true,
+		"skip":    true,
+	}
+)
+
+// immutableReleases checks if the repository has immutable releases enabled,
+// which prevents changing published releases and their assets. Servers
+// without support for the setting report it as disabled.
+func (rc *releaseClient) immutableReleases() (bool, error) {
+	req, err := rc.Client.NewRequest(http.MethodGet, fmt.Sprintf("repos/%s/%s/immutable-releases", rc.Owner, rc.Repo), nil)
+
+	if err != nil {
+		return false, err
+	}
+
+	var setting struct {
+		Enabled bool `json:"enabled"`
+	}
+
+	resp, err := rc.Client.Do(rc.Context, req, &setting)
+
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return false, nil
+		}
+
+		return false, fmt.Errorf("failed to get immutable releases setting: %w", err)
+	}
+
+	return setting.Enabled, nil
+}
+
+// nextFreeTag returns the first tag with a -rN suffix that has no release.
+func (rc *releaseClient) nextFreeTag() (string, error) {
+	for n := 2; n < 100; n++ {
+		tag := fmt.Sprintf("%s-r%d", rc.Tag, n)
+		_, resp, err := rc.Client.Repositories.GetReleaseByTag(rc.Context, rc.Owner, rc.Repo, tag)
+
+		if err != nil {
+			if resp != nil && resp.StatusCode == http.StatusNotFound {
+				return tag, nil
+			}
+
+			return "", fmt.Errorf("failed to get release for tag %s: %w", tag, err)
+		}
+	}
+
+	return "", fmt.Errorf("no free tag found for %s", rc.Tag)
+}
+
+// publishedImmutable checks if the release of the tag is published in a
+// repository with immutable releases.
+func (rc *releaseClient) publishedImmutable() (bool, error) {
+	release, err := rc.getRelease()
+
+	if err != nil {
+		return false, fmt.Errorf("failed to retrieve a release: %w", err)
+	}
+
+	if release == nil || release.GetDraft() {
+		return false, nil
+	}
+
+	return rc.immutableReleases()
+}
+
+// immutableTag returns the tag to release with the new-tag policy, which is
+// the next free tag if the release of the tag is published and immutable.
+func (rc *releaseClient) immutableTag() (string, error) {
+	immutable, err := rc.publishedImmutable()
+
+	if err != nil || !immutable {
+		return rc.Tag, err
+	}
+
+	tag, err := rc.nextFreeTag()
+
+	if err != nil {
+		return "", err
+	}
+
+	fmt.Fprintf(rc.Out, "Release %s is immutable, releasing as %s instead\n", rc.Tag, tag)
+	return tag, nil
+}
+
+// handleImmutable applies the policy if the release of the tag is published
+// in a repository with immutable releases. It returns false if the run has
+// to stop without changes. The new-tag policy is applied by immutableTag
+// before any asset is named after the tag.
+func (rc *releaseClient) handleImmutable(policy string) (bool, error) {
+	immutable, err := rc.publishedImmutable()
+
+	if err != nil {
+		return false, err
+	}
+
+	if !immutable {
+		return true, nil
+	}
+
+	if policy == "skip" {
+		fmt.Fprintf(rc.Out, "Release %s is immutable, skipping the update\n", rc.Tag)
+		return false, nil
+	}
+
+	return false, fmt.Errorf("release %s is immutable and cannot be changed, release a new tag instead or set on_immutable to new-tag or skip", rc.Tag)
+}
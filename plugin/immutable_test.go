@@ -0,0 +1,126 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/drone-plugins/drone-plugin-lib/drone"
+)
+
+// immutableHandler fakes a repository with immutable releases, where the
+// release of v1.0.0 and v1.0.0-r2 are published.
+func immutableHandler(immutable bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/octocat/hello/releases":
+			fmt.Fprint(w, `[{"id": 1, "tag_name": "v1.0.0"}, {"id": 2, "tag_name": "v1.0.0-r2"}, {"id": 3, "tag_name": "v2.0.0", "draft": true}]`)
+		case "/repos/octocat/hello/releases/tags/v1.0.0-r2":
+			fmt.Fprint(w, `{"id": 2, "tag_name": "v1.0.0-r2"}`)
+		case "/repos/octocat/hello/immutable-releases":
+			fmt.Fprintf(w, `{"enabled": %t}`, immutable)
+		default:
+			http.NotFound(w, r)
+		}
+	}
+}
+
+func TestHandleImmutable(t *testing.T) {
+	tests := []struct {
+		tag       string
+		immutable bool
+		policy    string
+		proceed   bool
+		fails     bool
+	}{
+		{"v1.0.0", true, "fail", false, true},
+		{"v1.0.0", true, "skip", false, false},
+		{"v1.0.0", false, "fail", true, false},
+		{"v2.0.0", true, "fail", true, false},
+		{"v3.0.0", true, "fail", true, false},
+	}
+
+	for _, test := range tests {
+		rc := newTestClient(t, immutableHandler(test.immutable))
+		rc.Tag = test.tag
+
+		proceed, err := rc.handleImmutable(test.policy)
+
+		if proceed != test.proceed || (err != nil) != test.fails {
+			t.Errorf("Unexpected result for %s with %s (Got: %t, %v, Expected: %t, failure %t)", test.tag, test.policy, proceed, err, test.proceed, test.fails)
+		}
+	}
+}
+
+func TestImmutableTag(t *testing.T) {
+	tests := []struct {
+		tag       string
+		immutable bool
+		expected  string
+	}{
+		{"v1.0.0", true, "v1.0.0-r3"},
+		{"v1.0.0", false, "v1.0.0"},
+		{"v2.0.0", true, "v2.0.0"},
+	}
+
+	for _, test := range tests {
+		rc := newTestClient(t, immutableHandler(test.immutable))
+		rc.Tag = test.tag
+
+		tag, err := rc.immutableTag()
+
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		if tag != test.expected {
+			t.Errorf("Unexpected tag for %s (Got: %s, Expected: %s)", test.tag, tag, test.expected)
+		}
+	}
+}
+
+func TestResolveImmutableTag(t *testing.T) {
+	server := httptest.NewServer(immutableHandler(true))
+	t.Cleanup(server.Close)
+
+	p := Plugin{
+		pipeline: drone.Pipeline{
+			Repo:   drone.Repo{Owner: "octocat", Name: "hello", Link: "https://github.com/octocat/hello"},
+			Commit: drone.Commit{Ref: "refs/tags/v1.0.0", SHA: "0123456789abcdef"},
+		},
+		network: drone.Network{Context: context.Background(), Client: server.Client()},
+	}
+
+	p.settings.baseURL, _ = url.Parse(server.URL + "/")
+	p.settings.uploadURL, _ = url.Parse(server.URL + "/")
+
+	if err := p.resolveImmutableTag(); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if !p.settings.newTag {
+		t.Error("Expected the tag to be replaced")
+	}
+
+	if data := p.templateData(); data.Tag != "v1.0.0-r3" || data.Version != "1.0.0-r3" {
+		t.Errorf("Unexpected template data (Got: %s, %s, Expected: v1.0.0-r3, 1.0.0-r3)", data.Tag, data.Version)
+	}
+
+	rc, err := p.newReleaseClient()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rc.Tag != "v1.0.0-r3" {
+		t.Errorf("Unexpected release tag (Got: %s, Expected: v1.0.0-r3)", rc.Tag)
+	}
+}
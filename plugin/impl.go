@@ -8,11 +8,20 @@ package plugin
 import (
 	"context"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/url"
+	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/drone-plugins/drone-plugin-lib/errors"
 	"github.com/google/go-github/v44/github"
+	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v2"
 	"golang.org/x/oauth2"
 )
@@ -26,6 +35,9 @@ type Settings struct {
 	Checksum             cli.StringSlice
 	ChecksumFile         string
 	ChecksumFlatten      bool
+	ChecksumSidecar      bool
+	ChecksumCombined     string
+	ChecksumFormat       string
 	Draft                bool
 	Prerelease           bool
 	BaseURL              string
@@ -34,135 +46,2134 @@ type Settings struct {
 	Note                 string
 	Overwrite            bool
 	GenerateReleaseNotes bool
+	Action               string
+	ResultFile           string
+	ListDraft            string
+	ListPrerelease       string
+	ListTagPattern       string
+	ListSince            string
+	ConsolidateDrafts    bool
+	ImportPrereleases    bool
+	PromoteAssets        bool
+	Bundles              string
+	BundleIncludes       cli.StringSlice
+	BundlePermissions    string
+	BundleReproducible   bool
+	BundleModTime        int64
+	BundleBuildInfo      string
+	BundleGoVersion      string
+	FIPS                 bool
+	Preflight            bool
+	IPVersion            string
+	HostOverrides        cli.StringSlice
+	MaxIdleConns         int
+	MaxIdleConnsPerHost  int
+	DisableCompression   bool
+	DedupAssets          bool
+	Metadata             bool
+	MetadataValues       cli.StringSlice
+	OnlyManageOwn        bool
+	Force                bool
+	DryRun               bool
+	Resume               bool
+	RequiredApprovals    int
+	ChangelogFile        string
+	ChangelogBranch      string
+	ChangelogPR          bool
+	VersionFileRepo      string
+	VersionFilePath      string
+	VersionFileBranch    string
+	VersionFilePR        bool
+	PackageRepo          string
+	PackageBranch        string
+	PackagePR            bool
+	PackageTemplates     cli.StringSlice
+	ImageManifest        string
+	ArtifactsSection     bool
+	CompareBase          string
+	CompareHead          string
+	TagSync              string
+	ScanCommand          string
+	ScanReport           string
+	RequiredFiles        cli.StringSlice
+	RequiredNotes        cli.StringSlice
+	FreezeWindows        string
+	FreezeTimezone       string
+	Canary               bool
+	CanaryFiles          cli.StringSlice
+	CanaryNote           string
+	CanarySoak           time.Duration
+	CanarySignal         string
+	ChecksumAggregate    string
+	ChecksumAggregated   string
+	ChecksumSignCommand  string
+	SmokeTest            bool
+	SmokeTestRetries     int
+	SmokeTestDelay       time.Duration
+	PrivateDownloads     bool
+	ForkCheck            bool
+	SourceRepo           string
+	SecretScan           bool
+	MaxTotalSize         string
+	SizeBudget           string
+	SizeDriftThreshold   float64
+	Yes                  bool
+	CI                   bool
+	TemplatePreset       string
+	WorkDir              string
+	TempDir              string
+	KeepTempDir          bool
+	VerifyFiles          string
+	VerifyFilesMethod    string
+	RequireChecks        bool
+	ChecksIgnore         cli.StringSlice
+	ChecksTimeout        time.Duration
+	ChecksInterval       time.Duration
+	CommentNotes         bool
+	CommentIssue         int
+	CommentTemplate      string
+	OnImmutable          string
+	AssetsManifest       string
+	AssetsOrder          cli.StringSlice
+	Queue                string
+	QueueRepo            string
+	QueueScope           string
+	QueueTimeout         time.Duration
+	QueueStale           time.Duration
+	QueueInterval        time.Duration
+	SandboxRepo          string
+	StatsFile            string
+	StatsEndpoint        string
+	FilesDepth           int
+	FilesExclude         cli.StringSlice
+	VerifySignature      string
+	AllowedSigners       cli.StringSlice
+	OnMissingTag         string
+	DeriveTagFile        string
+	SameCommit           string
+	Handoff              string
+	HandoffFile          string
+	HandoffBranch        string
+	LatestAliases        cli.StringSlice
+	LatestTag            string
+	IntotoLayout         string
+	IntotoKeys           cli.StringSlice
+	IntotoLinkDir        string
+	IntotoCommand        string
+	PreviewHTML          string
+	LatencySLA           time.Duration
+	LatencyWebhook       string
+	GPGKey               string
+	GPGPassphrase        string
+	MinisignKey          string
+	MinisignPassword     string
+	Cosign               string
+	CosignKey            string
+	CosignPassword       string
+	CosignIdentityToken  string
+	CosignCommand        string
+	DiscussionCategory   string
+	DiscussionDigests    bool
+	SBOM                 string
+	SBOMCommand          string
+	CheckRulesets        bool
+	Provenance           bool
+	Attestations         cli.StringSlice
+	SSHKey               string
+	SSHNamespace         string
+	SSHAllowedSigners    string
+	Encrypt              string
+	EncryptRecipients    cli.StringSlice
+	NoteTemplate         string
+	CommitComment        bool
+	CommitTemplate       string
+	AliasTags            bool
+	NotesRedactions      cli.StringSlice
+	LogFormat            string
+	InlineAssets         string
+	Quiet                bool
+	ProtectDownloaded    bool
+	EnvFile              string
+	NotesStats           bool
+	OwnAssets            bool
+	OutputFile           string
+	CardPath             string
+	StreamMaxSize        string
 
-	baseURL   *url.URL
-	uploadURL *url.URL
-	uploads   []string
+	// Sources records where each setting was loaded from.
+	Sources map[string]string
+
+	// LoadError records a failure to load the settings, which fails the
+	// validation.
+	LoadError error
+
+	// Completion is a shell completion script, which gets printed instead
+	// of running the plugin.
+	Completion string
+
+	baseURL    *url.URL
+	uploadURL  *url.URL
+	uploads    []string
+	listFilter listFilter
+	bundles    []bundle
+	hosts      map[string]string
+	metadata   map[string]string
+	freeze     []freezeWindow
+	freezeTZ   *time.Location
+	maxSize    int64
+	runDir     string
+	order      []assetsOrder
+	stats      *runStats
+	skip       bool
+	derivedTag bool
+	newTag     bool
+	latest     []string
+	combined   []string
+	previous   *github.RepositoryRelease
+	redactions []*regexp.Regexp
+	output     *outputLog
+	streams    map[string][]byte
+	scrubber   *scrubber
 }
 
 // Validate handles the settings validation of the plugin.
 func (p *Plugin) Validate() error {
+	if p.settings.LoadError != nil {
+		return p.settings.LoadError
+	}
+
+	if p.settings.Completion != "" {
+		fmt.Fprint(p.results(), p.settings.Completion)
+		p.settings.skip = true
+		return nil
+	}
+
+	p.settings.scrubber = newScrubber(append([]string{
+		p.settings.APIKey,
+		p.settings.GPGPassphrase,
+		p.settings.MinisignPassword,
+		p.settings.CosignPassword,
+		p.settings.CosignIdentityToken,
+	}, webhookSecrets(p.settings.LatencyWebhook, p.settings.StatsEndpoint)...)...)
+
+	if !logFormatValues[p.settings.LogFormat] {
+		return fmt.Errorf("invalid value for log_format")
+	}
+
+	// results of actions written to stdout are kept apart from the progress
+	var progress io.Writer = os.Stdout
+
+	if p.settings.ResultFile == "" && (p.settings.Action == "list" || p.settings.Action == "compare" || p.settings.DryRun && p.settings.SandboxRepo == "") {
+		progress = os.Stderr
+	}
+
+	// stopped at the end of the execution
+	p.settings.output = newOutputLog(os.Stdout, progress, os.Stderr, p.settings.LogFormat, p.settings.Quiet, p.settings.scrubber, logrus.Fields{
+		"repo": p.pipeline.Repo.Slug,
+		"tag":  strings.TrimPrefix(p.pipeline.Commit.Ref, "refs/tags/"),
+	})
+
+	if err := p.validate(); err != nil {
+		p.cleanup()
+		return p.stopOutputLog(p.settings.scrubber.scrubError(err))
+	}
+
+	return nil
+}
+
+// stopOutputLog stops the output log and, in json mode, logs the error as
+// a json entry with the fields of the run, returning it as an exit error
+// without message, so it is not logged again in text format.
+func (p *Plugin) stopOutputLog(err error) error {
+	l := p.settings.output
+
+	if l == nil {
+		return err
+	}
+
+	l.stop()
+	p.settings.output = nil
+
+	if err == nil || l.entries == nil {
+		return err
+	}
+
+	l.logger.Error(err)
+	return errors.ExitMessage("")
+}
+
+// out returns the writer of the progress, which is stdout until the output
+// log is started.
+func (p *Plugin) out() io.Writer {
+	if p.settings.output == nil {
+		return os.Stdout
+	}
+
+	return p.settings.output
+}
+
+// results returns the writer of action results, bypassing the filter of
+// the output log.
+func (p *Plugin) results() io.Writer {
+	if p.settings.output == nil {
+		return os.Stdout
+	}
+
+	return p.settings.output.results()
+}
+
+// log returns the logger of the debug logs, which is the standard logger
+// until the output log is started.
+func (p *Plugin) log() *logrus.Logger {
+	if p.settings.output == nil {
+		return logrus.StandardLogger()
+	}
+
+	return p.settings.output.logger
+}
+
+func (p *Plugin) validate() error {
 	var err error
 
-	if p.pipeline.Build.Event != "tag" {
-		return fmt.Errorf("github release plugin is only available for tags")
+	names := make([]string, 0, len(p.settings.Sources))
+	for name := range p.settings.Sources {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		p.log().Debugf("Setting %s loaded from %s", name, p.settings.Sources[name])
+	}
+
+	if !actionValues[p.settings.Action] {
+		return fmt.Errorf("invalid value for action")
+	}
+
+	if !handoffValues[p.settings.Handoff] {
+		return fmt.Errorf("invalid value for handoff")
+	}
+
+	if p.settings.Handoff == "write" && !p.settings.Draft {
+		return fmt.Errorf("handoff write is only available for drafts")
+	}
+
+	if !sameCommitValues[p.settings.SameCommit] {
+		return fmt.Errorf("invalid value for same_commit")
+	}
+
+	if !onMissingTagValues[p.settings.OnMissingTag] {
+		return fmt.Errorf("invalid value for on_missing_tag")
+	}
+
+	if p.settings.Action == "release" && (p.pipeline.Build.Event != "tag" || !strings.HasPrefix(p.pipeline.Commit.Ref, "refs/tags/")) {
+		switch p.settings.OnMissingTag {
+		case "skip":
+			fmt.Fprintln(p.out(), "No tag to release, skipping")
+			p.settings.skip = true
+			return nil
+		case "derive":
+			// read relative to the workdir, which is entered later on
+			versionFile := p.settings.DeriveTagFile
+
+			if versionFile != "" && p.settings.WorkDir != "" && !filepath.IsAbs(versionFile) {
+				versionFile = filepath.Join(p.settings.WorkDir, versionFile)
+			}
+
+			tag, err := deriveTag(versionFile, p.pipeline.Commit.SHA)
+
+			if err != nil {
+				return fmt.Errorf("failed to derive tag: %w", err)
+			}
+
+			fmt.Fprintf(p.out(), "No tag to release, using derived tag %s\n", tag)
+			p.pipeline.Commit.Ref = "refs/tags/" + tag
+			p.settings.derivedTag = true
+		default:
+			return fmt.Errorf("github release plugin is only available for tags, set on_missing_tag to derive or skip for other events")
+		}
+	}
+
+	if p.settings.APIKey == "" {
+		return fmt.Errorf("no api key provided")
+	}
+
+	if !fileExistsValues[p.settings.FileExists] {
+		return fmt.Errorf("invalid value for file_exists")
+	}
+
+	// cron lists use commas, so windows are separated by semicolons
+	for _, expr := range strings.Split(p.settings.FreezeWindows, ";") {
+		if strings.TrimSpace(expr) == "" {
+			continue
+		}
+
+		window, err := parseFreezeWindow(expr)
+
+		if err != nil {
+			return err
+		}
+
+		p.settings.freeze = append(p.settings.freeze, window)
+	}
+
+	if p.settings.freezeTZ, err = time.LoadLocation(p.settings.FreezeTimezone); err != nil {
+		return fmt.Errorf("invalid value for freeze_timezone: %w", err)
+	}
+
+	if p.settings.MaxTotalSize != "" {
+		if p.settings.maxSize, err = parseSize(p.settings.MaxTotalSize); err != nil {
+			return fmt.Errorf("invalid value for max_total_size: %w", err)
+		}
+	}
+
+	if !sizeBudgetValues[p.settings.SizeBudget] {
+		return fmt.Errorf("invalid value for size_budget")
+	}
+
+	if _, ok := presets[p.settings.TemplatePreset]; p.settings.TemplatePreset != "" && !ok {
+		return fmt.Errorf("invalid value for template_preset")
+	}
+
+	if p.settings.order, err = parseAssetsOrder(p.settings.AssetsOrder.Value()); err != nil {
+		return fmt.Errorf("invalid value for assets_order: %w", err)
+	}
+
+	if p.settings.SandboxRepo != "" {
+		if parts := strings.Split(p.settings.SandboxRepo, "/"); len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return fmt.Errorf("invalid value for sandbox_repo, expected owner/name")
+		}
+	}
+
+	if p.settings.FIPS {
+		for _, d := range fipsDisallowed {
+			if d.set(&p.settings) {
+				return fmt.Errorf("%s is not allowed in fips mode", d.name)
+			}
+		}
+	}
+
+	if !verifySignatureValues[p.settings.VerifySignature] {
+		return fmt.Errorf("invalid value for verify_signature")
+	}
+
+	if !queueValues[p.settings.Queue] {
+		return fmt.Errorf("invalid value for queue")
+	}
+
+	if !queueScopeValues[p.settings.QueueScope] {
+		return fmt.Errorf("invalid value for queue_scope")
+	}
+
+	if p.settings.QueueRepo != "" {
+		if parts := strings.Split(p.settings.QueueRepo, "/"); len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return fmt.Errorf("invalid value for queue_repo, expected owner/name")
+		}
+	}
+
+	// a lock shared by all repositories needs to live in one repository
+	if p.settings.Queue == "github" && p.settings.QueueScope == "token" && p.settings.QueueRepo == "" {
+		return fmt.Errorf("queue_repo is required for queue_scope token")
+	}
+
+	if !onImmutableValues[p.settings.OnImmutable] {
+		return fmt.Errorf("invalid value for on_immutable")
+	}
+
+	if !checksumAggregateValues[p.settings.ChecksumAggregate] {
+		return fmt.Errorf("invalid value for checksum_aggregate")
+	}
+
+	if !tagSyncValues[p.settings.TagSync] {
+		return fmt.Errorf("invalid value for tag_sync")
+	}
+
+	if !ipVersionValues[p.settings.IPVersion] {
+		return fmt.Errorf("invalid value for ip_version")
+	}
+
+	if p.settings.hosts, err = parseHostOverrides(p.settings.HostOverrides.Value()); err != nil {
+		return fmt.Errorf("invalid host overrides: %w", err)
+	}
+
+	p.settings.baseURL, p.settings.uploadURL, err = gitHubURLs(p.settings.GitHubURL)
+	if err != nil {
+		return fmt.Errorf("failed to get GitHub urls: %w", err)
+	}
+
+	// base_url and upload_url can be overridden independently, e.g. if
+	// uploads are exposed on a different host by a reverse proxy
+	if p.settings.BaseURL != "" {
+		if p.settings.baseURL, p.settings.uploadURL, err = enterpriseURLs(p.settings.BaseURL); err != nil {
+			return fmt.Errorf("failed to parse base url: %w", err)
+		}
+	}
+
+	if p.settings.UploadURL != "" {
+		if p.settings.uploadURL, err = parseUploadURL(p.settings.UploadURL); err != nil {
+			return fmt.Errorf("failed to parse upload url: %w", err)
+		}
+	}
+
+	if p.settings.VersionFileRepo != "" {
+		if parts := strings.Split(p.settings.VersionFileRepo, "/"); len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return fmt.Errorf("invalid value for version_file_repo, expected owner/name")
+		}
+
+		if p.settings.VersionFilePath == "" {
+			return fmt.Errorf("no version_file_path provided")
+		}
+	}
+
+	if p.settings.PackageRepo != "" {
+		if parts := strings.Split(p.settings.PackageRepo, "/"); len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return fmt.Errorf("invalid value for package_repo, expected owner/name")
+		}
+
+		for _, entry := range p.settings.PackageTemplates.Value() {
+			if pair := strings.SplitN(entry, "=", 2); len(pair) != 2 || pair[0] == "" || pair[1] == "" {
+				return fmt.Errorf("invalid package template %s, expected template=path", entry)
+			}
+		}
+	}
+
+	if p.settings.Action == "compare" {
+		if p.settings.CompareBase == "" {
+			return fmt.Errorf("no compare_base provided")
+		}
+
+		if p.settings.CompareHead == "" {
+			p.settings.CompareHead = strings.TrimPrefix(p.pipeline.Commit.Ref, "refs/tags/")
+		}
+
+		return nil
+	}
+
+	if p.settings.Action == "list" {
+		p.settings.listFilter, err = newListFilter(
+			p.settings.ListDraft,
+			p.settings.ListPrerelease,
+			p.settings.ListTagPattern,
+			p.settings.ListSince,
+		)
+		if err != nil {
+			return fmt.Errorf("invalid list filter: %w", err)
+		}
+
+		return nil
+	}
+
+	// a published immutable release can't be changed, the new tag released
+	// instead has to be known before anything gets named after the tag
+	if p.settings.OnImmutable == "new-tag" {
+		if err := p.resolveImmutableTag(); err != nil {
+			return err
+		}
+	}
+
+	// the metadata is required to recognize releases created by the plugin
+	if p.settings.OnlyManageOwn || p.settings.OwnAssets || p.settings.Resume || p.settings.Canary {
+		p.settings.Metadata = true
+	}
+
+	if p.settings.Metadata {
+		if p.settings.metadata, err = parseMetadataValues(p.settings.MetadataValues.Value()); err != nil {
+			return fmt.Errorf("invalid metadata: %w", err)
+		}
+
+		p.settings.metadata = mergeMetadata(map[string]string{
+			"build":  strconv.Itoa(p.pipeline.Build.Number),
+			"link":   p.pipeline.Build.Link,
+			"commit": p.pipeline.Commit.SHA,
+			"tag":    strings.TrimPrefix(p.pipeline.Commit.Ref, "refs/tags/"),
+		}, p.settings.metadata)
+	}
+
+	if err := p.prepareWorkspace(); err != nil {
+		return err
+	}
+
+	if p.settings.Note != "" {
+		if p.settings.Note, err = readStringOrFile(p.settings.Note); err != nil {
+			return fmt.Errorf("error while reading %s: %w", p.settings.Note, err)
+		}
+	}
+
+	if p.settings.CanaryNote != "" {
+		if p.settings.CanaryNote, err = readStringOrFile(p.settings.CanaryNote); err != nil {
+			return fmt.Errorf("error while reading %s: %w", p.settings.CanaryNote, err)
+		}
+	}
+
+	if p.settings.Title != "" {
+		if p.settings.Title, err = readStringOrFile(p.settings.Title); err != nil {
+			return fmt.Errorf("error while reading %s: %w", p.settings.Note, err)
+		}
+	}
+
+	files, err := readFileLists(p.settings.Files.Value())
+
+	if err != nil {
+		return err
+	}
+
+	maxStream, err := parseSize(p.settings.StreamMaxSize)
+
+	if err != nil {
+		return fmt.Errorf("invalid value for stream_max_size: %w", err)
+	}
+
+	for _, entry := range files {
+		source, _, ok := parseStream(entry)
+
+		if !ok {
+			continue
+		}
+
+		// the confirmation prompt reads stdin as well
+		if source == "-" && !p.settings.Yes && isInteractive(p.settings.CI) {
+			return fmt.Errorf("reading an asset from stdin requires yes in interactive runs")
+		}
+
+		for _, d := range streamDisallowed {
+			if d.set(&p.settings) {
+				return fmt.Errorf("streamed assets cannot be used with %s", d.name)
+			}
+		}
+	}
+
+	globs, streams, err := readStreams(p.out(), files, os.Stdin, maxStream)
+
+	if err != nil {
+		return err
+	}
+
+	p.settings.streams = streams
+
+	if p.settings.uploads, err = expandFiles(p.out(), p.log(), globs, p.settings.FilesDepth); err != nil {
+		return err
+	}
+
+	if p.settings.uploads, err = excludeFiles(p.out(), p.settings.uploads, p.settings.FilesExclude.Value()); err != nil {
+		return err
+	}
+
+	if len(files) > 0 && len(p.settings.uploads)+len(p.settings.streams) < 1 {
+		return fmt.Errorf("failed to find any file to release")
+	}
+
+	if p.settings.VerifyFiles != "" {
+		if p.settings.VerifyFilesMethod != "" && !checksumValues[p.settings.VerifyFilesMethod] {
+			return fmt.Errorf("invalid value for verify_files_method")
+		}
+
+		hashes, err := readChecksumManifest(p.settings.VerifyFiles, p.settings.VerifyFilesMethod)
+
+		if err != nil {
+			return fmt.Errorf("failed to read checksum manifest: %w", err)
+		}
+
+		var allowed map[string]bool
+
+		if p.settings.FIPS {
+			allowed = fipsChecksumValues
+		}
+
+		if err := verifyChecksums(p.settings.uploads, hashes, allowed); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(p.out(), "Successfully verified %d files against %s\n", len(p.settings.uploads), p.settings.VerifyFiles)
+	}
+
+	if p.settings.IntotoLayout != "" {
+		if len(p.settings.IntotoKeys.Value()) == 0 {
+			return fmt.Errorf("intoto_keys are required to verify the in-toto layout")
+		}
+
+		if err := verifyInToto(p.out(), p.settings.IntotoCommand, p.settings.IntotoLayout, p.settings.IntotoKeys.Value(), p.settings.IntotoLinkDir); err != nil {
+			return fmt.Errorf("in-toto verification failed: %w", err)
+		}
+	}
+
+	if p.settings.Bundles != "" {
+		if !bundlePermissionsValues[p.settings.BundlePermissions] {
+			return fmt.Errorf("invalid value for bundle_permissions")
+		}
+
+		if p.settings.bundles, err = parseBundles(p.settings.Bundles); err != nil {
+			return fmt.Errorf("failed to parse bundles: %w", err)
+		}
+
+		dir, err := p.runSubDir("bundles")
+
+		if err != nil {
+			return fmt.Errorf("failed to create bundle directory: %w", err)
+		}
+
+		opts := archiveOptions{
+			Permissions:  p.settings.BundlePermissions,
+			Reproducible: p.settings.BundleReproducible,
+			ModTime:      time.Unix(p.settings.BundleModTime, 0).UTC(),
+		}
+
+		if p.settings.BundleBuildInfo != "" {
+			infoDir, err := p.runSubDir("buildinfo")
+
+			if err != nil {
+				return err
+			}
+
+			opts.BuildInfo = filepath.Join(infoDir, p.settings.BundleBuildInfo)
+
+			goVersion := p.settings.BundleGoVersion
+
+			if goVersion == "" {
+				var binaries []string
+
+				for _, b := range p.settings.bundles {
+					files, err := globRegularFiles(b.Files)
+
+					if err != nil {
+						return err
+					}
+
+					binaries = append(binaries, files...)
+				}
+
+				goVersion = binariesGoVersion(binaries)
+			}
+
+			err = writeBuildInfo(opts.BuildInfo, buildInfo{
+				Commit:    p.pipeline.Commit.SHA,
+				Tag:       strings.TrimPrefix(p.pipeline.Commit.Ref, "refs/tags/"),
+				BuildURL:  p.pipeline.Build.Link,
+				GoVersion: goVersion,
+			})
+
+			if err != nil {
+				return fmt.Errorf("failed to write build info: %w", err)
+			}
+		}
+
+		archives, err := writeBundles(p.out(), p.settings.bundles, p.settings.BundleIncludes.Value(), dir, opts)
+
+		if err != nil {
+			return fmt.Errorf("failed to write bundles: %w", err)
+		}
+
+		p.settings.uploads = append(p.settings.uploads, archives...)
+	}
+
+	if p.settings.SBOM != "" {
+		if err := checkSBOM(p.out(), p.settings.SBOM); err != nil {
+			return fmt.Errorf("invalid sbom: %w", err)
+		}
+
+		p.settings.uploads = append(p.settings.uploads, p.settings.SBOM)
+	}
+
+	if p.settings.SBOMCommand != "" {
+		dir, err := p.runSubDir("sbom")
+
+		if err != nil {
+			return err
+		}
+
+		sbom, err := generateSBOM(p.out(), p.settings.SBOMCommand, p.pipeline.Repo.Name, dir)
+
+		if err != nil {
+			return fmt.Errorf("failed to generate sbom: %w", err)
+		}
+
+		p.settings.uploads = append(p.settings.uploads, sbom)
+	}
+
+	if p.settings.InlineAssets != "" {
+		assets, err := parseInlineAssets(p.settings.InlineAssets)
+
+		if err != nil {
+			return fmt.Errorf("failed to parse inline assets: %w", err)
+		}
+
+		dir, err := p.runSubDir("inline")
+
+		if err != nil {
+			return err
+		}
+
+		data := p.templateData()
+
+		if data.Assets, err = p.plannedAssets(); err != nil {
+			return fmt.Errorf("failed to describe the assets: %w", err)
+		}
+
+		files, err := writeInlineAssets(p.out(), assets, data, dir)
+
+		if err != nil {
+			return err
+		}
+
+		p.settings.uploads = append(p.settings.uploads, files...)
+	}
+
+	if p.settings.DedupAssets {
+		var aliases map[string][]string
+
+		if p.settings.uploads, aliases, err = dedupFiles(p.settings.uploads); err != nil {
+			return fmt.Errorf("failed to deduplicate files: %w", err)
+		}
+
+		if notes := aliasNotes(p.settings.uploads, aliases); notes != "" {
+			p.settings.Note = strings.TrimSpace(p.settings.Note + "\n\n" + notes)
+		}
+	}
+
+	// patterns may contain commas, so they can be listed in files
+	redactions, err := readFileLists(p.settings.NotesRedactions.Value())
+
+	if err != nil {
+		return err
+	}
+
+	for _, pattern := range redactions {
+		re, err := regexp.Compile(pattern)
+
+		if err != nil {
+			return fmt.Errorf("invalid redaction %s: %w", pattern, err)
+		}
+
+		p.settings.redactions = append(p.settings.redactions, re)
+	}
+
+	if !encryptValues[p.settings.Encrypt] {
+		return fmt.Errorf("invalid value for encrypt")
+	}
+
+	// only the ciphertext is published, so everything after works on the
+	// encrypted files
+	if p.settings.Encrypt != "" {
+		dir, err := p.runSubDir("encrypted")
+
+		if err != nil {
+			return err
+		}
+
+		e, err := newEncrypter(p.settings.Encrypt, p.settings.EncryptRecipients.Value(), dir)
+
+		if err != nil {
+			return err
+		}
+
+		if p.settings.uploads, err = e.encryptFiles(p.out(), p.settings.uploads, dir); err != nil {
+			return err
+		}
+	}
+
+	if p.settings.ArtifactsSection {
+		var images []imageArtifact
+
+		if p.settings.ImageManifest != "" {
+			if _, err := os.Stat(p.settings.ImageManifest); err == nil {
+				if images, err = readImageManifest(p.settings.ImageManifest); err != nil {
+					return err
+				}
+			} else {
+				fmt.Fprintf(p.out(), "No image manifest found at %s\n", p.settings.ImageManifest)
+			}
+		}
+
+		section, err := artifactsSection(p.settings.uploads, images)
+
+		if err != nil {
+			return fmt.Errorf("failed to render artifacts section: %w", err)
+		}
+
+		if section != "" {
+			p.settings.Note = strings.TrimSpace(p.settings.Note + "\n\n" + section)
+		}
+	}
+
+	if !cosignValues[p.settings.Cosign] {
+		return fmt.Errorf("invalid value for cosign")
+	}
+
+	if !checksumFormatValues[p.settings.ChecksumFormat] {
+		return fmt.Errorf("invalid value for checksum_format")
+	}
+
+	var minisign *minisignKey
+
+	if p.settings.MinisignKey != "" {
+		if len(p.settings.Checksum.Value()) == 0 && p.settings.ChecksumCombined == "" {
+			return fmt.Errorf("minisign_key requires checksum or checksum_combined")
+		}
+
+		key, err := readStringOrFile(p.settings.MinisignKey)
+
+		if err != nil {
+			return fmt.Errorf("error while reading minisign key: %w", err)
+		}
+
+		if minisign, err = parseMinisignKey(key, p.settings.MinisignPassword); err != nil {
+			return err
+		}
+	}
+
+	var ssh *sshSigner
+
+	if p.settings.SSHKey != "" {
+		if len(p.settings.Checksum.Value()) == 0 && p.settings.ChecksumCombined == "" {
+			return fmt.Errorf("ssh_key requires checksum or checksum_combined")
+		}
+
+		if ssh, err = p.newSSHSigner(); err != nil {
+			return err
+		}
+	}
+
+	if p.settings.ChecksumSidecar && p.settings.ChecksumCombined != "" {
+		return fmt.Errorf("checksum_sidecar and checksum_combined cannot be used together")
+	}
+
+	checksum := p.settings.Checksum.Value()
+	for _, method := range checksum {
+		if !checksumValues[method] {
+			return fmt.Errorf("hashing method %s is not supported", method)
+		}
+	}
+
+	if p.settings.FIPS {
+		for _, method := range checksum {
+			if !fipsChecksumValues[method] {
+				return fmt.Errorf("hashing method %s is not allowed in fips mode", method)
+			}
+		}
+	}
+
+	var (
+		sidecars   []string
+		sumsSigned []string
+	)
+
+	if p.settings.ChecksumSidecar {
+		dir, err := p.runSubDir("sidecars")
+
+		if err != nil {
+			return err
+		}
+
+		if sidecars, err = writeSidecars(p.settings.uploads, dir, p.settings.ChecksumFormat); err != nil {
+			return fmt.Errorf("failed to write checksum sidecars: %w", err)
+		}
+	}
+
+	if len(checksum) > 0 {
+		tag := strings.TrimPrefix(p.pipeline.Commit.Ref, "refs/tags/")
+		format := renderName(p.settings.ChecksumFile, map[string]string{
+			"project": p.pipeline.Repo.Name,
+			"tag":     tag,
+			"version": strings.TrimPrefix(tag, "v"),
+		})
+
+		count := len(p.settings.uploads)
+		p.settings.uploads, err = writeChecksums(p.settings.uploads, checksum, format, p.settings.ChecksumFormat, p.settings.ChecksumFlatten)
+
+		if err != nil {
+			return fmt.Errorf("failed to write checksums: %w", err)
+		}
+
+		sums := p.settings.uploads[count:]
+
+		if minisign != nil && p.settings.ChecksumCombined == "" {
+			dir, err := p.runSubDir("minisign")
+
+			if err != nil {
+				return err
+			}
+
+			signatures, err := minisign.signFiles(p.out(), sums, dir)
+
+			if err != nil {
+				return fmt.Errorf("failed to sign checksums: %w", err)
+			}
+
+			sumsSigned = append(sumsSigned, signatures...)
+		}
+
+		if ssh != nil && p.settings.ChecksumCombined == "" {
+			dir, err := p.runSubDir("sshsig")
+
+			if err != nil {
+				return err
+			}
+
+			signatures, err := ssh.signFiles(p.out(), sums, dir)
+
+			if err != nil {
+				return fmt.Errorf("failed to sign checksums: %w", err)
+			}
+
+			sumsSigned = append(sumsSigned, signatures...)
+		}
+	}
+
+	p.settings.uploads = append(p.settings.uploads, sidecars...)
+
+	if len(p.settings.LatestAliases.Value()) > 0 {
+		dir, err := p.runSubDir("latest")
+
+		if err != nil {
+			return err
+		}
+
+		tag := strings.TrimPrefix(p.pipeline.Commit.Ref, "refs/tags/")
+
+		if p.settings.latest, err = latestAliases(p.settings.uploads, p.settings.LatestAliases.Value(), tag, dir); err != nil {
+			return err
+		}
+	}
+
+	if p.settings.maxSize > 0 {
+		if err := checkSizeBudget(p.out(), p.settings.uploads, p.settings.maxSize); err != nil {
+			if p.settings.SizeBudget == "fail" {
+				return err
+			}
+
+			fmt.Fprintf(p.out(), "Warning: %s\n", err)
+		}
+	}
+
+	if p.settings.ChecksumAggregate == "partial" {
+		stage := p.pipeline.Stage.Name
+
+		if stage == "" {
+			stage = strconv.Itoa(p.pipeline.Stage.Number)
+		}
+
+		dir, err := p.runSubDir("checksums")
+
+		if err != nil {
+			return fmt.Errorf("failed to create checksum directory: %w", err)
+		}
+
+		partial, err := writePartialChecksums(p.settings.uploads, stage, dir)
+
+		if err != nil {
+			return fmt.Errorf("failed to write partial checksums: %w", err)
+		}
+
+		p.settings.uploads = append(p.settings.uploads, partial)
+	}
+
+	if p.settings.ScanCommand != "" {
+		if err := scanFiles(p.out(), p.settings.ScanCommand, p.settings.uploads, p.settings.ScanReport); err != nil {
+			return fmt.Errorf("failed to scan files: %w", err)
+		}
+
+		p.settings.uploads = append(p.settings.uploads, p.settings.ScanReport)
+	}
+
+	if p.settings.Provenance {
+		subjects, err := fileSubjects(p.settings.uploads)
+
+		if err != nil {
+			return fmt.Errorf("failed to describe provenance subjects: %w", err)
+		}
+
+		dir, err := p.runSubDir("provenance")
+
+		if err != nil {
+			return err
+		}
+
+		file := filepath.Join(dir, provenanceName)
+
+		if err := writeProvenance(file, buildProvenance(subjects, p.pipeline, time.Now().UTC())); err != nil {
+			return fmt.Errorf("failed to write provenance: %w", err)
+		}
+
+		p.settings.uploads = append(p.settings.uploads, file)
+	}
+
+	if len(p.settings.Attestations.Value()) > 0 {
+		files, err := expandFiles(p.out(), p.log(), p.settings.Attestations.Value(), 0)
+
+		if err != nil {
+			return fmt.Errorf("failed to find attestations: %w", err)
+		}
+
+		assets, err := fileSubjects(p.settings.uploads)
+
+		if err != nil {
+			return fmt.Errorf("failed to describe attestation subjects: %w", err)
+		}
+
+		dir, err := p.runSubDir("attestations")
+
+		if err != nil {
+			return err
+		}
+
+		attestations, err := writeAttestations(p.out(), files, assets, dir)
+
+		if err != nil {
+			return err
+		}
+
+		p.settings.uploads = append(p.settings.uploads, attestations...)
+	}
+
+	// signatures are only created for the released files, not for other
+	// signatures
+	signed := p.settings.uploads
+
+	var signer *gpgSigner
+
+	if p.settings.GPGKey != "" {
+		key, err := readStringOrFile(p.settings.GPGKey)
+
+		if err != nil {
+			return fmt.Errorf("error while reading gpg key: %w", err)
+		}
+
+		home, err := p.runSubDir("gnupg")
+
+		if err != nil {
+			return err
+		}
+
+		if signer, err = newGPGSigner(key, p.settings.GPGPassphrase, home); err != nil {
+			return err
+		}
+
+		dir, err := p.runSubDir("signatures")
+
+		if err != nil {
+			return err
+		}
+
+		signatures, err := signer.signFiles(p.out(), signed, dir)
+
+		if err != nil {
+			return err
+		}
+
+		p.settings.uploads = append(p.settings.uploads, signatures...)
+	}
+
+	if p.settings.Cosign != "" {
+		dir, err := p.runSubDir("cosign")
+
+		if err != nil {
+			return err
+		}
+
+		cosign := cosignSigner{
+			Command:       p.settings.CosignCommand,
+			Password:      p.settings.CosignPassword,
+			IdentityToken: p.settings.CosignIdentityToken,
+		}
+
+		if p.settings.Cosign == "key" {
+			if p.settings.CosignKey == "" {
+				return fmt.Errorf("cosign_key is required for cosign key signing")
+			}
+
+			// cosign reads the key from a file or a kms reference
+			if strings.Contains(p.settings.CosignKey, "PRIVATE KEY") {
+				cosign.Key = filepath.Join(dir, "cosign.key")
+
+				if err := ioutil.WriteFile(cosign.Key, []byte(p.settings.CosignKey), 0600); err != nil {
+					return err
+				}
+
+				// removed once the files are signed, even if the temp dir is kept
+				defer os.Remove(cosign.Key)
+			} else {
+				cosign.Key = p.settings.CosignKey
+			}
+		}
+
+		signatures, err := cosign.signFiles(p.out(), signed, dir)
+
+		if err != nil {
+			return err
+		}
+
+		p.settings.uploads = append(p.settings.uploads, signatures...)
+	}
+
+	// added after gpg and cosign signed the released files
+	p.settings.uploads = append(p.settings.uploads, sumsSigned...)
+
+	if p.settings.ChecksumCombined != "" && len(p.settings.uploads) > 0 {
+		dir, err := p.runSubDir("combined")
+
+		if err != nil {
+			return err
+		}
+
+		// uploaded separately once all other assets are up
+		files, err := writeChecksums(p.settings.uploads, []string{"sha256"}, filepath.Join(dir, p.settings.ChecksumCombined), p.settings.ChecksumFormat, true)
+
+		if err != nil {
+			return fmt.Errorf("failed to write combined checksums: %w", err)
+		}
+
+		// only the checksums are signed, not the signatures of the other signers
+		sums := files[len(files)-1:]
+		p.settings.combined = sums
+
+		if minisign != nil {
+			signatures, err := minisign.signFiles(p.out(), sums, dir)
+
+			if err != nil {
+				return fmt.Errorf("failed to sign combined checksums: %w", err)
+			}
+
+			p.settings.combined = append(p.settings.combined, signatures...)
+		}
+
+		if ssh != nil {
+			signatures, err := ssh.signFiles(p.out(), sums, dir)
+
+			if err != nil {
+				return fmt.Errorf("failed to sign combined checksums: %w", err)
+			}
+
+			p.settings.combined = append(p.settings.combined, signatures...)
+		}
+
+		if signer != nil {
+			signatures, err := signer.signFiles(p.out(), sums, dir)
+
+			if err != nil {
+				return err
+			}
+
+			p.settings.combined = append(p.settings.combined, signatures...)
+		}
+	}
+
+	// built once all assets are known
+	if p.settings.TemplatePreset != "" {
+		data := p.templateData()
+
+		if data.Assets, err = p.plannedAssets(); err != nil {
+			return fmt.Errorf("failed to describe the assets: %w", err)
+		}
+
+		p.settings.Title, p.settings.Note, err = applyPreset(p.settings.TemplatePreset, p.settings.Title, p.settings.Note, data)
+
+		if err != nil {
+			return fmt.Errorf("failed to render template preset: %w", err)
+		}
+	}
+
+	// the manifest describes all other assets, including the combined
+	// checksums, but isn't part of them itself
+	if p.settings.AssetsManifest != "" {
+		assets, err := p.plannedAssets()
+
+		if err != nil {
+			return fmt.Errorf("failed to describe the assets: %w", err)
+		}
+
+		manifest := buildAssetsManifest(p.templateData().Tag, assets, p.settings.order)
+		target := filepath.Join(p.settings.runDir, p.settings.AssetsManifest)
+
+		if err := writeJSON(p.results(), target, manifest); err != nil {
+			return fmt.Errorf("failed to write assets manifest: %w", err)
+		}
+
+		p.settings.uploads = append(p.settings.uploads, target)
+	}
+
+	if len(p.settings.RequiredFiles.Value()) > 0 || len(p.settings.RequiredNotes.Value()) > 0 {
+		if err := checkPolicy(p.settings.uploads, p.settings.RequiredFiles.Value(), p.settings.Note, p.settings.RequiredNotes.Value()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// resolveImmutableTag switches the pipeline to the next free tag if the
+// release of the tag is published and immutable.
+func (p *Plugin) resolveImmutableTag() error {
+	rc, err := p.newReleaseClient()
+
+	if err != nil {
+		return err
+	}
+
+	tag, err := rc.immutableTag()
+
+	if err != nil {
+		return err
+	}
+
+	if tag != rc.Tag {
+		p.pipeline.Commit.Ref = "refs/tags/" + tag
+		p.settings.newTag = true
+	}
+
+	return nil
+}
+
+// newReleaseClient returns a client for the release of the pipeline tag.
+func (p *Plugin) newReleaseClient() (*releaseClient, error) {
+	httpClient, err := configureTransport(p.network.Client, transportOptions{
+		IPVersion:           p.settings.IPVersion,
+		Hosts:               p.settings.hosts,
+		MaxIdleConns:        p.settings.MaxIdleConns,
+		MaxIdleConnsPerHost: p.settings.MaxIdleConnsPerHost,
+		DisableCompression:  p.settings.DisableCompression,
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure transport: %w", err)
+	}
+
+	// statistics are gathered once the release is executed
+	if p.settings.stats != nil {
+		httpClient.Transport = &statsTransport{
+			base:  httpClient.Transport,
+			stats: p.settings.stats,
+		}
+	}
+
+	if p.log().IsLevelEnabled(logrus.DebugLevel) {
+		httpClient.Transport = &debugTransport{base: httpClient.Transport, log: p.log()}
+	}
+
+	ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: p.settings.APIKey})
+	tc := oauth2.NewClient(
+		context.WithValue(context.Background(), oauth2.HTTPClient, httpClient),
+		ts,
+	)
+
+	client := github.NewClient(tc)
+	client.BaseURL = p.settings.baseURL
+	client.UploadURL = p.settings.uploadURL
+
+	return &releaseClient{
+		Client:               client,
+		HTTPClient:           httpClient,
+		Context:              p.network.Context,
+		Owner:                p.pipeline.Repo.Owner,
+		Repo:                 p.pipeline.Repo.Name,
+		Tag:                  strings.TrimPrefix(p.pipeline.Commit.Ref, "refs/tags/"),
+		Commit:               p.pipeline.Commit.SHA,
+		Draft:                p.settings.Draft,
+		Prerelease:           p.settings.Prerelease,
+		FileExists:           p.settings.FileExists,
+		Title:                p.settings.Title,
+		Note:                 p.settings.Note,
+		Overwrite:            p.settings.Overwrite,
+		GenerateReleaseNotes: p.settings.GenerateReleaseNotes,
+		ConsolidateDrafts:    p.settings.ConsolidateDrafts,
+		ImportPrereleases:    p.settings.ImportPrereleases,
+		PromoteAssets:        p.settings.PromoteAssets,
+		Metadata:             p.settings.metadata,
+		OnlyManageOwn:        p.settings.OnlyManageOwn,
+		Force:                p.settings.Force,
+		Resume:               p.settings.Resume,
+		TempDir:              p.settings.runDir,
+		DiscussionCategory:   p.settings.DiscussionCategory,
+		Redactions:           p.settings.redactions,
+		ProtectDownloaded:    p.settings.ProtectDownloaded,
+		OwnAssets:            p.settings.OwnAssets,
+		Streams:              p.settings.streams,
+		ScanSecrets:          p.settings.SecretScan,
+		Out:                  p.out(),
+	}, nil
+}
+
+// Execute provides the implementation of the plugin.
+func (p *Plugin) Execute() (err error) {
+	defer func() {
+		err = p.stopOutputLog(err)
+	}()
+	defer p.cleanup()
+
+	p.settings.stats = &runStats{
+		Repo:    p.pipeline.Repo.Slug,
+		Tag:     strings.TrimPrefix(p.pipeline.Commit.Ref, "refs/tags/"),
+		Build:   p.pipeline.Build.Number,
+		Started: time.Now(),
+	}
+
+	err = p.settings.scrubber.scrubError(p.execute())
+	p.settings.stats.finish(err)
+
+	// statistics are best effort and never fail the release
+	if p.settings.StatsFile != "" {
+		if err := appendStats(p.settings.StatsFile, p.settings.stats); err != nil {
+			fmt.Fprintf(p.out(), "Warning: failed to write run statistics: %s\n", err)
+		}
+	}
+
+	if p.settings.StatsEndpoint != "" {
+		if err := postJSON(p.network.Client, p.settings.StatsEndpoint, p.settings.stats); err != nil {
+			fmt.Fprintf(p.out(), "Warning: failed to post run statistics: %s\n", err)
+		}
+	}
+
+	return err
+}
+
+func (p *Plugin) execute() error {
+	if p.settings.skip {
+		return nil
+	}
+
+	rc, err := p.newReleaseClient()
+
+	if err != nil {
+		return err
+	}
+
+	if p.settings.derivedTag {
+		if err := rc.checkDerivedTag(); err != nil {
+			return err
+		}
+	}
+
+	// derived tags and tags replacing immutable releases don't exist yet and
+	// get created for the commit
+	if p.settings.derivedTag || p.settings.newTag {
+		rc.Target = p.pipeline.Commit.SHA
+	}
+
+	if p.settings.NoteTemplate != "" || p.settings.NotesStats || p.settings.CommentTemplate != "" || len(p.settings.PackageTemplates.Value()) > 0 {
+		if p.settings.previous, err = rc.previousRelease(); err != nil {
+			return err
+		}
+	}
+
+	// the notes are passed as data, so they never get parsed as a template
+	if p.settings.NoteTemplate != "" {
+		text, err := readStringOrFile(p.settings.NoteTemplate)
+
+		if err != nil {
+			return fmt.Errorf("failed to read note template: %w", err)
+		}
+
+		data := p.templateData()
+		data.Notes = rc.Note
+
+		if rc.Note, err = renderTemplate("note", text, data); err != nil {
+			return fmt.Errorf("failed to render note template: %w", err)
+		}
+
+		rc.Note = strings.TrimSpace(rc.Note)
+	}
+
+	if p.settings.NotesStats && p.settings.previous != nil {
+		section, err := rc.statsSection(p.settings.previous, p.settings.uploads)
+
+		if err != nil {
+			return fmt.Errorf("failed to gather release stats: %w", err)
+		}
+
+		rc.Note = strings.TrimSpace(rc.Note + "\n\n" + section)
+	}
+
+	if p.settings.Action == "list" {
+		releases, err := rc.listReleases(p.settings.listFilter)
+
+		if err != nil {
+			return fmt.Errorf("failed to list releases: %w", err)
+		}
+
+		if err := writeJSON(p.results(), p.settings.ResultFile, releases); err != nil {
+			return fmt.Errorf("failed to write result: %w", err)
+		}
+
+		return nil
+	}
+
+	if p.settings.Action == "compare" {
+		report, err := rc.compareTags(p.settings.CompareBase, p.settings.CompareHead)
+
+		if err != nil {
+			return fmt.Errorf("failed to compare tags: %w", err)
+		}
+
+		if err := writeJSON(p.results(), p.settings.ResultFile, report); err != nil {
+			return fmt.Errorf("failed to write result: %w", err)
+		}
+
+		return nil
+	}
+
+	// a dry run against a sandbox runs all steps, with every repository
+	// written to replaced by the sandbox
+	if p.settings.DryRun && p.settings.SandboxRepo != "" {
+		fmt.Fprintf(p.out(), "Running dry run against sandbox repository %s\n", p.settings.SandboxRepo)
+
+		for _, step := range p.useSandbox(rc) {
+			fmt.Fprintf(p.out(), "Skipping %s, the commit is not part of the sandbox\n", step)
+		}
+	}
+
+	if p.settings.DryRun && p.settings.SandboxRepo == "" {
+		plan, err := rc.plan(p.settings.uploads)
+
+		if err != nil {
+			return fmt.Errorf("failed to plan the release: %w", err)
+		}
+
+		if err := writeJSON(p.results(), p.settings.ResultFile, plan); err != nil {
+			return fmt.Errorf("failed to write result: %w", err)
+		}
+
+		return nil
+	}
+
+	if p.settings.Queue == "github" {
+		lock := repoLock{
+			Owner: rc.Owner,
+			Repo:  rc.Repo,
+			Ref:   lockRef(p.settings.QueueScope, rc.Owner, rc.Repo),
+		}
+
+		if p.settings.QueueRepo != "" {
+			parts := strings.Split(p.settings.QueueRepo, "/")
+			lock.Owner, lock.Repo = parts[0], parts[1]
+		}
+
+		sha, err := rc.acquireLock(lock, p.settings.QueueTimeout, p.settings.QueueStale, p.settings.QueueInterval)
+
+		if err != nil {
+			return fmt.Errorf("failed to acquire the release lock: %w", err)
+		}
+
+		defer func() {
+			if err := rc.releaseLock(lock, sha); err != nil {
+				fmt.Fprintf(p.out(), "Warning: %s\n", err)
+			}
+		}()
+	}
+
+	if p.settings.Preflight {
+		if err := rc.preflight(); err != nil {
+			return fmt.Errorf("preflight check failed: %w", err)
+		}
+	}
+
+	if p.settings.CheckRulesets {
+		// tags the run is going to create or move
+		tags := make(map[string][]string)
+
+		if p.settings.derivedTag || p.settings.newTag {
+			tags[rc.Tag] = append(tags[rc.Tag], "creation")
+		}
+
+		if p.settings.TagSync == "to-tag" {
+			tags[rc.Tag] = append(tags[rc.Tag], "update")
+		}
+
+		if len(p.settings.latest) > 0 {
+			tags[p.settings.LatestTag] = []string{"creation", "update"}
+		}
+
+		if p.settings.AliasTags && !rc.Draft && !rc.Prerelease {
+			for _, alias := range aliasTags(rc.Tag) {
+				tags[alias] = []string{"creation", "update"}
+			}
+		}
+
+		if err := rc.checkRulesets(tags); err != nil {
+			return fmt.Errorf("ruleset check failed: %w", err)
+		}
+	}
+
+	if p.settings.ForkCheck {
+		if err := rc.checkForkSafety(p.settings.SourceRepo); err != nil {
+			return fmt.Errorf("fork safety check failed: %w", err)
+		}
+	}
+
+	if !rc.Draft {
+		if expr, ok := activeFreeze(p.settings.freeze, time.Now().In(p.settings.freezeTZ)); ok {
+			fmt.Fprintf(p.out(), "Release freeze %q is active, staging the release as draft\n", expr)
+			rc.Draft = true
+		}
+	}
+
+	uploads := p.settings.uploads
+
+	if p.settings.Canary {
+		if uploads, err = p.canaryStage(rc, uploads); err != nil {
+			return fmt.Errorf("failed to prepare the canary stage: %w", err)
+		}
+	}
+
+	if p.settings.VerifySignature != "" && !rc.Draft {
+		if err := rc.verifySignature(p.settings.VerifySignature, p.settings.AllowedSigners.Value()); err != nil {
+			return fmt.Errorf("signature verification failed: %w", err)
+		}
+	}
+
+	if p.settings.RequireChecks && !rc.Draft {
+		if err := rc.waitForChecks(p.settings.ChecksIgnore.Value(), p.settings.ChecksTimeout, p.settings.ChecksInterval); err != nil {
+			return fmt.Errorf("status check gate failed: %w", err)
+		}
+	}
+
+	if p.settings.RequiredApprovals > 0 && !rc.Draft {
+		if err := rc.checkApprovals(p.settings.RequiredApprovals); err != nil {
+			return fmt.Errorf("approval check failed: %w", err)
+		}
+	}
+
+	if p.settings.TagSync == "from-tag" {
+		message, err := rc.tagMessage()
+
+		if err != nil {
+			return fmt.Errorf("failed to read the tag message: %w", err)
+		}
+
+		if message != "" {
+			rc.Note = message
+		}
+	}
+
+	proceed, err := rc.handleImmutable(p.settings.OnImmutable)
+
+	if err != nil {
+		return err
+	}
+
+	if !proceed {
+		return nil
 	}
 
-	if p.settings.APIKey == "" {
-		return fmt.Errorf("no api key provided")
+	if p.settings.SizeDriftThreshold > 0 {
+		if err := rc.checkSizeDrift(uploads, p.settings.SizeDriftThreshold); err != nil {
+			return fmt.Errorf("size drift check failed: %w", err)
+		}
 	}
 
-	if !fileExistsValues[p.settings.FileExists] {
-		return fmt.Errorf("invalid value for file_exists")
+	if p.settings.SecretScan {
+		// the notes are scanned by prepareNote once they are complete
+		if err := scanSecrets(rc.Title, "", rc.Metadata, uploads); err != nil {
+			return fmt.Errorf("secret scan failed: %w", err)
+		}
 	}
 
-	if p.settings.BaseURL != "" && p.settings.UploadURL != "" {
-		fmt.Printf("Both base_url and upload_url are deprecated. Please remove them from your config!")
+	var sameCommit []*github.RepositoryRelease
+
+	if p.settings.SameCommit != "" {
+		if sameCommit, err = rc.sameCommitReleases(); err != nil {
+			return err
+		}
 
-		if !strings.HasSuffix(p.settings.BaseURL, "/") {
-			p.settings.BaseURL = p.settings.BaseURL + "/"
+		for _, other := range sameCommit {
+			fmt.Fprintf(p.out(), "Commit %s is already released as %s: %s\n", rc.Commit, other.GetTagName(), other.GetHTMLURL())
 		}
-		p.settings.baseURL, err = url.Parse(p.settings.BaseURL)
+	}
+
+	if p.settings.Handoff == "read" {
+		descriptor, err := rc.readHandoff(p.settings.HandoffFile, p.settings.HandoffBranch)
+
 		if err != nil {
-			return fmt.Errorf("failed to parse base url: %w", err)
+			return fmt.Errorf("failed to read the release handoff: %w", err)
+		}
+
+		rc.ReleaseID = descriptor.ReleaseID
+	}
+
+	if !p.settings.Yes && isInteractive(p.settings.CI) {
+		// planning imports the prerelease notes, which buildRelease does again
+		preview := *rc
+		plan, err := preview.plan(uploads)
+
+		if err != nil {
+			return fmt.Errorf("failed to plan the release: %w", err)
 		}
 
-		if !strings.HasSuffix(p.settings.UploadURL, "/") {
-			p.settings.UploadURL = p.settings.UploadURL + "/"
+		if err := writeJSON(p.results(), "", plan); err != nil {
+			return err
 		}
-		p.settings.uploadURL, err = url.Parse(p.settings.UploadURL)
+
+		ok, err := confirm(os.Stdin, p.results(), fmt.Sprintf("Apply the changes to the %s release?", rc.Tag))
+
 		if err != nil {
-			return fmt.Errorf("failed to parse upload url: %w", err)
+			return fmt.Errorf("failed to read confirmation: %w", err)
+		}
+
+		if !ok {
+			return fmt.Errorf("release aborted")
 		}
-	} else {
-		p.settings.baseURL, p.settings.uploadURL, err = gitHubURLs(p.settings.GitHubURL)
+	}
+
+	release, err := rc.buildRelease()
+
+	if err != nil {
+		return fmt.Errorf("failed to create the release: %w", err)
+	}
+
+	if p.settings.EnvFile != "" {
+		err := appendEnvFile(p.settings.EnvFile, [][2]string{
+			{"RELEASE_URL", release.GetHTMLURL()},
+			{"RELEASE_ID", strconv.FormatInt(release.GetID(), 10)},
+			{"UPLOAD_URL", release.GetUploadURL()},
+		})
+
 		if err != nil {
-			return fmt.Errorf("failed to get GitHub urls: %w", err)
+			return fmt.Errorf("failed to write env file: %w", err)
 		}
 	}
 
-	if p.settings.Note != "" {
-		if p.settings.Note, err = readStringOrFile(p.settings.Note); err != nil {
-			return fmt.Errorf("error while reading %s: %w", p.settings.Note, err)
+	if rc.PromoteAssets && !rc.Prerelease {
+		if uploads, err = rc.promoteAssets(release, uploads); err != nil {
+			return fmt.Errorf("failed to promote prerelease assets: %w", err)
 		}
 	}
 
-	if p.settings.Title != "" {
-		if p.settings.Title, err = readStringOrFile(p.settings.Title); err != nil {
-			return fmt.Errorf("error while reading %s: %w", p.settings.Note, err)
+	if err := rc.uploadFiles(*release.ID, uploads); err != nil {
+		return fmt.Errorf("failed to upload the files: %w", err)
+	}
+
+	if len(p.settings.streams) > 0 {
+		if err := rc.uploadFiles(release.GetID(), streamFiles(p.settings.streams)); err != nil {
+			return fmt.Errorf("failed to upload the streamed assets: %w", err)
+		}
+	}
+
+	if len(p.settings.combined) > 0 {
+		if err := rc.uploadFiles(release.GetID(), p.settings.combined); err != nil {
+			return fmt.Errorf("failed to upload the combined checksums: %w", err)
+		}
+	}
+
+	if p.settings.ChecksumAggregate == "finalize" {
+		if err := rc.finalizeChecksums(release.GetID(), p.settings.ChecksumAggregated, p.settings.ChecksumSignCommand); err != nil {
+			return fmt.Errorf("failed to finalize checksums: %w", err)
+		}
+	}
+
+	// later edits of the body have to keep the recorded owned assets
+	if rc.OwnAssets {
+		if release, err = rc.reloadRelease(release); err != nil {
+			return err
+		}
+	}
+
+	if p.settings.PrivateDownloads {
+		if release, err = rc.addPrivateDownloads(release); err != nil {
+			return fmt.Errorf("failed to add private download instructions: %w", err)
+		}
+	}
+
+	if p.settings.SameCommit == "link" {
+		if release, err = rc.linkReleases(release, sameCommit); err != nil {
+			return fmt.Errorf("failed to link releases of the same commit: %w", err)
 		}
 	}
 
-	files := p.settings.Files.Value()
-	for _, glob := range files {
-		globed, err := filepath.Glob(glob)
+	if rc.Resume {
+		if release, err = rc.publishRelease(release); err != nil {
+			return fmt.Errorf("failed to publish the release: %w", err)
+		}
+	}
+
+	switch {
+	case p.settings.Handoff == "write" && release.GetDraft():
+		if err := rc.writeHandoff(release, p.settings.HandoffFile, p.settings.HandoffBranch); err != nil {
+			return fmt.Errorf("failed to write the release handoff: %w", err)
+		}
+	case p.settings.Handoff == "read" && !release.GetDraft():
+		if err := rc.removeHandoff(p.settings.HandoffFile, p.settings.HandoffBranch); err != nil {
+			return fmt.Errorf("failed to remove the release handoff: %w", err)
+		}
+	}
+
+	if p.settings.SmokeTest && !release.GetDraft() {
+		assets, err := rc.listAssets(release.GetID())
 
 		if err != nil {
-			return fmt.Errorf("failed to glob %s: %w", glob, err)
+			return err
 		}
 
-		if globed != nil {
-			p.settings.uploads = append(p.settings.uploads, globed...)
+		if err := smokeTest(p.out(), rc.HTTPClient, assets, p.settings.SmokeTestRetries, p.settings.SmokeTestDelay); err != nil {
+			return fmt.Errorf("smoke test failed: %w", err)
 		}
 	}
 
-	if len(files) > 0 && len(p.settings.uploads) < 1 {
-		return fmt.Errorf("failed to find any file to release")
+	if len(p.settings.latest) > 0 && !release.GetDraft() && !release.GetPrerelease() {
+		if err := rc.updateLatest(p.settings.LatestTag, p.settings.latest); err != nil {
+			return fmt.Errorf("failed to update the %s release: %w", p.settings.LatestTag, err)
+		}
 	}
 
-	checksum := p.settings.Checksum.Value()
-	if len(checksum) > 0 {
-		p.settings.uploads, err = writeChecksums(p.settings.uploads, checksum, p.settings.ChecksumFile, p.settings.ChecksumFlatten)
+	if p.settings.AliasTags && !release.GetDraft() && !release.GetPrerelease() {
+		for _, alias := range aliasTags(rc.Tag) {
+			if err := rc.moveAliasTag(alias); err != nil {
+				return err
+			}
+		}
+	}
+
+	if p.settings.DiscussionDigests && !release.GetDraft() {
+		if err := p.commentDigests(rc, release); err != nil {
+			return fmt.Errorf("failed to comment asset digests: %w", err)
+		}
+	}
+
+	measure := p.settings.LatencySLA > 0 || p.settings.LatencyWebhook != "" || p.settings.StatsFile != "" || p.settings.StatsEndpoint != ""
+
+	if measure && !release.GetDraft() {
+		// latency is informational and never fails the release
+		if err := p.recordLatency(rc, release); err != nil {
+			fmt.Fprintf(p.out(), "Warning: failed to measure the release latency: %s\n", err)
+		}
+	}
+
+	if p.settings.PreviewHTML != "" {
+		if err := rc.writePreview(release, p.settings.PreviewHTML); err != nil {
+			return fmt.Errorf("failed to write the release notes preview: %w", err)
+		}
+	}
+
+	if err := p.afterRelease(rc, release); err != nil {
+		return err
+	}
+
+	if p.settings.CardPath != "" {
+		if err := p.writeCard(rc, release); err != nil {
+			return fmt.Errorf("failed to write the drone card: %w", err)
+		}
+	}
+
+	if p.settings.OutputFile != "" {
+		if err := rc.writeRelease(release.GetID(), p.settings.OutputFile); err != nil {
+			return fmt.Errorf("failed to write the release to %s: %w", p.settings.OutputFile, err)
+		}
+	}
+
+	fmt.Fprintf(p.out(), "%s%s\n", releaseURLPrefix, release.GetHTMLURL())
+	return nil
+}
+
+// afterRelease runs the integrations which depend on the final release.
+func (p *Plugin) afterRelease(rc *releaseClient, release *github.RepositoryRelease) error {
+	if p.settings.CommentNotes && !release.GetDraft() {
+		if err := p.commentNotes(rc, release); err != nil {
+			return fmt.Errorf("failed to comment the release notes: %w", err)
+		}
+	}
+
+	if p.settings.CommitComment && !release.GetDraft() {
+		if err := p.commentCommit(rc, release); err != nil {
+			return fmt.Errorf("failed to comment the released commit: %w", err)
+		}
+	}
+
+	if p.settings.TagSync == "to-tag" {
+		if err := rc.syncTagMessage(stripMetadata(release.GetBody())); err != nil {
+			return fmt.Errorf("failed to sync the tag message: %w", err)
+		}
+	}
+
+	if p.settings.ChangelogFile != "" && !release.GetDraft() {
+		notes := stripMetadata(release.GetBody())
+
+		err := rc.commitFile(fileUpdate{
+			Owner:       rc.Owner,
+			Repo:        rc.Repo,
+			Branch:      p.settings.ChangelogBranch,
+			Path:        p.settings.ChangelogFile,
+			Message:     fmt.Sprintf("Update changelog for %s", rc.Tag),
+			PullRequest: p.settings.ChangelogPR,
+			Update: func(content string) (string, error) {
+				return prependChangelog(p.out(), content, rc.Tag, notes)
+			},
+		})
 
 		if err != nil {
-			return fmt.Errorf("failed to write checksums: %w", err)
+			return fmt.Errorf("failed to update the changelog: %w", err)
+		}
+	}
+
+	if p.settings.VersionFileRepo != "" && !release.GetDraft() {
+		parts := strings.Split(p.targetRepo(p.settings.VersionFileRepo), "/")
+
+		err := rc.commitFile(fileUpdate{
+			Owner:       parts[0],
+			Repo:        parts[1],
+			Branch:      p.settings.VersionFileBranch,
+			Path:        p.settings.VersionFilePath,
+			Message:     fmt.Sprintf("Bump %s version to %s", rc.Repo, rc.Tag),
+			PullRequest: p.settings.VersionFilePR,
+			Update: func(content string) (string, error) {
+				return bumpVersionFile(p.settings.VersionFilePath, content, rc.Tag)
+			},
+		})
+
+		if err != nil {
+			return fmt.Errorf("failed to update the version file: %w", err)
+		}
+	}
+
+	if p.settings.PackageRepo != "" && !release.GetDraft() {
+		if err := p.publishPackages(rc, release); err != nil {
+			return fmt.Errorf("failed to publish package metadata: %w", err)
 		}
 	}
 
 	return nil
 }
 
-// Execute provides the implementation of the plugin.
-func (p *Plugin) Execute() error {
-	ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: p.settings.APIKey})
-	tc := oauth2.NewClient(
-		context.WithValue(context.Background(), oauth2.HTTPClient, p.network.Client),
-		ts,
-	)
+// commentNotes posts the notes on the configured issue or the merged pull
+// request the release originates from.
+func (p *Plugin) commentNotes(rc *releaseClient, release *github.RepositoryRelease) error {
+	number := p.settings.CommentIssue
 
-	client := github.NewClient(tc)
+	if number == 0 {
+		var err error
 
-	client.BaseURL = p.settings.baseURL
-	client.UploadURL = p.settings.uploadURL
+		if number, err = rc.mergedPull(); err != nil {
+			return err
+		}
 
-	rc := releaseClient{
-		Client:               client,
-		Context:              p.network.Context,
-		Owner:                p.pipeline.Repo.Owner,
-		Repo:                 p.pipeline.Repo.Name,
-		Tag:                  strings.TrimPrefix(p.pipeline.Commit.Ref, "refs/tags/"),
-		Draft:                p.settings.Draft,
-		Prerelease:           p.settings.Prerelease,
-		FileExists:           p.settings.FileExists,
-		Title:                p.settings.Title,
-		Note:                 p.settings.Note,
-		Overwrite:            p.settings.Overwrite,
-		GenerateReleaseNotes: p.settings.GenerateReleaseNotes,
+		if number == 0 {
+			fmt.Fprintf(p.out(), "No merged pull request found for commit %s, skipping comment\n", rc.Commit)
+			return nil
+		}
 	}
 
-	release, err := rc.buildRelease()
+	text := defaultCommentTemplate
+
+	if p.settings.CommentTemplate != "" {
+		content, err := readStringOrFile(p.settings.CommentTemplate)
+
+		if err != nil {
+			return fmt.Errorf("failed to read comment template: %w", err)
+		}
+
+		text = content
+	}
+
+	data := p.templateData()
+	data.Notes = strings.TrimSpace(stripMetadata(release.GetBody()))
+	data.ReleaseURL = release.GetHTMLURL()
+
+	body, err := renderTemplate("comment", text, data)
 
 	if err != nil {
-		return fmt.Errorf("failed to create the release: %w", err)
+		return fmt.Errorf("failed to render comment template: %w", err)
 	}
 
-	if err := rc.uploadFiles(*release.ID, p.settings.uploads); err != nil {
-		return fmt.Errorf("failed to upload the files: %w", err)
+	return rc.commentNotes(number, body)
+}
+
+// commentCommit renders the commit comment with the release link and the
+// released files.
+func (p *Plugin) commentCommit(rc *releaseClient, release *github.RepositoryRelease) error {
+	text := defaultCommitCommentTemplate
+
+	if p.settings.CommitTemplate != "" {
+		content, err := readStringOrFile(p.settings.CommitTemplate)
+
+		if err != nil {
+			return fmt.Errorf("failed to read commit comment template: %w", err)
+		}
+
+		text = content
+	}
+
+	assets, err := rc.listAssets(release.GetID())
+
+	if err != nil {
+		return err
+	}
+
+	data := p.templateData()
+	data.Notes = strings.TrimSpace(stripMetadata(release.GetBody()))
+	data.ReleaseURL = release.GetHTMLURL()
+
+	if data.Assets, err = templateAssets(p.settings.uploads, assets); err != nil {
+		return err
+	}
+
+	body, err := renderTemplate("commit-comment", text, data)
+
+	if err != nil {
+		return fmt.Errorf("failed to render commit comment template: %w", err)
+	}
+
+	return rc.commentCommit(body)
+}
+
+// publishPackages renders the package templates and commits them to the
+// packaging repo.
+func (p *Plugin) publishPackages(rc *releaseClient, release *github.RepositoryRelease) error {
+	assets, err := rc.listAssets(release.GetID())
+
+	if err != nil {
+		return err
+	}
+
+	data := p.templateData()
+
+	if data.Assets, err = templateAssets(p.settings.uploads, assets); err != nil {
+		return err
+	}
+
+	parts := strings.Split(p.targetRepo(p.settings.PackageRepo), "/")
+
+	for _, entry := range p.settings.PackageTemplates.Value() {
+		pair := strings.SplitN(entry, "=", 2)
+		source, target := pair[0], pair[1]
+
+		text, err := ioutil.ReadFile(source)
+
+		if err != nil {
+			return fmt.Errorf("failed to read template %s: %w", source, err)
+		}
+
+		content, err := renderTemplate(source, string(text), data)
+
+		if err != nil {
+			return fmt.Errorf("failed to render template %s: %w", source, err)
+		}
+
+		err = rc.commitFile(fileUpdate{
+			Owner:       parts[0],
+			Repo:        parts[1],
+			Branch:      p.settings.PackageBranch,
+			Path:        target,
+			Message:     fmt.Sprintf("Update %s to %s", rc.Repo, rc.Tag),
+			PullRequest: p.settings.PackagePR,
+			Update: func(string) (string, error) {
+				return content, nil
+			},
+		})
+
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
+// commentDigests posts the checksums of the uploaded files to the
+// discussion of the release, which is created before the uploads.
+func (p *Plugin) commentDigests(rc *releaseClient, release *github.RepositoryRelease) error {
+	number, err := rc.discussionNumber(release.GetID())
+
+	if err != nil {
+		return err
+	}
+
+	if number == 0 {
+		fmt.Fprintln(p.out(), "Release has no discussion, skipping asset digests")
+		return nil
+	}
+
+	assets, err := templateAssets(append(append([]string(nil), p.settings.uploads...), p.settings.combined...), nil)
+
+	if err != nil {
+		return err
+	}
+
+	return rc.commentDiscussion(number, digestsComment(rc.Tag, assets))
+}
+
+// targetRepo returns the repository to write to, which is the sandbox
+// during a sandboxed dry run.
+func (p *Plugin) targetRepo(repo string) string {
+	if p.settings.DryRun && p.settings.SandboxRepo != "" {
+		return p.settings.SandboxRepo
+	}
+
+	return repo
+}
+
+// useSandbox points the release client at the sandbox repository. The
+// released commit doesn't exist there, so the steps bound to it are turned
+// off and returned, and releases target the default branch of the sandbox.
+func (p *Plugin) useSandbox(rc *releaseClient) []string {
+	parts := strings.Split(p.settings.SandboxRepo, "/")
+	rc.Owner, rc.Repo = parts[0], parts[1]
+	rc.Target = ""
+
+	var skipped []string
+
+	if p.settings.VerifySignature != "" {
+		p.settings.VerifySignature = ""
+		skipped = append(skipped, "the signature verification")
+	}
+
+	if p.settings.RequireChecks {
+		p.settings.RequireChecks = false
+		skipped = append(skipped, "the status check gate")
+	}
+
+	if p.settings.RequiredApprovals > 0 {
+		p.settings.RequiredApprovals = 0
+		skipped = append(skipped, "the approval check")
+	}
+
+	if p.settings.SameCommit != "" {
+		p.settings.SameCommit = ""
+		skipped = append(skipped, "the same commit check")
+	}
+
+	if p.settings.CommitComment {
+		p.settings.CommitComment = false
+		skipped = append(skipped, "the commit comment")
+	}
+
+	// the merged pull request is looked up by the commit
+	if p.settings.CommentNotes && p.settings.CommentIssue == 0 {
+		p.settings.CommentNotes = false
+		skipped = append(skipped, "the pull request comment")
+	}
+
+	return skipped
+}
+
+// plannedAssets describes the files to upload. Assets are uploaded later,
+// so their download urls get predicted.
+func (p *Plugin) plannedAssets() ([]templateAsset, error) {
+	assets, err := templateAssets(append(append([]string(nil), p.settings.uploads...), p.settings.combined...), nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tag := p.templateData().Tag
+
+	for i := range assets {
+		assets[i].URL = fmt.Sprintf("%s/releases/download/%s/%s", p.pipeline.Repo.Link, tag, url.PathEscape(assets[i].Name))
+	}
+
+	return assets, nil
+}
+
+func (p *Plugin) templateData() templateData {
+	tag := strings.TrimPrefix(p.pipeline.Commit.Ref, "refs/tags/")
+
+	return templateData{
+		Owner:         p.pipeline.Repo.Owner,
+		Project:       p.pipeline.Repo.Name,
+		Tag:           tag,
+		Version:       strings.TrimPrefix(tag, "v"),
+		Commit:        p.pipeline.Commit.SHA,
+		BuildNumber:   p.pipeline.Build.Number,
+		BuildLink:     p.pipeline.Build.Link,
+		PreviousTag:   p.settings.previous.GetTagName(),
+		PreviousNotes: strings.TrimSpace(stripMetadata(p.settings.previous.GetBody())),
+	}
+}
+
 func gitHubURLs(gh string) (*url.URL, *url.URL, error) {
 	uri, err := url.Parse(gh)
 	if err != nil {
@@ -184,3 +2195,57 @@ func gitHubURLs(gh string) (*url.URL, *url.URL, error) {
 
 	return baseURL, uploadURL, nil
 }
+
+func parseAPIURL(raw string) (*url.URL, error) {
+	if !strings.HasSuffix(raw, "/") {
+		raw = raw + "/"
+	}
+
+	return url.Parse(raw)
+}
+
+// enterpriseURLs resolves the api and upload urls of base_url following
+// github.NewEnterpriseClient. A bare host gets the api/v3/ path of GitHub
+// Enterprise Server, a given path is kept as it is. The upload url is the
+// api/uploads/ path next to the api/v3/ path.
+func enterpriseURLs(raw string) (*url.URL, *url.URL, error) {
+	base, err := parseAPIURL(raw)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if base.Hostname() == "api.github.com" {
+		return gitHubURLs("https://github.com")
+	}
+
+	if base.Path == "/" {
+		base.Path = "/api/v3/"
+	}
+
+	upload := *base
+	upload.Path = strings.TrimSuffix(base.Path, "v3/")
+
+	if !strings.HasSuffix(upload.Path, "api/") {
+		upload.Path += "api/"
+	}
+
+	upload.Path += "uploads/"
+	return base, &upload, nil
+}
+
+// parseUploadURL parses upload_url, adding the upload path of GitHub
+// Enterprise Server to a bare host.
+func parseUploadURL(raw string) (*url.URL, error) {
+	upload, err := parseAPIURL(raw)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if upload.Path == "/" && upload.Hostname() != "uploads.github.com" {
+		upload.Path = "/api/uploads/"
+	}
+
+	return upload, nil
+}
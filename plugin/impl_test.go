@@ -6,13 +6,39 @@
 package plugin
 
 import (
+	"errors"
+	"os"
+	"path/filepath"
 	"testing"
+
+	"github.com/drone-plugins/drone-plugin-lib/drone"
 )
 
 func TestValidate(t *testing.T) {
 	t.Skip()
 }
 
+func TestValidateLoadError(t *testing.T) {
+	expected := errors.New("failed to load settings")
+	p := Plugin{settings: Settings{LoadError: expected, Completion: "complete"}}
+
+	if err := p.Validate(); err != expected {
+		t.Errorf("Unexpected error (Got: %v, Expected: %v)", err, expected)
+	}
+}
+
+func TestValidateCompletion(t *testing.T) {
+	p := Plugin{settings: Settings{Completion: "complete"}}
+
+	if err := p.Validate(); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if !p.settings.skip {
+		t.Error("Expected the release to be skipped after printing the completion")
+	}
+}
+
 func TestExecute(t *testing.T) {
 	t.Skip()
 }
@@ -40,3 +66,111 @@ func TestGitHubURLs(t *testing.T) {
 		t.Errorf("Unexpected upload API URL (Got: %s, Expected: %s", actualUploadURL.String(), expectedUploadURL)
 	}
 }
+
+func TestEnterpriseURLs(t *testing.T) {
+	tests := []struct {
+		base, upload                 string
+		expectedBase, expectedUpload string
+	}{
+		{"https://api.github.com", "", "https://api.github.com/", "https://uploads.github.com/"},
+		{"https://ghe.example.com", "", "https://ghe.example.com/api/v3/", "https://ghe.example.com/api/uploads/"},
+		{"https://ghe.example.com/api/v3/", "", "https://ghe.example.com/api/v3/", "https://ghe.example.com/api/uploads/"},
+		{"https://proxy.example.com/github/api/v3", "", "https://proxy.example.com/github/api/v3/", "https://proxy.example.com/github/api/uploads/"},
+		{"https://ghe.example.com", "https://ghe.example.com", "https://ghe.example.com/api/v3/", "https://ghe.example.com/api/uploads/"},
+		{"https://ghe.example.com/api/v3", "https://uploads.example.com", "https://ghe.example.com/api/v3/", "https://uploads.example.com/api/uploads/"},
+		{"https://ghe.example.com/api/v3/", "https://proxy.example.com/api/v3/upload/", "https://ghe.example.com/api/v3/", "https://proxy.example.com/api/v3/upload/"},
+		{"https://api.github.com/", "https://uploads.github.com/", "https://api.github.com/", "https://uploads.github.com/"},
+	}
+
+	for _, test := range tests {
+		base, upload, err := enterpriseURLs(test.base)
+
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		if test.upload != "" {
+			if upload, err = parseUploadURL(test.upload); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+		}
+
+		if base.String() != test.expectedBase {
+			t.Errorf("Unexpected base API URL (Got: %s, Expected: %s)", base, test.expectedBase)
+		}
+
+		if upload.String() != test.expectedUpload {
+			t.Errorf("Unexpected upload API URL (Got: %s, Expected: %s)", upload, test.expectedUpload)
+		}
+	}
+}
+
+func TestPlannedAssets(t *testing.T) {
+	dir := t.TempDir()
+	files := []string{filepath.Join(dir, "app"), filepath.Join(dir, "checksums.txt")}
+
+	for _, file := range files {
+		if err := os.WriteFile(file, []byte("content"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	p := Plugin{
+		settings: Settings{uploads: files[:1], combined: files[1:]},
+		pipeline: drone.Pipeline{
+			Repo:   drone.Repo{Link: "https://github.com/octocat/hello"},
+			Commit: drone.Commit{Ref: "refs/tags/v1.0.0"},
+		},
+	}
+
+	assets, err := p.plannedAssets()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(assets) != 2 || assets[1].Name != "checksums.txt" {
+		t.Fatalf("Unexpected assets (Got: %v)", assets)
+	}
+
+	if expected := "https://github.com/octocat/hello/releases/download/v1.0.0/checksums.txt"; assets[1].URL != expected {
+		t.Errorf("Unexpected asset url (Got: %s, Expected: %s)", assets[1].URL, expected)
+	}
+}
+
+func TestUseSandbox(t *testing.T) {
+	p := Plugin{
+		settings: Settings{
+			DryRun:            true,
+			SandboxRepo:       "octocat/sandbox",
+			VerifySignature:   "tag",
+			RequireChecks:     true,
+			RequiredApprovals: 2,
+			SameCommit:        "warn",
+			CommitComment:     true,
+			CommentNotes:      true,
+		},
+	}
+
+	rc := releaseClient{Owner: "octocat", Repo: "hello", Commit: "0123456789abcdef", Target: "0123456789abcdef"}
+	skipped := p.useSandbox(&rc)
+
+	if rc.Owner != "octocat" || rc.Repo != "sandbox" || rc.Target != "" {
+		t.Errorf("Unexpected release client (Got: %s/%s at %q)", rc.Owner, rc.Repo, rc.Target)
+	}
+
+	if len(skipped) != 6 {
+		t.Errorf("Unexpected skipped steps (Got: %v)", skipped)
+	}
+
+	if p.settings.VerifySignature != "" || p.settings.RequireChecks || p.settings.RequiredApprovals != 0 || p.settings.SameCommit != "" || p.settings.CommitComment || p.settings.CommentNotes {
+		t.Errorf("Unexpected commit bound steps left enabled (Got: %+v)", p.settings)
+	}
+
+	p.settings.CommentNotes = true
+	p.settings.CommentIssue = 42
+
+	if skipped := p.useSandbox(&rc); len(skipped) != 0 || !p.settings.CommentNotes {
+		t.Errorf("Unexpected skipped steps for a configured issue (Got: %v)", skipped)
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// inlineAsset is a small file rendered from a template in the settings.
+type inlineAsset struct {
+	Name     string `json:"name"`
+	Template string `json:"template"`
+}
+
+func parseInlineAssets(input string) ([]inlineAsset, error) {
+	var assets []inlineAsset
+
+	if err := json.Unmarshal([]byte(input), &assets); err != nil {
+		return nil, err
+	}
+
+	for i, a := range assets {
+		if a.Name == "" {
+			return nil, fmt.Errorf("inline asset %d has no name", i)
+		}
+	}
+
+	return assets, nil
+}
+
+// writeInlineAssets renders the names and contents of the assets into the
+// directory. Scripts keep their executable bit for local testing.
+func writeInlineAssets(out io.Writer, assets []inlineAsset, data templateData, dir string) ([]string, error) {
+	var files []string
+
+	for _, a := range assets {
+		name, err := renderTemplate(a.Name, a.Name, data)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to render name of inline asset %s: %w", a.Name, err)
+		}
+
+		if name != filepath.Base(name) {
+			return nil, fmt.Errorf("inline asset name %s must not contain a directory", name)
+		}
+
+		content, err := renderTemplate(name, a.Template, data)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to render inline asset %s: %w", name, err)
+		}
+
+		mode := 0644
+		if strings.HasPrefix(content, "#!") {
+			mode = 0755
+		}
+
+		file := filepath.Join(dir, name)
+
+		if err := ioutil.WriteFile(file, []byte(content), os.FileMode(mode)); err != nil {
+			return nil, err
+		}
+
+		fmt.Fprintf(out, "Successfully rendered inline asset %s\n", name)
+		files = append(files, file)
+	}
+
+	return files, nil
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteInlineAssets(t *testing.T) {
+	assets, err := parseInlineAssets(`[{"name": "install-{{.Version}}.sh", "template": "#!/bin/sh\ncurl -fsSL https://example.com/{{.Tag}}/app -o app\n"}]`)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	files, err := writeInlineAssets(ioutil.Discard, assets, templateData{Tag: "v1.2.0", Version: "1.2.0"}, dir)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := filepath.Join(dir, "install-1.2.0.sh"); len(files) != 1 || files[0] != expected {
+		t.Fatalf("Unexpected files (Got: %v, Expected: %s)", files, expected)
+	}
+
+	content, err := ioutil.ReadFile(files[0])
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := "#!/bin/sh\ncurl -fsSL https://example.com/v1.2.0/app -o app\n"; string(content) != expected {
+		t.Errorf("Unexpected content (Got: %q, Expected: %q)", content, expected)
+	}
+
+	info, err := os.Stat(files[0])
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Mode().Perm() != 0755 {
+		t.Errorf("Unexpected mode (Got: %s, Expected: %s)", info.Mode().Perm(), os.FileMode(0755))
+	}
+
+	if _, err := writeInlineAssets(ioutil.Discard, []inlineAsset{{Name: "../escape.sh"}}, templateData{}, dir); err == nil {
+		t.Error("Expected an error for a name with a directory")
+	}
+
+	if _, err := parseInlineAssets(`[{"template": "x"}]`); err == nil {
+		t.Error("Expected an error for a missing name")
+	}
+}
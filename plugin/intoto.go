@@ -0,0 +1,51 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+)
+
+// intotoArgs builds the arguments of the in-toto-verify command.
+func intotoArgs(command, layout string, keys []string, linkDir string) ([]string, error) {
+	args := strings.Fields(command)
+
+	if len(args) == 0 {
+		return nil, errors.New("empty in-toto command")
+	}
+
+	args = append(args, "--layout", layout, "--verification-keys")
+	args = append(args, keys...)
+
+	if linkDir != "" {
+		args = append(args, "--link-dir", linkDir)
+	}
+
+	return args, nil
+}
+
+// verifyInToto verifies the supply chain of the artifacts in the working
+// directory against the signed layout and the link metadata.
+func verifyInToto(out io.Writer, command, layout string, keys []string, linkDir string) error {
+	args, err := intotoArgs(command, layout, keys, linkDir)
+
+	if err != nil {
+		return err
+	}
+
+	output, err := exec.Command(args[0], args[1:]...).CombinedOutput()
+
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	fmt.Fprintf(out, "Successfully verified the artifacts against in-toto layout %s\n", layout)
+	return nil
+}
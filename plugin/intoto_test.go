@@ -0,0 +1,30 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIntotoArgs(t *testing.T) {
+	args, err := intotoArgs("in-toto-verify -v", "root.layout", []string{"alice.pub", "bob.pub"}, "links")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual := strings.Join(args, " ")
+	expected := "in-toto-verify -v --layout root.layout --verification-keys alice.pub bob.pub --link-dir links"
+
+	if actual != expected {
+		t.Errorf("Unexpected args (Got: %s, Expected: %s)", actual, expected)
+	}
+
+	if _, err := intotoArgs(" ", "root.layout", nil, ""); err == nil {
+		t.Errorf("Expected an error for an empty command")
+	}
+}
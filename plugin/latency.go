@@ -0,0 +1,99 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/go-github/v44/github"
+)
+
+// latencyAlert is posted to the webhook when a release misses the SLA.
+type latencyAlert struct {
+	Repo       string  `json:"repo"`
+	Tag        string  `json:"tag"`
+	ReleaseURL string  `json:"release_url"`
+	Latency    float64 `json:"latency_seconds"`
+	SLA        float64 `json:"sla_seconds"`
+}
+
+// tagCreated returns the date of the annotated tag. Lightweight tags have no
+// date of their own, the time of the build triggered by pushing them is used
+// instead, or the commit date without one.
+func (rc *releaseClient) tagCreated(pushed time.Time) (time.Time, error) {
+	ref, tag, err := rc.resolveTag(rc.Tag)
+
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if tag != nil {
+		return tag.GetTagger().GetDate(), nil
+	}
+
+	if !pushed.IsZero() {
+		return pushed, nil
+	}
+
+	commit, _, err := rc.Client.Git.GetCommit(rc.Context, rc.Owner, rc.Repo, ref.GetObject().GetSHA())
+
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get commit %s: %w", ref.GetObject().GetSHA(), err)
+	}
+
+	return commit.GetCommitter().GetDate(), nil
+}
+
+// releaseLatency is the time between creating the tag and publishing.
+func releaseLatency(created time.Time, release *github.RepositoryRelease) time.Duration {
+	published := release.GetPublishedAt().Time
+
+	if published.IsZero() {
+		published = time.Now()
+	}
+
+	return published.Sub(created).Round(time.Second)
+}
+
+// recordLatency adds the tag to release latency to the run statistics and
+// alerts the webhook if the SLA is exceeded.
+func (p *Plugin) recordLatency(rc *releaseClient, release *github.RepositoryRelease) error {
+	var pushed time.Time
+
+	if p.pipeline.Build.Event == "tag" {
+		pushed = p.pipeline.Build.Created
+	}
+
+	created, err := rc.tagCreated(pushed)
+
+	if err != nil {
+		return err
+	}
+
+	latency := releaseLatency(created, release)
+	p.settings.stats.TagLatency = latency
+
+	fmt.Fprintf(p.out(), "Release %s was published %s after the tag was created\n", rc.Tag, latency)
+
+	if p.settings.LatencySLA <= 0 || latency <= p.settings.LatencySLA {
+		return nil
+	}
+
+	fmt.Fprintf(p.out(), "Release latency exceeds the SLA of %s\n", p.settings.LatencySLA)
+
+	if p.settings.LatencyWebhook == "" {
+		return nil
+	}
+
+	return postJSON(p.network.Client, p.settings.LatencyWebhook, latencyAlert{
+		Repo:       rc.Owner + "/" + rc.Repo,
+		Tag:        rc.Tag,
+		ReleaseURL: release.GetHTMLURL(),
+		Latency:    latency.Seconds(),
+		SLA:        p.settings.LatencySLA.Seconds(),
+	})
+}
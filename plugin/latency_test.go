@@ -0,0 +1,52 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v44/github"
+)
+
+func TestReleaseLatency(t *testing.T) {
+	created := time.Date(2022, 8, 1, 12, 0, 0, 0, time.UTC)
+	release := &github.RepositoryRelease{
+		PublishedAt: &github.Timestamp{Time: created.Add(90*time.Minute + 400*time.Millisecond)},
+	}
+
+	if actual, expected := releaseLatency(created, release), 90*time.Minute; actual != expected {
+		t.Errorf("Unexpected latency (Got: %s, Expected: %s)", actual, expected)
+	}
+}
+
+func TestTagCreated(t *testing.T) {
+	rc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/octocat/hello/git/ref/tags/v1.0.0":
+			fmt.Fprint(w, `{"ref": "refs/tags/v1.0.0", "object": {"sha": "abc", "type": "commit"}}`)
+		case "/repos/octocat/hello/git/commits/abc":
+			fmt.Fprint(w, `{"sha": "abc", "committer": {"date": "2022-07-01T12:00:00Z"}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	rc.Tag = "v1.0.0"
+	pushed := time.Date(2022, 8, 1, 12, 0, 0, 0, time.UTC)
+
+	if created, err := rc.tagCreated(pushed); err != nil || !created.Equal(pushed) {
+		t.Errorf("Unexpected creation of a pushed tag (Got: %s, %v, Expected: %s)", created, err, pushed)
+	}
+
+	expected := time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC)
+
+	if created, err := rc.tagCreated(time.Time{}); err != nil || !created.Equal(expected) {
+		t.Errorf("Unexpected creation without a build (Got: %s, %v, Expected: %s)", created, err, expected)
+	}
+}
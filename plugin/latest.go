@@ -0,0 +1,225 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/google/go-github/v44/github"
+)
+
+// latestAliases copies the files matching the patterns into the directory,
+// named without the version. Patterns may set the name as "pattern=name".
+func latestAliases(files, patterns []string, tag, dir string) ([]string, error) {
+	var aliases []string
+	seen := make(map[string]string)
+
+	for _, entry := range patterns {
+		pattern, name := entry, ""
+
+		if i := strings.LastIndex(entry, "="); i >= 0 {
+			pattern, name = entry[:i], entry[i+1:]
+		}
+
+		for _, file := range files {
+			matched, err := matchFile(pattern, file)
+
+			if err != nil {
+				return nil, fmt.Errorf("invalid latest alias pattern %s: %w", pattern, err)
+			}
+
+			if !matched {
+				continue
+			}
+
+			alias := name
+			if alias == "" {
+				alias = versionless(filepath.Base(file), tag)
+			}
+
+			if other, ok := seen[alias]; ok {
+				if other != file {
+					return nil, fmt.Errorf("latest alias %s is used for %s and %s", alias, other, file)
+				}
+
+				continue
+			}
+
+			seen[alias] = file
+			target := filepath.Join(dir, alias)
+
+			if err := writeAlias(file, target); err != nil {
+				return nil, fmt.Errorf("failed to copy %s to %s: %w", file, alias, err)
+			}
+
+			aliases = append(aliases, target)
+		}
+	}
+
+	return aliases, nil
+}
+
+// versionless drops the tag or the version from the name, together with
+// the separator in front of it or, at the start of the name, after it.
+func versionless(name, tag string) string {
+	for _, version := range []string{tag, strings.TrimPrefix(tag, "v")} {
+		if version == "" {
+			continue
+		}
+
+		for _, sep := range []string{"-", "_", "."} {
+			if strings.Contains(name, sep+version) {
+				return strings.Replace(name, sep+version, "", 1)
+			}
+
+			if strings.HasPrefix(name, version+sep) {
+				return strings.TrimPrefix(name, version+sep)
+			}
+		}
+	}
+
+	return name
+}
+
+func writeAlias(file, target string) error {
+	out, err := os.Create(target)
+
+	if err != nil {
+		return err
+	}
+
+	if err := copyFile(out, file); err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
+
+// latestVersion matches the version the rolling release currently names in
+// its body.
+var latestVersion = regexp.MustCompile(`currently (\S+)\.$`)
+
+// updateLatest moves the rolling tag to the commit and replaces the assets
+// of its release with the aliases. Versions older than the one the rolling
+// release currently names are skipped, e.g. backports to a former branch.
+func (rc *releaseClient) updateLatest(tag string, files []string) error {
+	release, resp, err := rc.Client.Repositories.GetReleaseByTag(rc.Context, rc.Owner, rc.Repo, tag)
+	missing := resp != nil && resp.StatusCode == http.StatusNotFound
+
+	if err != nil && !missing {
+		return fmt.Errorf("failed to retrieve release %s: %w", tag, err)
+	}
+
+	if m := latestVersion.FindStringSubmatch(release.GetBody()); m != nil && !missing {
+		current := m[1]
+
+		if stableVersion.MatchString(current) && stableVersion.MatchString(rc.Tag) && compareVersions(rc.Tag, current) < 0 {
+			fmt.Fprintf(rc.Out, "Warning: skipping %s release, %s is older than its current version %s\n", tag, rc.Tag, current)
+			return nil
+		}
+	}
+
+	ref, resp, err := rc.Client.Git.GetRef(rc.Context, rc.Owner, rc.Repo, "tags/"+tag)
+
+	switch {
+	case resp != nil && resp.StatusCode == http.StatusNotFound:
+		ref = &github.Reference{
+			Ref:    github.String("refs/tags/" + tag),
+			Object: &github.GitObject{SHA: github.String(rc.Commit)},
+		}
+
+		if _, _, err := rc.Client.Git.CreateRef(rc.Context, rc.Owner, rc.Repo, ref); err != nil {
+			return fmt.Errorf("failed to create tag %s: %w", tag, err)
+		}
+	case err != nil:
+		return fmt.Errorf("failed to get tag %s: %w", tag, err)
+	case ref.GetObject().GetSHA() != rc.Commit:
+		ref.Object = &github.GitObject{SHA: github.String(rc.Commit)}
+
+		if _, _, err := rc.Client.Git.UpdateRef(rc.Context, rc.Owner, rc.Repo, ref, true); err != nil {
+			return fmt.Errorf("failed to move tag %s: %w", tag, err)
+		}
+	}
+
+	// marked as prerelease, so GitHub keeps the versioned release as the
+	// latest one
+	body := fmt.Sprintf("Rolling release of the latest version, currently %s.", rc.Tag)
+
+	if missing {
+		release, _, err = rc.Client.Repositories.CreateRelease(rc.Context, rc.Owner, rc.Repo, &github.RepositoryRelease{
+			TagName:    github.String(tag),
+			Name:       github.String(tag),
+			Body:       github.String(body),
+			Prerelease: github.Bool(true),
+		})
+
+		if err != nil {
+			return fmt.Errorf("failed to create release %s: %w", tag, err)
+		}
+	} else {
+		release, _, err = rc.Client.Repositories.EditRelease(rc.Context, rc.Owner, rc.Repo, release.GetID(), &github.RepositoryRelease{
+			Body:       github.String(body),
+			Prerelease: github.Bool(true),
+		})
+
+		if err != nil {
+			return fmt.Errorf("failed to update release %s: %w", tag, err)
+		}
+	}
+
+	latest := *rc
+	latest.Tag = tag
+	latest.FileExists = "overwrite"
+	latest.OwnAssets = false
+	latest.ProtectDownloaded = false
+	latest.resumed = false
+
+	if err := latest.uploadFiles(release.GetID(), files); err != nil {
+		return err
+	}
+
+	if err := rc.deleteStaleAliases(release.GetID(), files); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(rc.Out, "Successfully updated %s release to %s\n", tag, rc.Tag)
+	return nil
+}
+
+// deleteStaleAliases deletes the assets of the rolling release that are not
+// among the aliases anymore, e.g. after a platform was dropped.
+func (rc *releaseClient) deleteStaleAliases(id int64, files []string) error {
+	names := make(map[string]bool)
+	for _, file := range files {
+		names[filepath.Base(file)] = true
+	}
+
+	assets, err := rc.listAssets(id)
+
+	if err != nil {
+		return err
+	}
+
+	for _, asset := range assets {
+		if names[asset.GetName()] {
+			continue
+		}
+
+		if _, err := rc.Client.Repositories.DeleteReleaseAsset(rc.Context, rc.Owner, rc.Repo, asset.GetID()); err != nil {
+			return fmt.Errorf("failed to delete stale %s artifact: %w", asset.GetName(), err)
+		}
+
+		fmt.Fprintf(rc.Out, "Successfully deleted stale %s artifact\n", asset.GetName())
+	}
+
+	return nil
+}
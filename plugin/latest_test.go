@@ -0,0 +1,180 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/google/go-github/v44/github"
+)
+
+func TestVersionless(t *testing.T) {
+	tests := map[string]string{
+		"myapp-v1.2.3-linux-amd64":        "myapp-linux-amd64",
+		"myapp_1.2.3_linux_amd64.tar.gz":  "myapp_linux_amd64.tar.gz",
+		"1.2.3-myapp.zip":                 "myapp.zip",
+		"myapp-linux-amd64":               "myapp-linux-amd64",
+		"myapp-1.2.3-windows-amd64.1.exe": "myapp-windows-amd64.1.exe",
+	}
+
+	for name, expected := range tests {
+		if actual := versionless(name, "v1.2.3"); actual != expected {
+			t.Errorf("Unexpected name (Got: %s, Expected: %s)", actual, expected)
+		}
+	}
+}
+
+func TestLatestAliases(t *testing.T) {
+	dir := t.TempDir()
+	files := []string{filepath.Join(dir, "myapp-v1.2.3-linux-amd64"), filepath.Join(dir, "notes.md")}
+
+	for _, file := range files {
+		if err := os.WriteFile(file, []byte("content"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out := t.TempDir()
+	aliases, err := latestAliases(files, []string{"myapp-*", "*.md=NOTES.md"}, "v1.2.3", out)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{filepath.Join(out, "myapp-linux-amd64"), filepath.Join(out, "NOTES.md")}
+
+	if len(aliases) != len(expected) {
+		t.Fatalf("Unexpected aliases (Got: %v, Expected: %v)", aliases, expected)
+	}
+
+	for i := range expected {
+		if aliases[i] != expected[i] {
+			t.Errorf("Unexpected alias (Got: %s, Expected: %s)", aliases[i], expected[i])
+		}
+
+		if content, _ := os.ReadFile(aliases[i]); string(content) != "content" {
+			t.Errorf("Unexpected content of %s (Got: %s, Expected: %s)", aliases[i], content, "content")
+		}
+	}
+}
+
+func TestUpdateLatest(t *testing.T) {
+	var (
+		deleted []string
+		created github.RepositoryRelease
+	)
+
+	rc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/repos/octocat/hello/git/ref/tags/latest":
+			fmt.Fprint(w, `{"ref": "refs/tags/latest", "object": {"sha": "abc"}}`)
+		case r.URL.Path == "/repos/octocat/hello/releases/tags/latest":
+			http.NotFound(w, r)
+		case r.Method == http.MethodPost && r.URL.Path == "/repos/octocat/hello/releases":
+			json.NewDecoder(r.Body).Decode(&created)
+			fmt.Fprint(w, `{"id": 1, "tag_name": "latest"}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/octocat/hello/releases/1/assets":
+			fmt.Fprint(w, `[{"id": 2, "name": "myapp-linux-amd64"}, {"id": 3, "name": "myapp-darwin-amd64"}]`)
+		case r.Method == http.MethodPost && r.URL.Path == "/repos/octocat/hello/releases/1/assets":
+			io.Copy(io.Discard, r.Body)
+			fmt.Fprintf(w, `{"id": 4, "name": %q}`, r.URL.Query().Get("name"))
+		case r.Method == http.MethodDelete:
+			deleted = append(deleted, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	rc.Commit = "abc"
+	rc.Tag = "v1.2.3"
+
+	file := filepath.Join(t.TempDir(), "myapp-linux-amd64")
+
+	if err := os.WriteFile(file, []byte("content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := rc.updateLatest("latest", []string{file}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if !created.GetPrerelease() {
+		t.Errorf("Unexpected release (Got: %v, Expected: prerelease)", created.GetPrerelease())
+	}
+
+	sort.Strings(deleted)
+	expected := []string{"/repos/octocat/hello/releases/assets/2", "/repos/octocat/hello/releases/assets/3"}
+
+	if fmt.Sprint(deleted) != fmt.Sprint(expected) {
+		t.Errorf("Unexpected deletions (Got: %v, Expected: %v)", deleted, expected)
+	}
+}
+
+func TestUpdateLatestVersion(t *testing.T) {
+	tests := []struct {
+		tag     string
+		updated bool
+	}{
+		{"v1.2.9", false},
+		{"v1.3.0", true},
+		{"v1.4.0", true},
+		{"nightly", true},
+	}
+
+	for _, test := range tests {
+		var moved, uploaded bool
+
+		rc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case r.URL.Path == "/repos/octocat/hello/releases/tags/latest":
+				fmt.Fprint(w, `{"id": 1, "tag_name": "latest", "body": "Rolling release of the latest version, currently v1.3.0."}`)
+			case r.Method == http.MethodGet && r.URL.Path == "/repos/octocat/hello/git/ref/tags/latest":
+				fmt.Fprint(w, `{"ref": "refs/tags/latest", "object": {"sha": "def"}}`)
+			case r.Method == http.MethodPatch && r.URL.Path == "/repos/octocat/hello/git/refs/tags/latest":
+				moved = true
+				fmt.Fprint(w, `{"ref": "refs/tags/latest", "object": {"sha": "abc"}}`)
+			case r.Method == http.MethodPatch && r.URL.Path == "/repos/octocat/hello/releases/1":
+				fmt.Fprint(w, `{"id": 1, "tag_name": "latest"}`)
+			case r.Method == http.MethodGet && r.URL.Path == "/repos/octocat/hello/releases/1/assets":
+				fmt.Fprint(w, `[{"id": 2, "name": "myapp-linux-amd64", "download_count": 5}]`)
+			case r.Method == http.MethodPost && r.URL.Path == "/repos/octocat/hello/releases/1/assets":
+				uploaded = true
+				io.Copy(io.Discard, r.Body)
+				fmt.Fprintf(w, `{"id": 3, "name": %q}`, r.URL.Query().Get("name"))
+			case r.Method == http.MethodDelete:
+				w.WriteHeader(http.StatusNoContent)
+			default:
+				http.NotFound(w, r)
+			}
+		})
+
+		rc.Commit = "abc"
+		rc.Tag = test.tag
+		rc.ProtectDownloaded = true
+
+		file := filepath.Join(t.TempDir(), "myapp-linux-amd64")
+
+		if err := os.WriteFile(file, []byte("content"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := rc.updateLatest("latest", []string{file}); err != nil {
+			t.Fatalf("Unexpected error for %s: %s", test.tag, err)
+		}
+
+		if moved != test.updated || uploaded != test.updated {
+			t.Errorf("Unexpected update for %s (Got: %t, %t, Expected: %t)", test.tag, moved, uploaded, test.updated)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/google/go-github/v44/github"
+)
+
+// listFilter restricts the releases returned by the list action.
+type listFilter struct {
+	Draft      *bool
+	Prerelease *bool
+	TagPattern string
+	Since      time.Time
+}
+
+// listedRelease is the JSON representation of a release in the list output.
+type listedRelease struct {
+	ID          int64         `json:"id"`
+	Tag         string        `json:"tag"`
+	Name        string        `json:"name"`
+	Draft       bool          `json:"draft"`
+	Prerelease  bool          `json:"prerelease"`
+	CreatedAt   time.Time     `json:"created_at"`
+	PublishedAt *time.Time    `json:"published_at,omitempty"`
+	URL         string        `json:"url"`
+	Assets      []listedAsset `json:"assets"`
+}
+
+// listedAsset is the JSON representation of a release asset in the list output.
+type listedAsset struct {
+	ID            int64  `json:"id"`
+	Name          string `json:"name"`
+	Size          int    `json:"size"`
+	DownloadCount int    `json:"download_count"`
+	URL           string `json:"url"`
+}
+
+func newListFilter(draft, prerelease, tagPattern, since string) (listFilter, error) {
+	var (
+		filter listFilter
+		err    error
+	)
+
+	if filter.Draft, err = parseOptionalBool(draft); err != nil {
+		return filter, fmt.Errorf("invalid value for list_draft: %w", err)
+	}
+
+	if filter.Prerelease, err = parseOptionalBool(prerelease); err != nil {
+		return filter, fmt.Errorf("invalid value for list_prerelease: %w", err)
+	}
+
+	if tagPattern != "" {
+		if _, err := path.Match(tagPattern, ""); err != nil {
+			return filter, fmt.Errorf("invalid value for list_tag_pattern: %w", err)
+		}
+
+		filter.TagPattern = tagPattern
+	}
+
+	if since != "" {
+		if filter.Since, err = time.Parse(time.RFC3339, since); err != nil {
+			if filter.Since, err = time.Parse("2006-01-02", since); err != nil {
+				return filter, fmt.Errorf("invalid value for list_since: %s", since)
+			}
+		}
+	}
+
+	return filter, nil
+}
+
+func parseOptionalBool(s string) (*bool, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	b, err := strconv.ParseBool(s)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &b, nil
+}
+
+func (f listFilter) match(release *github.RepositoryRelease) bool {
+	if f.Draft != nil && release.GetDraft() != *f.Draft {
+		return false
+	}
+
+	if f.Prerelease != nil && release.GetPrerelease() != *f.Prerelease {
+		return false
+	}
+
+	if f.TagPattern != "" {
+		if ok, _ := path.Match(f.TagPattern, release.GetTagName()); !ok {
+			return false
+		}
+	}
+
+	if !f.Since.IsZero() && release.GetCreatedAt().Time.Before(f.Since) {
+		return false
+	}
+
+	return true
+}
+
+func (rc *releaseClient) listReleases(filter listFilter) ([]listedRelease, error) {
+	result := []listedRelease{}
+	listOpts := &github.ListOptions{PerPage: 100}
+
+	for {
+		releases, resp, err := rc.Client.Repositories.ListReleases(rc.Context, rc.Owner, rc.Repo, listOpts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list releases: %w", err)
+		}
+
+		for _, release := range releases {
+			if filter.match(release) {
+				result = append(result, newListedRelease(release))
+			}
+		}
+
+		// stop iteration if there is no next page
+		if resp.NextPage == 0 {
+			break
+		}
+
+		listOpts.Page = resp.NextPage
+	}
+
+	fmt.Fprintf(os.Stderr, "Found %d matching releases\n", len(result))
+	return result, nil
+}
+
+func newListedRelease(release *github.RepositoryRelease) listedRelease {
+	lr := listedRelease{
+		ID:         release.GetID(),
+		Tag:        release.GetTagName(),
+		Name:       release.GetName(),
+		Draft:      release.GetDraft(),
+		Prerelease: release.GetPrerelease(),
+		CreatedAt:  release.GetCreatedAt().Time,
+		URL:        release.GetHTMLURL(),
+		Assets:     []listedAsset{},
+	}
+
+	if release.PublishedAt != nil {
+		published := release.GetPublishedAt().Time
+		lr.PublishedAt = &published
+	}
+
+	for _, asset := range release.Assets {
+		lr.Assets = append(lr.Assets, listedAsset{
+			ID:            asset.GetID(),
+			Name:          asset.GetName(),
+			Size:          asset.GetSize(),
+			DownloadCount: asset.GetDownloadCount(),
+			URL:           asset.GetBrowserDownloadURL(),
+		})
+	}
+
+	return lr
+}
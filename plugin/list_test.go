@@ -0,0 +1,73 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v44/github"
+)
+
+func TestListFilterMatch(t *testing.T) {
+	filter, err := newListFilter("false", "", "v1.*", "2022-01-01")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		release  *github.RepositoryRelease
+		expected bool
+	}{
+		{
+			release: &github.RepositoryRelease{
+				TagName:   github.String("v1.2.0"),
+				Draft:     github.Bool(false),
+				CreatedAt: &github.Timestamp{Time: time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC)},
+			},
+			expected: true,
+		},
+		{
+			release: &github.RepositoryRelease{
+				TagName:   github.String("v1.2.0"),
+				Draft:     github.Bool(true),
+				CreatedAt: &github.Timestamp{Time: time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC)},
+			},
+			expected: false,
+		},
+		{
+			release: &github.RepositoryRelease{
+				TagName:   github.String("v2.0.0"),
+				CreatedAt: &github.Timestamp{Time: time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC)},
+			},
+			expected: false,
+		},
+		{
+			release: &github.RepositoryRelease{
+				TagName:   github.String("v1.0.0"),
+				CreatedAt: &github.Timestamp{Time: time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)},
+			},
+			expected: false,
+		},
+	}
+
+	for _, test := range tests {
+		if actual := filter.match(test.release); actual != test.expected {
+			t.Errorf("Unexpected match for %s (Got: %t, Expected: %t)", test.release.GetTagName(), actual, test.expected)
+		}
+	}
+}
+
+func TestNewListFilterInvalid(t *testing.T) {
+	if _, err := newListFilter("maybe", "", "", ""); err == nil {
+		t.Error("Expected an error for an invalid draft value")
+	}
+
+	if _, err := newListFilter("", "", "", "yesterday"); err == nil {
+		t.Error("Expected an error for an invalid since value")
+	}
+}
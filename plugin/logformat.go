@@ -0,0 +1,199 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const releaseURLPrefix = "Release URL: "
+
+var (
+	logFormatValues = map[string]bool{
+		"":     true,
+		"text": true,
+		"json": true,
+	}
+
+	assetPrefix = regexp.MustCompile(`^\[([^\]]+)\] `)
+)
+
+// outputLog filters the lines the plugin prints. Secrets are scrubbed from
+// every line, in quiet mode only warnings and the release url pass, in json
+// mode every line becomes a json log entry, so the plain output of the
+// plugin becomes machine-parseable. Action results and the confirmation
+// prompt bypass the filter by results.
+type outputLog struct {
+	stdout   io.Writer
+	progress io.Writer
+	entries  *logrus.Logger
+	logger   *logrus.Logger
+	quiet    bool
+	scrubber *scrubber
+	fields   logrus.Fields
+	started  time.Time
+	mu       sync.Mutex
+	partial  []byte
+}
+
+// newOutputLog returns the output log writing results to stdout and the
+// progress to the progress writer, e.g. stderr to keep it apart from action
+// results. The logs of its logger, like the debug logs, are written to
+// stderr and in json mode formatted as json as well.
+func newOutputLog(stdout, progress, stderr io.Writer, format string, quiet bool, s *scrubber, fields logrus.Fields) *outputLog {
+	l := &outputLog{
+		stdout:   stdout,
+		progress: progress,
+		logger:   logrus.New(),
+		quiet:    quiet,
+		scrubber: s,
+		fields:   fields,
+		started:  time.Now(),
+	}
+
+	l.logger.SetOutput(stderr)
+	l.logger.SetLevel(logrus.GetLevel())
+	l.logger.AddHook(&scrubHook{scrubber: s})
+
+	if format == "json" {
+		l.logger.SetFormatter(&logrus.JSONFormatter{})
+		l.logger.AddHook(&fieldsHook{fields: fields})
+		l.entries = newJSONLogger(progress)
+	}
+
+	return l
+}
+
+func newJSONLogger(out io.Writer) *logrus.Logger {
+	logger := logrus.New()
+	logger.SetOutput(out)
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	logger.SetLevel(logrus.GetLevel())
+
+	return logger
+}
+
+// Write forwards the complete lines which pass the filter. A partial line
+// is kept until it is completed or the log is stopped.
+func (l *outputLog) Write(b []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.partial = append(l.partial, b...)
+
+	for {
+		i := bytes.IndexByte(l.partial, '\n')
+
+		if i < 0 {
+			break
+		}
+
+		l.forwardLine(strings.TrimRight(string(l.partial[:i]), "\r"))
+		l.partial = l.partial[i+1:]
+	}
+
+	return len(b), nil
+}
+
+func (l *outputLog) forwardLine(line string) {
+	line = l.scrubber.scrub(line)
+	trimmed := strings.TrimSpace(line)
+
+	if l.quiet && !strings.HasPrefix(trimmed, "Warning: ") && !strings.HasPrefix(trimmed, releaseURLPrefix) {
+		return
+	}
+
+	if l.entries == nil {
+		fmt.Fprintln(l.progress, line)
+		return
+	}
+
+	if trimmed == "" {
+		return
+	}
+
+	line = trimmed
+
+	entry := l.entries.WithFields(l.fields)
+
+	if m := assetPrefix.FindStringSubmatch(line); m != nil {
+		entry = entry.WithField("asset", m[1])
+		line = line[len(m[0]):]
+	}
+
+	if strings.HasPrefix(line, "Warning: ") {
+		entry.Warn(strings.TrimPrefix(line, "Warning: "))
+		return
+	}
+
+	entry.Info(line)
+}
+
+// results returns the writer of action results, which bypasses the filter,
+// so they are neither split into log entries nor dropped in quiet mode.
+// Secrets are scrubbed nevertheless.
+func (l *outputLog) results() io.Writer {
+	return resultWriter{l}
+}
+
+type resultWriter struct {
+	l *outputLog
+}
+
+func (w resultWriter) Write(b []byte) (int, error) {
+	w.l.mu.Lock()
+	defer w.l.mu.Unlock()
+
+	if _, err := io.WriteString(w.l.stdout, w.l.scrubber.scrub(string(b))); err != nil {
+		return 0, err
+	}
+
+	return len(b), nil
+}
+
+// fieldsHook adds the fields of the output log to the entries of its
+// logger, like the debug logs and the final error.
+type fieldsHook struct {
+	fields logrus.Fields
+}
+
+func (h *fieldsHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *fieldsHook) Fire(entry *logrus.Entry) error {
+	for key, value := range h.fields {
+		if _, ok := entry.Data[key]; !ok {
+			entry.Data[key] = value
+		}
+	}
+
+	return nil
+}
+
+// stop forwards a partial last line and, in json mode, logs the duration
+// of the run.
+func (l *outputLog) stop() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.partial) > 0 {
+		l.forwardLine(string(l.partial))
+		l.partial = nil
+	}
+
+	if l.entries != nil {
+		l.entries.WithFields(l.fields).WithField("duration", time.Since(l.started).Seconds()).Info("Finished")
+	}
+}
@@ -0,0 +1,189 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestJSONLog(t *testing.T) {
+	var out, stderr bytes.Buffer
+	l := newOutputLog(&out, &out, &stderr, "json", false, nil, logrus.Fields{"tag": "v1.0.0"})
+
+	fmt.Fprintln(l, "Found release 1 for tag v1.0.0")
+	fmt.Fprintf(l, "[app.zip] Successfully uploaded dist/app.zip artifact\n")
+	fmt.Fprint(l, "Warning: size ")
+	fmt.Fprintln(l, "budget exceeded")
+	l.stop()
+
+	var entries []map[string]interface{}
+	scanner := bufio.NewScanner(&out)
+
+	for scanner.Scan() {
+		var entry map[string]interface{}
+
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("Unexpected non-json line %s", scanner.Text())
+		}
+
+		entries = append(entries, entry)
+	}
+
+	if len(entries) != 4 {
+		t.Fatalf("Unexpected number of entries (Got: %d, Expected: %d)", len(entries), 4)
+	}
+
+	if entries[0]["tag"] != "v1.0.0" || entries[0]["level"] != "info" {
+		t.Errorf("Unexpected entry (Got: %v)", entries[0])
+	}
+
+	if entries[1]["asset"] != "app.zip" || entries[1]["msg"] != "Successfully uploaded dist/app.zip artifact" {
+		t.Errorf("Unexpected asset entry (Got: %v)", entries[1])
+	}
+
+	if entries[2]["level"] != "warning" || entries[2]["msg"] != "size budget exceeded" {
+		t.Errorf("Unexpected warning entry (Got: %v)", entries[2])
+	}
+
+	if _, ok := entries[3]["duration"]; !ok {
+		t.Errorf("Unexpected final entry (Got: %v)", entries[3])
+	}
+}
+
+func TestRawOutput(t *testing.T) {
+	var out, stderr bytes.Buffer
+	l := newOutputLog(&out, &out, &stderr, "json", false, nil, nil)
+
+	fmt.Fprintln(l, "Found release 1 for tag v1.0.0")
+
+	if err := writeJSON(l.results(), "", map[string]int{"id": 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	// lines are forwarded without a length limit
+	fmt.Fprintln(l, strings.Repeat("x", 2*1024*1024))
+	fmt.Fprintln(l, "Warning: size budget exceeded")
+	l.stop()
+
+	lines := strings.Split(out.String(), "\n")
+
+	if len(lines) != 8 || !strings.Contains(lines[0], "Found release") || strings.Join(lines[1:4], "\n") != "{\n  \"id\": 1\n}" {
+		t.Fatalf("Unexpected output (Got: %.200q)", out.String())
+	}
+
+	if !strings.Contains(lines[5], `"level":"warning"`) {
+		t.Errorf("Unexpected warning entry (Got: %s)", lines[5])
+	}
+}
+
+func TestProgressOutput(t *testing.T) {
+	var out, progress, stderr bytes.Buffer
+	l := newOutputLog(&out, &progress, &stderr, "text", false, nil, nil)
+
+	fmt.Fprintln(l, "Would create v1.0.0 release")
+
+	if err := writeJSON(l.results(), "", []int{}); err != nil {
+		t.Fatal(err)
+	}
+
+	l.stop()
+
+	if out.String() != "[]\n" {
+		t.Errorf("Unexpected result (Got: %q, Expected: %q)", out.String(), "[]\n")
+	}
+
+	if progress.String() != "Would create v1.0.0 release\n" {
+		t.Errorf("Unexpected progress (Got: %q)", progress.String())
+	}
+}
+
+func TestQuietLog(t *testing.T) {
+	var out, stderr bytes.Buffer
+	l := newOutputLog(&out, &out, &stderr, "text", true, nil, nil)
+
+	fmt.Fprintln(l, "[app.zip] Successfully uploaded dist/app.zip artifact")
+	fmt.Fprintln(l, "Warning: size budget exceeded")
+	fmt.Fprintln(l, releaseURLPrefix+"https://github.com/octocat/hello/releases/tag/v1.0.0")
+
+	// action results and prompts pass the filter
+	if err := writeJSON(l.results(), "", []int{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := confirm(strings.NewReader("y\n"), l.results(), "Proceed?"); err != nil {
+		t.Fatal(err)
+	}
+
+	l.stop()
+
+	expected := "Warning: size budget exceeded\nRelease URL: https://github.com/octocat/hello/releases/tag/v1.0.0\n[]\nProceed? [y/N] "
+	if out.String() != expected {
+		t.Errorf("Unexpected output (Got: %q, Expected: %q)", out.String(), expected)
+	}
+}
+
+func TestOutputLogger(t *testing.T) {
+	level := logrus.GetLevel()
+	logrus.SetLevel(logrus.DebugLevel)
+
+	defer logrus.SetLevel(level)
+
+	var out, stderr bytes.Buffer
+	s := newScrubber("s3cr3t")
+
+	for i := 0; i < 2; i++ {
+		stderr.Reset()
+
+		l := newOutputLog(&out, &out, &stderr, "json", false, s, logrus.Fields{"tag": "v1.0.0"})
+		l.logger.Debugf("GET https://example.com?token=s3cr3t")
+
+		var entry map[string]interface{}
+
+		if err := json.Unmarshal(stderr.Bytes(), &entry); err != nil {
+			t.Fatalf("Unexpected non-json log %s", stderr.String())
+		}
+
+		if entry["tag"] != "v1.0.0" || entry["msg"] != "GET https://example.com?token=[secret]" {
+			t.Errorf("Unexpected log entry (Got: %v)", entry)
+		}
+	}
+
+	// the standard logger is left as it is
+	if _, ok := logrus.StandardLogger().Formatter.(*logrus.JSONFormatter); ok || len(logrus.StandardLogger().Hooks) > 0 {
+		t.Error("Unexpected change of the standard logger")
+	}
+}
+
+func TestJSONError(t *testing.T) {
+	var out, stderr bytes.Buffer
+
+	p := Plugin{}
+	p.settings.output = newOutputLog(&out, &out, &stderr, "json", false, nil, logrus.Fields{"tag": "v1.0.0"})
+
+	err := p.stopOutputLog(errors.New("failed to upload"))
+
+	if err == nil || err.Error() != "" {
+		t.Fatalf("Unexpected error (Got: %v, Expected: exit error without message)", err)
+	}
+
+	var entry map[string]interface{}
+
+	if err := json.Unmarshal(stderr.Bytes(), &entry); err != nil {
+		t.Fatalf("Unexpected non-json log %s", stderr.String())
+	}
+
+	if entry["level"] != "error" || entry["msg"] != "failed to upload" || entry["tag"] != "v1.0.0" {
+		t.Errorf("Unexpected error entry (Got: %v)", entry)
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// assetsManifest is the ordered asset listing consumed by download pages.
+type assetsManifest struct {
+	Tag    string        `json:"tag"`
+	Groups []assetsGroup `json:"groups"`
+}
+
+// assetsGroup is a named group of assets in display order.
+type assetsGroup struct {
+	Name   string          `json:"name"`
+	Assets []manifestAsset `json:"assets"`
+}
+
+// manifestAsset describes a single asset within the manifest.
+type manifestAsset struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+	URL    string `json:"url"`
+}
+
+// assetsOrder is a group with the patterns of its assets in display order.
+type assetsOrder struct {
+	Group    string
+	Patterns []string
+}
+
+// parseAssetsOrder parses group=pattern entries, the order of the first
+// occurrence of a group is its display order.
+func parseAssetsOrder(entries []string) ([]assetsOrder, error) {
+	var order []assetsOrder
+
+	for _, entry := range entries {
+		pair := strings.SplitN(entry, "=", 2)
+
+		if len(pair) != 2 || pair[0] == "" || pair[1] == "" {
+			return nil, fmt.Errorf("invalid assets order %s, expected group=pattern", entry)
+		}
+
+		if _, err := path.Match(pair[1], ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %s: %w", pair[1], err)
+		}
+
+		found := false
+		for i := range order {
+			if order[i].Group == pair[0] {
+				order[i].Patterns = append(order[i].Patterns, pair[1])
+				found = true
+			}
+		}
+
+		if !found {
+			order = append(order, assetsOrder{Group: pair[0], Patterns: []string{pair[1]}})
+		}
+	}
+
+	return order, nil
+}
+
+// buildAssetsManifest sorts the assets into the groups by the first matching
+// pattern, keeping the pattern order. Unmatched assets are listed as Other.
+func buildAssetsManifest(tag string, assets []templateAsset, order []assetsOrder) assetsManifest {
+	manifest := assetsManifest{
+		Tag:    tag,
+		Groups: []assetsGroup{},
+	}
+
+	used := make(map[string]bool)
+
+	for _, o := range order {
+		group := assetsGroup{Name: o.Group, Assets: []manifestAsset{}}
+
+		for _, pattern := range o.Patterns {
+			for _, asset := range assets {
+				if ok, _ := path.Match(pattern, asset.Name); ok && !used[asset.Name] {
+					used[asset.Name] = true
+					group.Assets = append(group.Assets, manifestAsset(asset))
+				}
+			}
+		}
+
+		manifest.Groups = append(manifest.Groups, group)
+	}
+
+	other := assetsGroup{Name: "Other", Assets: []manifestAsset{}}
+
+	for _, asset := range assets {
+		if !used[asset.Name] {
+			other.Assets = append(other.Assets, manifestAsset(asset))
+		}
+	}
+
+	if len(other.Assets) > 0 {
+		manifest.Groups = append(manifest.Groups, other)
+	}
+
+	return manifest
+}
@@ -0,0 +1,52 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuildAssetsManifest(t *testing.T) {
+	order, err := parseAssetsOrder([]string{
+		"macOS=*.dmg",
+		"Linux=*.AppImage",
+		"Linux=*.deb",
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assets := []templateAsset{
+		{Name: "app.deb"},
+		{Name: "app.AppImage"},
+		{Name: "checksums.txt"},
+		{Name: "app.dmg"},
+	}
+
+	manifest := buildAssetsManifest("v1.0.0", assets, order)
+
+	var actual [][]string
+	for _, group := range manifest.Groups {
+		names := []string{group.Name}
+		for _, asset := range group.Assets {
+			names = append(names, asset.Name)
+		}
+
+		actual = append(actual, names)
+	}
+
+	expected := [][]string{
+		{"macOS", "app.dmg"},
+		{"Linux", "app.AppImage", "app.deb"},
+		{"Other", "checksums.txt"},
+	}
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Unexpected groups (Got: %v, Expected: %v)", actual, expected)
+	}
+}
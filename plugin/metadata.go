@@ -0,0 +1,87 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	metadataPrefix = "<!-- drone-github-release"
+	metadataSuffix = "-->"
+)
+
+var (
+	metadataBlock = regexp.MustCompile(`(?s)\n*<!-- drone-github-release\n(.*?)\n-->\s*$`)
+)
+
+func parseMetadataValues(entries []string) (map[string]string, error) {
+	values := make(map[string]string)
+
+	for _, entry := range entries {
+		parts := strings.SplitN(entry, "=", 2)
+
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid metadata value %s, expected key=value", entry)
+		}
+
+		values[parts[0]] = parts[1]
+	}
+
+	return values, nil
+}
+
+// readMetadata extracts the metadata block from a release body, returning
+// nil if there is none.
+func readMetadata(body string) map[string]string {
+	m := metadataBlock.FindStringSubmatch(body)
+
+	if m == nil {
+		return nil
+	}
+
+	var values map[string]string
+
+	if err := json.Unmarshal([]byte(m[1]), &values); err != nil {
+		return nil
+	}
+
+	return values
+}
+
+// stripMetadata removes the metadata block from a release body.
+func stripMetadata(body string) string {
+	return metadataBlock.ReplaceAllString(body, "")
+}
+
+// writeMetadata replaces the metadata block of a release body.
+func writeMetadata(body string, values map[string]string) string {
+	b, _ := json.Marshal(values)
+	body = strings.TrimRight(stripMetadata(body), "\n")
+
+	if body != "" {
+		body += "\n\n"
+	}
+
+	return body + metadataPrefix + "\n" + string(b) + "\n" + metadataSuffix
+}
+
+func mergeMetadata(existing, values map[string]string) map[string]string {
+	result := make(map[string]string, len(existing)+len(values))
+
+	for k, v := range existing {
+		result[k] = v
+	}
+
+	for k, v := range values {
+		result[k] = v
+	}
+
+	return result
+}
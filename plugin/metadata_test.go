@@ -0,0 +1,33 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"testing"
+)
+
+func TestMetadataRoundTrip(t *testing.T) {
+	body := writeMetadata("Some notes", map[string]string{"build": "42", "channel": "stable"})
+	expected := "Some notes\n\n<!-- drone-github-release\n{\"build\":\"42\",\"channel\":\"stable\"}\n-->"
+
+	if body != expected {
+		t.Errorf("Unexpected body (Got: %q, Expected: %q)", body, expected)
+	}
+
+	values := readMetadata(body)
+	if values["build"] != "42" || values["channel"] != "stable" {
+		t.Errorf("Unexpected metadata %v", values)
+	}
+
+	body = writeMetadata(body, mergeMetadata(values, map[string]string{"build": "43"}))
+	if readMetadata(body)["build"] != "43" {
+		t.Errorf("Expected metadata to be replaced, got %q", body)
+	}
+
+	if stripMetadata(body) != "Some notes" {
+		t.Errorf("Unexpected stripped body %q", stripMetadata(body))
+	}
+}
@@ -0,0 +1,169 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/scrypt"
+)
+
+// minisignKey is a decrypted minisign secret key.
+type minisignKey struct {
+	id  []byte
+	key ed25519.PrivateKey
+}
+
+// parseMinisignKey decodes a minisign secret key file, decrypting it with
+// the password if it is encrypted.
+func parseMinisignKey(content, password string) (*minisignKey, error) {
+	var encoded string
+
+	for _, line := range strings.Split(content, "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "untrusted comment:") {
+			encoded = line
+			break
+		}
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode minisign key: %w", err)
+	}
+
+	// sig alg, kdf alg, checksum alg, salt, opslimit, memlimit, key id,
+	// secret key and checksum
+	if len(raw) != 2+2+2+32+8+8+8+64+32 {
+		return nil, errors.New("invalid minisign key length")
+	}
+
+	if string(raw[0:2]) != "Ed" || string(raw[4:6]) != "B2" {
+		return nil, errors.New("unsupported minisign key algorithm")
+	}
+
+	salt := raw[6:38]
+	opsLimit := binary.LittleEndian.Uint64(raw[38:46])
+	memLimit := binary.LittleEndian.Uint64(raw[46:54])
+	keynum := append([]byte(nil), raw[54:]...)
+
+	switch string(raw[2:4]) {
+	case "\x00\x00":
+	case "Sc":
+		stream, err := minisignStream(password, salt, opsLimit, memLimit, len(keynum))
+
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range keynum {
+			keynum[i] ^= stream[i]
+		}
+	default:
+		return nil, errors.New("unsupported minisign key derivation")
+	}
+
+	id, key, sum := keynum[0:8], keynum[8:72], keynum[72:104]
+	expected := blake2b.Sum256(append(append(append([]byte(nil), raw[0:2]...), id...), key...))
+
+	if !bytes.Equal(sum, expected[:]) {
+		return nil, errors.New("wrong password for minisign key")
+	}
+
+	return &minisignKey{id: id, key: ed25519.PrivateKey(key)}, nil
+}
+
+// minisignStream derives the key stream like libsodium, which turns the
+// ops and memory limits into the scrypt parameters.
+func minisignStream(password string, salt []byte, opsLimit, memLimit uint64, length int) ([]byte, error) {
+	const r = 8
+
+	if opsLimit < 32768 {
+		opsLimit = 32768
+	}
+
+	var (
+		n    uint
+		p    uint64 = 1
+		maxN uint64
+	)
+
+	if opsLimit < memLimit/32 {
+		maxN = opsLimit / (r * 4)
+	} else {
+		maxN = memLimit / (r * 128)
+	}
+
+	for n = 1; n < 63; n++ {
+		if uint64(1)<<n > maxN/2 {
+			break
+		}
+	}
+
+	if opsLimit >= memLimit/32 {
+		maxRP := (opsLimit / 4) / (uint64(1) << n)
+
+		if maxRP > 0x3fffffff {
+			maxRP = 0x3fffffff
+		}
+
+		p = maxRP / r
+	}
+
+	return scrypt.Key([]byte(password), salt, 1<<n, r, int(p), length)
+}
+
+// sign creates the prehashed minisign signature of the file content.
+func (k *minisignKey) sign(name string, content []byte, now time.Time) string {
+	hash := blake2b.Sum512(content)
+	signature := ed25519.Sign(k.key, hash[:])
+
+	trusted := fmt.Sprintf("timestamp:%d\tfile:%s\thashed", now.Unix(), name)
+	global := ed25519.Sign(k.key, append(append([]byte(nil), signature...), trusted...))
+
+	blob := append(append([]byte("ED"), k.id...), signature...)
+
+	return "untrusted comment: signature from minisign secret key\n" +
+		base64.StdEncoding.EncodeToString(blob) + "\n" +
+		"trusted comment: " + trusted + "\n" +
+		base64.StdEncoding.EncodeToString(global) + "\n"
+}
+
+// signFiles writes a <name>.minisig signature for every file into the
+// directory.
+func (k *minisignKey) signFiles(out io.Writer, files []string, dir string) ([]string, error) {
+	var signatures []string
+
+	for _, file := range files {
+		content, err := ioutil.ReadFile(file)
+
+		if err != nil {
+			return nil, err
+		}
+
+		signature := filepath.Join(dir, filepath.Base(file)+".minisig")
+
+		if err := ioutil.WriteFile(signature, []byte(k.sign(filepath.Base(file), content, time.Now())), 0644); err != nil {
+			return nil, err
+		}
+
+		fmt.Fprintf(out, "Successfully signed %s with minisign\n", file)
+		signatures = append(signatures, signature)
+	}
+
+	return signatures, nil
+}
@@ -0,0 +1,90 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/binary"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// testMinisignKey builds a secret key file like minisign -G, encrypted
+// with small limits if a password is set.
+func testMinisignKey(t *testing.T, password string) (string, ed25519.PublicKey) {
+	public, private, err := ed25519.GenerateKey(nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id := []byte("keyid-01")
+	sum := blake2b.Sum256(append(append([]byte("Ed"), id...), private...))
+	keynum := append(append(append([]byte(nil), id...), private...), sum[:]...)
+
+	kdf := "\x00\x00"
+	salt := make([]byte, 32)
+	limits := make([]byte, 16)
+
+	if password != "" {
+		kdf = "Sc"
+		binary.LittleEndian.PutUint64(limits[0:8], 32768)
+		binary.LittleEndian.PutUint64(limits[8:16], 1<<20)
+
+		stream, err := minisignStream(password, salt, 32768, 1<<20, len(keynum))
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i := range keynum {
+			keynum[i] ^= stream[i]
+		}
+	}
+
+	raw := append(append(append([]byte("Ed"+kdf+"B2"), salt...), limits...), keynum...)
+	return "untrusted comment: minisign encrypted secret key\n" + base64.StdEncoding.EncodeToString(raw) + "\n", public
+}
+
+func TestMinisignSign(t *testing.T) {
+	for _, password := range []string{"", "secret"} {
+		content, public := testMinisignKey(t, password)
+		key, err := parseMinisignKey(content, password)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		lines := strings.Split(key.sign("checksums.txt", []byte("data"), time.Unix(1660000000, 0)), "\n")
+
+		if expected := "trusted comment: timestamp:1660000000\tfile:checksums.txt\thashed"; lines[2] != expected {
+			t.Errorf("Unexpected trusted comment (Got: %s, Expected: %s)", lines[2], expected)
+		}
+
+		blob, _ := base64.StdEncoding.DecodeString(lines[1])
+		hash := blake2b.Sum512([]byte("data"))
+
+		if string(blob[:2]) != "ED" || string(blob[2:10]) != "keyid-01" || !ed25519.Verify(public, hash[:], blob[10:]) {
+			t.Errorf("Unexpected signature %s", lines[1])
+		}
+
+		global, _ := base64.StdEncoding.DecodeString(lines[3])
+
+		if !ed25519.Verify(public, append(blob[10:], strings.TrimPrefix(lines[2], "trusted comment: ")...), global) {
+			t.Errorf("Unexpected global signature %s", lines[3])
+		}
+	}
+
+	content, _ := testMinisignKey(t, "secret")
+
+	if _, err := parseMinisignKey(content, "wrong"); err == nil {
+		t.Error("Expected an error for a wrong password")
+	}
+}
@@ -0,0 +1,372 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v44/github"
+)
+
+var (
+	pullRequestRef = regexp.MustCompile(`(?:/pull/|#)(\d+)\b`)
+	commitRef      = regexp.MustCompile(`\b[0-9a-f]{7,40}\b`)
+
+	semanticVersion = regexp.MustCompile(`^v?(\d+)\.(\d+)\.(\d+)(?:-([0-9A-Za-z.-]+))?(?:\+[0-9A-Za-z.-]+)?$`)
+)
+
+// prereleaseNotes collects the notes of all prereleases published since the
+// last stable release.
+func (rc *releaseClient) prereleaseNotes() (string, error) {
+	prereleases, err := rc.prereleaseChain()
+
+	if err != nil {
+		return "", err
+	}
+
+	var bodies []string
+	for _, release := range prereleases {
+		fmt.Fprintf(rc.Out, "Importing notes of prerelease %s\n", release.GetTagName())
+		bodies = append(bodies, release.GetBody())
+	}
+
+	return mergeNotes(bodies), nil
+}
+
+// mergeNotes combines the list items of the given notes, dropping entries
+// which reference an already included pull request or commit.
+func mergeNotes(bodies []string) string {
+	var result []string
+	seen := make(map[string]bool)
+
+	for _, body := range bodies {
+		for _, line := range strings.Split(body, "\n") {
+			line = strings.TrimRight(line, "\r ")
+			trimmed := strings.TrimSpace(line)
+
+			if !strings.HasPrefix(trimmed, "- ") && !strings.HasPrefix(trimmed, "* ") {
+				continue
+			}
+
+			key := trimmed[2:]
+			if m := pullRequestRef.FindStringSubmatch(key); m != nil {
+				key = "pr:" + m[1]
+			} else if m := commitRef.FindString(key); m != "" {
+				key = "commit:" + m[:7]
+			}
+
+			if seen[key] {
+				continue
+			}
+
+			seen[key] = true
+			result = append(result, line)
+		}
+	}
+
+	return strings.Join(result, "\n")
+}
+
+// imageArtifact describes a container image built by a previous step.
+type imageArtifact struct {
+	Name   string   `json:"name"`
+	Tags   []string `json:"tags"`
+	Digest string   `json:"digest"`
+}
+
+// readImageManifest reads a json list of images, or a metadata file written
+// by docker buildx.
+func readImageManifest(file string) ([]imageArtifact, error) {
+	b, err := ioutil.ReadFile(file)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var images []imageArtifact
+
+	if err := json.Unmarshal(b, &images); err == nil {
+		return images, nil
+	}
+
+	var buildx struct {
+		Name   string `json:"image.name"`
+		Digest string `json:"containerimage.digest"`
+	}
+
+	if err := json.Unmarshal(b, &buildx); err != nil {
+		return nil, fmt.Errorf("failed to parse image manifest %s: %w", file, err)
+	}
+
+	for _, name := range strings.Split(buildx.Name, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			images = append(images, imageArtifact{Name: name, Digest: buildx.Digest})
+		}
+	}
+
+	return images, nil
+}
+
+// artifactsSection lists the released files and container images.
+func artifactsSection(files []string, images []imageArtifact) (string, error) {
+	if len(files) == 0 && len(images) == 0 {
+		return "", nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString("## Artifacts\n")
+
+	if len(files) > 0 {
+		sb.WriteString("\n### Binaries\n\n| File | SHA256 |\n| --- | --- |\n")
+
+		for _, file := range files {
+			handle, err := os.Open(file)
+
+			if err != nil {
+				return "", err
+			}
+
+			hash, err := checksum(handle, "sha256")
+			handle.Close()
+
+			if err != nil {
+				return "", err
+			}
+
+			fmt.Fprintf(&sb, "| %s | `%s` |\n", filepath.Base(file), hash)
+		}
+	}
+
+	if len(images) > 0 {
+		sb.WriteString("\n### Images\n\n| Image | Digest |\n| --- | --- |\n")
+
+		for _, image := range images {
+			names := []string{image.Name}
+
+			if len(image.Tags) > 0 {
+				names = nil
+				for _, tag := range image.Tags {
+					names = append(names, image.Name+":"+tag)
+				}
+			}
+
+			for _, name := range names {
+				fmt.Fprintf(&sb, "| %s | `%s` |\n", name, image.Digest)
+			}
+		}
+	}
+
+	return strings.TrimSuffix(sb.String(), "\n"), nil
+}
+
+const privateDownloadsHeading = "## Downloads from a private repository"
+
+// privateDownloadsSection renders download instructions using the API asset
+// URLs, as the browser download URLs of private repositories require a
+// logged in session.
+func privateDownloadsSection(assets []*github.ReleaseAsset) string {
+	if len(assets) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString(privateDownloadsHeading + "\n\n")
+	sb.WriteString("This repository is private, assets have to be downloaded through the API with a token granting read access.\n\n")
+	sb.WriteString("| File | API URL |\n| --- | --- |\n")
+
+	for _, asset := range assets {
+		fmt.Fprintf(&sb, "| %s | %s |\n", asset.GetName(), asset.GetURL())
+	}
+
+	fmt.Fprintf(&sb, "\n```sh\ncurl -fL -H \"Authorization: token $GITHUB_TOKEN\" -H \"Accept: application/octet-stream\" -o %s %s\n```", assets[0].GetName(), assets[0].GetURL())
+
+	return sb.String()
+}
+
+// replaceSection replaces the section starting with the heading up to the
+// next heading of the same level, or appends the section if it is missing.
+func replaceSection(body, heading, section string) string {
+	meta := readMetadata(body)
+	body = stripMetadata(body)
+
+	if start := strings.Index(body, heading); start >= 0 {
+		rest := body[start+len(heading):]
+		end := len(body)
+
+		if next := strings.Index(rest, "\n"+strings.SplitN(heading, " ", 2)[0]+" "); next >= 0 {
+			end = start + len(heading) + next
+		}
+
+		body = strings.TrimRight(body[:start], "\n") + "\n\n" + strings.TrimLeft(body[end:], "\n")
+	}
+
+	body = strings.TrimSpace(strings.TrimSpace(body) + "\n\n" + section)
+
+	if meta != nil {
+		body = writeMetadata(body, meta)
+	}
+
+	return body
+}
+
+// addPrivateDownloads adds the download instructions to the notes if the
+// repository is private.
+func (rc *releaseClient) addPrivateDownloads(release *github.RepositoryRelease) (*github.RepositoryRelease, error) {
+	repo, _, err := rc.Client.Repositories.Get(rc.Context, rc.Owner, rc.Repo)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve repository: %w", err)
+	}
+
+	if !repo.GetPrivate() {
+		return release, nil
+	}
+
+	assets, err := rc.listAssets(release.GetID())
+
+	if err != nil {
+		return nil, err
+	}
+
+	section := privateDownloadsSection(assets)
+
+	if section == "" {
+		return release, nil
+	}
+
+	body := replaceSection(release.GetBody(), privateDownloadsHeading, section)
+
+	release, _, err = rc.Client.Repositories.EditRelease(rc.Context, rc.Owner, rc.Repo, release.GetID(), &github.RepositoryRelease{Body: &body})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to update release notes: %w", err)
+	}
+
+	fmt.Fprintf(rc.Out, "Successfully added private download instructions for %d assets\n", len(assets))
+	return release, nil
+}
+
+// previousRelease returns the published release preceding this one, which is
+// the highest lower version for semantic version tags and the latest release
+// otherwise, ignoring prereleases unless this is a prerelease as well.
+func (rc *releaseClient) previousRelease() (*github.RepositoryRelease, error) {
+	listOpts := &github.ListOptions{PerPage: 100}
+	semantic := semanticVersion.MatchString(rc.Tag)
+
+	var previous *github.RepositoryRelease
+
+	for {
+		releases, resp, err := rc.Client.Repositories.ListReleases(rc.Context, rc.Owner, rc.Repo, listOpts)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to list releases: %w", err)
+		}
+
+		for _, release := range releases {
+			if release.GetDraft() || release.GetTagName() == rc.Tag || (release.GetPrerelease() && !rc.Prerelease) {
+				continue
+			}
+
+			// releases are listed newest first
+			if !semantic {
+				previous = release
+				break
+			}
+
+			tag := release.GetTagName()
+
+			if !semanticVersion.MatchString(tag) || compareSemver(tag, rc.Tag) >= 0 {
+				continue
+			}
+
+			if previous == nil || compareSemver(tag, previous.GetTagName()) > 0 {
+				previous = release
+			}
+		}
+
+		// stop iteration if there is no next page
+		if previous != nil && !semantic || resp.NextPage == 0 {
+			break
+		}
+
+		listOpts.Page = resp.NextPage
+	}
+
+	if previous == nil {
+		fmt.Fprintln(rc.Out, "No previous release found")
+		return nil, nil
+	}
+
+	fmt.Fprintf(rc.Out, "Using release %s as previous release\n", previous.GetTagName())
+	return previous, nil
+}
+
+// compareSemver compares two semantic version tags by their precedence,
+// returning a negative number if a is older than b, zero if they are equal
+// and a positive number otherwise.
+func compareSemver(a, b string) int {
+	ma, mb := semanticVersion.FindStringSubmatch(a), semanticVersion.FindStringSubmatch(b)
+
+	for i := 1; i <= 3; i++ {
+		x, _ := strconv.Atoi(ma[i])
+		y, _ := strconv.Atoi(mb[i])
+
+		if x != y {
+			return x - y
+		}
+	}
+
+	// a version without prerelease is newer than its prereleases
+	switch {
+	case ma[4] == mb[4]:
+		return 0
+	case ma[4] == "":
+		return 1
+	case mb[4] == "":
+		return -1
+	}
+
+	pa, pb := strings.Split(ma[4], "."), strings.Split(mb[4], ".")
+
+	for i := 0; i < len(pa) && i < len(pb); i++ {
+		if pa[i] == pb[i] {
+			continue
+		}
+
+		x, errx := strconv.Atoi(pa[i])
+		y, erry := strconv.Atoi(pb[i])
+
+		// numeric identifiers are older than alphanumeric ones
+		switch {
+		case errx == nil && erry == nil:
+			return x - y
+		case errx == nil:
+			return -1
+		case erry == nil:
+			return 1
+		}
+
+		return strings.Compare(pa[i], pb[i])
+	}
+
+	return len(pa) - len(pb)
+}
+
+// redactNotes replaces every match of the redaction patterns.
+func redactNotes(notes string, redactions []*regexp.Regexp) string {
+	for _, re := range redactions {
+		notes = re.ReplaceAllString(notes, "[redacted]")
+	}
+
+	return notes
+}
@@ -0,0 +1,134 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"testing"
+)
+
+func TestMergeNotes(t *testing.T) {
+	bodies := []string{
+		"## What's Changed\n- Add feature by @octocat in #12\n- Fix bug abc1234\n",
+		"## What's Changed\n- Add feature by @octocat in https://github.com/octocat/foo/pull/12\n- Fix bug abc1234def\n- Update docs\n",
+	}
+
+	actual := mergeNotes(bodies)
+	expected := "- Add feature by @octocat in #12\n- Fix bug abc1234\n- Update docs"
+
+	if actual != expected {
+		t.Errorf("Unexpected merged notes (Got: %q, Expected: %q)", actual, expected)
+	}
+}
+
+func TestArtifactsSection(t *testing.T) {
+	actual, err := artifactsSection(nil, []imageArtifact{{Name: "octocat/foo", Tags: []string{"1.0.0", "latest"}, Digest: "sha256:abc"}})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "## Artifacts\n\n### Images\n\n| Image | Digest |\n| --- | --- |\n| octocat/foo:1.0.0 | `sha256:abc` |\n| octocat/foo:latest | `sha256:abc` |"
+	if actual != expected {
+		t.Errorf("Unexpected section (Got: %q, Expected: %q)", actual, expected)
+	}
+}
+
+func TestReplaceSection(t *testing.T) {
+	body := writeMetadata("Notes\n\n## Old\n\nold section\n\n## Other\n\nother", map[string]string{"tag": "v1"})
+	actual := replaceSection(body, "## Old", "## Old\n\nnew section")
+	expected := writeMetadata("Notes\n\n## Other\n\nother\n\n## Old\n\nnew section", map[string]string{"tag": "v1"})
+
+	if actual != expected {
+		t.Errorf("Unexpected body (Got: %q, Expected: %q)", actual, expected)
+	}
+}
+
+func TestRedactNotes(t *testing.T) {
+	redactions := []*regexp.Regexp{
+		regexp.MustCompile(`[a-z0-9.-]+\.corp\.example\.com`),
+		regexp.MustCompile(`\bJIRA-\d+\b`),
+		regexp.MustCompile(`[a-z.]+@example\.com`),
+	}
+
+	notes := "- Fix timeout to build.corp.example.com (JIRA-123) by jane.doe@example.com in #12"
+	expected := "- Fix timeout to [redacted] ([redacted]) by [redacted] in #12"
+
+	if actual := redactNotes(notes, redactions); actual != expected {
+		t.Errorf("Unexpected notes (Got: %s, Expected: %s)", actual, expected)
+	}
+}
+
+func TestCompareSemver(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"v1.2.3", "v1.2.3", 0},
+		{"v1.2.3", "v1.10.0", -1},
+		{"v2.0.0", "v1.9.9", 1},
+		{"v1.0.0-rc.1", "v1.0.0", -1},
+		{"v1.0.0-rc.2", "v1.0.0-rc.10", -1},
+		{"v1.0.0-alpha", "v1.0.0-alpha.1", -1},
+		{"v1.0.0-1", "v1.0.0-alpha", -1},
+		{"1.0.0+build.1", "v1.0.0", 0},
+	}
+
+	for _, test := range tests {
+		actual := compareSemver(test.a, test.b)
+
+		if (actual < 0) != (test.expected < 0) || (actual > 0) != (test.expected > 0) {
+			t.Errorf("Unexpected comparison of %s and %s (Got: %d, Expected: %d)", test.a, test.b, actual, test.expected)
+		}
+	}
+}
+
+func TestPreviousRelease(t *testing.T) {
+	rc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/octocat/hello/releases":
+			fmt.Fprint(w, `[
+				{"tag_name": "v2.0.0"},
+				{"tag_name": "v1.5.0-rc.1", "prerelease": true},
+				{"tag_name": "v1.4.1", "draft": true},
+				{"tag_name": "v1.4.0"},
+				{"tag_name": "nightly"},
+				{"tag_name": "v1.3.0"}
+			]`)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	tests := []struct {
+		tag        string
+		prerelease bool
+		expected   string
+	}{
+		{"v1.4.1", false, "v1.4.0"},
+		{"v1.5.0", false, "v1.4.0"},
+		{"v1.5.0", true, "v1.5.0-rc.1"},
+		{"v2.1.0", false, "v2.0.0"},
+		{"v1.0.0", false, ""},
+		{"snapshot", false, "v2.0.0"},
+	}
+
+	for _, test := range tests {
+		rc.Tag, rc.Prerelease = test.tag, test.prerelease
+
+		previous, err := rc.previousRelease()
+
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		if actual := previous.GetTagName(); actual != test.expected {
+			t.Errorf("Unexpected previous release of %s (Got: %s, Expected: %s)", test.tag, actual, test.expected)
+		}
+	}
+}
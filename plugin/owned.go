@@ -0,0 +1,100 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v44/github"
+)
+
+// ownedAssetsKey is the metadata key listing the assets uploaded by the
+// plugin.
+const ownedAssetsKey = "assets"
+
+// parseOwnedAssets reads the names of the assets uploaded by the plugin
+// from the release metadata.
+func parseOwnedAssets(metadata map[string]string) map[string]bool {
+	owned := make(map[string]bool)
+
+	for _, name := range strings.Split(metadata[ownedAssetsKey], ",") {
+		if name != "" {
+			owned[name] = true
+		}
+	}
+
+	return owned
+}
+
+// joinOwnedAssets serializes the asset names for the release metadata.
+func joinOwnedAssets(owned map[string]bool) string {
+	names := make([]string, 0, len(owned))
+
+	for name := range owned {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+// ownedAssets fetches the release to read the assets uploaded by previous
+// runs.
+func (rc *releaseClient) ownedAssets(id int64) (*github.RepositoryRelease, map[string]bool, error) {
+	release, _, err := rc.Client.Repositories.GetRelease(rc.Context, rc.Owner, rc.Repo, id)
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get release %d: %w", id, err)
+	}
+
+	return release, parseOwnedAssets(readMetadata(release.GetBody())), nil
+}
+
+// checkOwnedAssets fails before anything is uploaded if an asset attached
+// by hand would be replaced, as the checksums of the local files would not
+// match the kept asset.
+func (rc *releaseClient) checkOwnedAssets(planned []plannedAsset, owned map[string]bool) error {
+	for _, pa := range planned {
+		if pa.Action != "replace" || owned[pa.Name] {
+			continue
+		}
+
+		if !rc.Force {
+			return fmt.Errorf("refusing to replace %s artifact not uploaded by this plugin, use force to replace it anyway", pa.Name)
+		}
+
+		fmt.Fprintf(rc.Out, "Warning: forcing replacement of %s artifact not uploaded by this plugin\n", pa.Name)
+	}
+
+	return nil
+}
+
+// recordOwnedAssets adds the uploaded assets to the release metadata.
+func (rc *releaseClient) recordOwnedAssets(release *github.RepositoryRelease, owned map[string]bool, uploaded []string) error {
+	before := joinOwnedAssets(owned)
+
+	for _, name := range uploaded {
+		owned[name] = true
+	}
+
+	if joinOwnedAssets(owned) == before {
+		return nil
+	}
+
+	metadata := mergeMetadata(readMetadata(release.GetBody()), map[string]string{
+		ownedAssetsKey: joinOwnedAssets(owned),
+	})
+
+	body := writeMetadata(release.GetBody(), metadata)
+
+	if _, _, err := rc.Client.Repositories.EditRelease(rc.Context, rc.Owner, rc.Repo, release.GetID(), &github.RepositoryRelease{Body: &body}); err != nil {
+		return fmt.Errorf("failed to record uploaded assets: %w", err)
+	}
+
+	return nil
+}
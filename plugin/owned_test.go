@@ -0,0 +1,102 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v44/github"
+)
+
+func TestOwnedAssets(t *testing.T) {
+	owned := parseOwnedAssets(readMetadata(writeMetadata("notes", map[string]string{
+		"build":        "12",
+		ownedAssetsKey: "app.tar.gz,checksums.txt",
+	})))
+
+	if !owned["app.tar.gz"] || !owned["checksums.txt"] || owned["installer.msi"] {
+		t.Errorf("Unexpected owned assets (Got: %v)", owned)
+	}
+
+	owned["app.zip"] = true
+
+	if actual, expected := joinOwnedAssets(owned), "app.tar.gz,app.zip,checksums.txt"; actual != expected {
+		t.Errorf("Unexpected owned assets (Got: %s, Expected: %s)", actual, expected)
+	}
+
+	if actual := parseOwnedAssets(nil); len(actual) != 0 {
+		t.Errorf("Unexpected owned assets (Got: %v, Expected: none)", actual)
+	}
+}
+
+func TestUploadOwnedAssets(t *testing.T) {
+	body := writeMetadata("notes", map[string]string{ownedAssetsKey: "app"})
+	var requests []string
+
+	rc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/octocat/hello/releases/1":
+			fmt.Fprintf(w, `{"id": 1, "body": %q}`, body)
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/octocat/hello/releases/1/assets":
+			fmt.Fprint(w, `[{"id": 2, "name": "app"}, {"id": 3, "name": "installer"}]`)
+		case r.Method == http.MethodPatch:
+			release := github.RepositoryRelease{}
+			json.NewDecoder(r.Body).Decode(&release)
+			body = release.GetBody()
+			fmt.Fprint(w, `{}`)
+		case r.Method == http.MethodPost && r.URL.Query().Get("name") == "broken":
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			requests = append(requests, r.Method+" "+r.URL.Path)
+			fmt.Fprint(w, `{}`)
+		}
+	})
+
+	rc.OwnAssets = true
+	rc.FileExists = "overwrite"
+	dir := t.TempDir()
+
+	for _, name := range []string{"app", "installer", "lib", "broken"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the asset attached by hand fails the upload before anything changed
+	err := rc.uploadFiles(1, []string{filepath.Join(dir, "app"), filepath.Join(dir, "installer")})
+
+	if err == nil || !strings.Contains(err.Error(), "installer") || len(requests) != 0 {
+		t.Errorf("Unexpected result for an asset attached by hand (Got: %v, %v)", err, requests)
+	}
+
+	// uploads are recorded one by one, so a failure keeps the ones before
+	err = rc.uploadFiles(1, []string{filepath.Join(dir, "app"), filepath.Join(dir, "lib"), filepath.Join(dir, "broken")})
+
+	if err == nil {
+		t.Errorf("Expected an error for the failed upload")
+	}
+
+	if owned := joinOwnedAssets(parseOwnedAssets(readMetadata(body))); owned != "app,lib" {
+		t.Errorf("Unexpected owned assets (Got: %s, Expected: app,lib)", owned)
+	}
+
+	// the release returned on creation doesn't know the recorded assets
+	release, err := rc.reloadRelease(&github.RepositoryRelease{ID: github.Int64(1), Body: github.String("notes")})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if owned := joinOwnedAssets(parseOwnedAssets(readMetadata(release.GetBody()))); owned != "app,lib" {
+		t.Errorf("Unexpected owned assets of the reloaded release (Got: %s, Expected: app,lib)", owned)
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/drone-plugins/drone-github-release/pkg/release"
+	"github.com/google/go-github/v44/github"
+)
+
+// resultSchemaVersion is the version of the schemas of the plan and result
+// files in the schema directory. It changes when fields are removed or
+// change their meaning, not when fields are added.
+const resultSchemaVersion = 1
+
+// releasePlan describes the changes a release run would apply.
+type releasePlan struct {
+	SchemaVersion int                       `json:"schema_version"`
+	Tag           string                    `json:"tag"`
+	Action        string                    `json:"action"`
+	ReleaseID     int64                     `json:"release_id,omitempty"`
+	Payload       *github.RepositoryRelease `json:"payload"`
+	Assets        []plannedAsset            `json:"assets"`
+}
+
+// plannedAsset describes what happens to a single file.
+type plannedAsset = release.PlannedAsset
+
+// plan computes the changes of a release run without applying them.
+func (rc *releaseClient) plan(files []string) (*releasePlan, error) {
+	if err := rc.prepareNote(); err != nil {
+		return nil, err
+	}
+
+	release, err := rc.getRelease()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve a release: %w", err)
+	}
+
+	p := &releasePlan{
+		SchemaVersion: resultSchemaVersion,
+		Tag:           rc.Tag,
+		Assets:        []plannedAsset{},
+	}
+
+	var assets []*github.ReleaseAsset
+
+	if release == nil {
+		p.Action = "create"
+		p.Payload = rc.createPayload()
+	} else {
+		if err := rc.checkOwnership(release); err != nil {
+			return nil, err
+		}
+
+		p.Action = "update"
+		p.ReleaseID = release.GetID()
+		p.Payload = rc.editPayload(*release)
+
+		if assets, err = rc.listAssets(release.GetID()); err != nil {
+			return nil, err
+		}
+	}
+
+	planned, err := planAssets(files, assets, rc.FileExists)
+
+	if err != nil {
+		return nil, err
+	}
+
+	p.Assets = append(p.Assets, planned...)
+
+	// updates print a diff to the existing release instead
+	if release == nil {
+		for _, pa := range p.Assets {
+			fmt.Fprintf(rc.Out, "Would %s %s artifact\n", pa.Action, pa.Name)
+		}
+	} else {
+		fmt.Fprint(rc.Out, releaseDiff(release, p.Payload, p.Assets))
+	}
+
+	fmt.Fprintf(rc.Out, "Would %s %s release\n", p.Action, rc.Tag)
+	return p, nil
+}
+
+// releaseDiff describes the changes of the payload and the assets to the
+// existing release.
+func releaseDiff(release, payload *github.RepositoryRelease, assets []plannedAsset) string {
+	var b strings.Builder
+
+	if payload.Name != nil && payload.GetName() != release.GetName() {
+		fmt.Fprintf(&b, "  title: %q -> %q\n", release.GetName(), payload.GetName())
+	}
+
+	if payload.Draft != nil && payload.GetDraft() != release.GetDraft() {
+		fmt.Fprintf(&b, "  draft: %t -> %t\n", release.GetDraft(), payload.GetDraft())
+	}
+
+	if payload.Prerelease != nil && payload.GetPrerelease() != release.GetPrerelease() {
+		fmt.Fprintf(&b, "  prerelease: %t -> %t\n", release.GetPrerelease(), payload.GetPrerelease())
+	}
+
+	if payload.Body != nil && payload.GetBody() != release.GetBody() {
+		b.WriteString("  notes:\n")
+
+		for _, line := range lineDiff(release.GetBody(), payload.GetBody()) {
+			fmt.Fprintf(&b, "    %s\n", line)
+		}
+	}
+
+	if len(assets) > 0 {
+		b.WriteString("  assets:\n")
+
+		for _, pa := range assets {
+			mark := map[string]string{"upload": "+", "replace": "~", "skip": "="}[pa.Action]
+			fmt.Fprintf(&b, "    %s %s (%s)\n", mark, pa.Name, pa.Action)
+		}
+	}
+
+	if b.Len() == 0 {
+		return fmt.Sprintf("No changes to %s release\n", release.GetTagName())
+	}
+
+	return fmt.Sprintf("Changes to %s release:\n%s", release.GetTagName(), b.String())
+}
+
+// lineDiff lists the removed and added lines between the texts, based on
+// their longest common subsequence.
+func lineDiff(old, new string) []string {
+	a := strings.Split(strings.TrimRight(old, "\n"), "\n")
+	b := strings.Split(strings.TrimRight(new, "\n"), "\n")
+
+	// lcs[i][j] is the length of the common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var result []string
+	i, j := 0, 0
+
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lcs[i][j+1] > lcs[i+1][j]):
+			result = append(result, "+ "+b[j])
+			j++
+		default:
+			result = append(result, "- "+a[i])
+			i++
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,83 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"testing"
+
+	"github.com/google/go-github/v44/github"
+)
+
+func TestPlanAssets(t *testing.T) {
+	files := []string{"dist/app", "dist/app.exe"}
+	assets := []*github.ReleaseAsset{{ID: github.Int64(7), Name: github.String("app")}}
+
+	planned, err := planAssets(files, assets, "overwrite")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if planned[0].Action != "replace" || planned[0].AssetID != 7 {
+		t.Errorf("Unexpected plan for app (Got: %s %d, Expected: replace 7)", planned[0].Action, planned[0].AssetID)
+	}
+
+	if planned[1].Action != "upload" {
+		t.Errorf("Unexpected plan for app.exe (Got: %s, Expected: upload)", planned[1].Action)
+	}
+
+	planned, err = planAssets(files, assets, "skip")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if planned[0].Action != "skip" {
+		t.Errorf("Unexpected plan for app (Got: %s, Expected: skip)", planned[0].Action)
+	}
+
+	if _, err := planAssets(files, assets, "fail"); err == nil {
+		t.Error("Expected an error for an existing asset")
+	}
+}
+
+func TestReleaseDiff(t *testing.T) {
+	release := &github.RepositoryRelease{
+		TagName: github.String("v1.0.0"),
+		Name:    github.String("v1.0.0"),
+		Body:    github.String("## Changes\n\n- Fix\n- Old entry"),
+		Draft:   github.Bool(true),
+	}
+
+	payload := &github.RepositoryRelease{
+		Name:  github.String("Version 1.0.0"),
+		Body:  github.String("## Changes\n\n- Fix\n- New entry"),
+		Draft: github.Bool(false),
+	}
+
+	assets := []plannedAsset{
+		{Name: "app", Action: "replace"},
+		{Name: "app.exe", Action: "upload"},
+	}
+
+	expected := "Changes to v1.0.0 release:\n" +
+		"  title: \"v1.0.0\" -> \"Version 1.0.0\"\n" +
+		"  draft: true -> false\n" +
+		"  notes:\n" +
+		"    - - Old entry\n" +
+		"    + - New entry\n" +
+		"  assets:\n" +
+		"    ~ app (replace)\n" +
+		"    + app.exe (upload)\n"
+
+	if actual := releaseDiff(release, payload, assets); actual != expected {
+		t.Errorf("Unexpected diff (Got: %q, Expected: %q)", actual, expected)
+	}
+
+	if actual := releaseDiff(release, &github.RepositoryRelease{}, nil); actual != "No changes to v1.0.0 release\n" {
+		t.Errorf("Unexpected diff without changes (Got: %q)", actual)
+	}
+}
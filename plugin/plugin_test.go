@@ -6,7 +6,16 @@
 package plugin
 
 import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/blake2b"
 )
 
 func TestReadStringOrFileSelf(t *testing.T) {
@@ -34,3 +43,407 @@ func TestReadStringOrFileLongString(t *testing.T) {
 		t.Error("Expected readStringOrFile to return input for a long string")
 	}
 }
+
+func TestDedupFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := []string{filepath.Join(dir, "app"), filepath.Join(dir, "app-latest"), filepath.Join(dir, "other")}
+
+	for i, content := range []string{"same", "same", "other"} {
+		if err := os.WriteFile(files[i], []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	unique, aliases, err := dedupFiles(files)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(unique) != 2 || unique[0] != files[0] || unique[1] != files[2] {
+		t.Errorf("Unexpected unique files %v", unique)
+	}
+
+	expected := "### Aliases\n\n- `app-latest` is identical to `app`"
+	if actual := aliasNotes(unique, aliases); actual != expected {
+		t.Errorf("Unexpected alias notes (Got: %q, Expected: %q)", actual, expected)
+	}
+}
+
+func TestRenderName(t *testing.T) {
+	actual := renderName("{{project}}_{{version}}_checksums.txt", map[string]string{"project": "drone", "version": "1.2.3"})
+	expected := "drone_1.2.3_checksums.txt"
+
+	if actual != expected {
+		t.Errorf("Unexpected name (Got: %s, Expected: %s)", actual, expected)
+	}
+}
+
+func TestMergeChecksums(t *testing.T) {
+	actual, err := mergeChecksums([]string{"bbb  app-linux\naaa  app-darwin\n", "bbb  app-linux\nccc  app.exe\n"})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "aaa  app-darwin\nbbb  app-linux\nccc  app.exe\n"
+	if actual != expected {
+		t.Errorf("Unexpected checksums (Got: %q, Expected: %q)", actual, expected)
+	}
+
+	if _, err := mergeChecksums([]string{"aaa  app\n", "bbb  app\n"}); err == nil {
+		t.Error("Expected an error for conflicting checksums")
+	}
+}
+
+func TestVerifyChecksums(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "app")
+
+	if err := os.WriteFile(file, []byte("hello\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	manifest := filepath.Join(dir, "checksums.txt")
+	content := "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03 *dist/app\nSHA256 (dist/other) = 2CF24DBA5FB0A30E26E83B2AC5B9E29E1B161E5C1FA7425E73043362938B9824\n"
+
+	if err := os.WriteFile(manifest, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	hashes, err := readChecksumManifest(manifest, "")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := verifyChecksums([]string{file}, hashes, nil); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+
+	expected := manifestEntry{method: "sha256", digest: "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"}
+	if hashes["dist/other"] != expected {
+		t.Errorf("Unexpected bsd checksum (Got: %v, Expected: %v)", hashes["dist/other"], expected)
+	}
+
+	hashes["dist/app"] = manifestEntry{digest: strings.Repeat("0", 64)}
+
+	if err := verifyChecksums([]string{file}, hashes, nil); err == nil {
+		t.Error("Expected an error for a mismatching checksum")
+	}
+
+	if err := verifyChecksums([]string{manifest}, hashes, nil); err == nil {
+		t.Error("Expected an error for an unlisted file")
+	}
+
+	hashes["dist/app"] = manifestEntry{digest: "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d"}
+
+	if err := verifyChecksums([]string{file}, hashes, fipsChecksumValues); err == nil || !strings.Contains(err.Error(), "sha1") {
+		t.Errorf("Unexpected error for a sha1 checksum in fips mode (Got: %v)", err)
+	}
+}
+
+func TestVerifyChecksumsPaths(t *testing.T) {
+	dir := t.TempDir()
+	content := ""
+
+	for _, name := range []string{"linux", "darwin"} {
+		if err := os.MkdirAll(filepath.Join(dir, "dist", name), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(filepath.Join(dir, "dist", name, "app"), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		content += fmt.Sprintf("%x  ./%s/app\n", blake2b.Sum512([]byte(name)), name)
+	}
+
+	linux := filepath.Join(dir, "dist", "linux", "app")
+	darwin := filepath.Join(dir, "dist", "darwin", "app")
+
+	manifest := filepath.Join(dir, "checksums.txt")
+
+	if err := os.WriteFile(manifest, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	hashes, err := readChecksumManifest(manifest, "blake2b")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := verifyChecksums([]string{linux, darwin}, hashes, nil); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+
+	hashes, err = readChecksumManifest(manifest, "")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := verifyChecksums([]string{linux}, hashes, nil); err == nil || !strings.Contains(err.Error(), "sha512") {
+		t.Errorf("Unexpected error for a blake2b digest read as sha512 (Got: %v)", err)
+	}
+
+	hashes = map[string]manifestEntry{
+		"linux/app":  {digest: strings.Repeat("0", 64)},
+		"darwin/app": {digest: strings.Repeat("0", 64)},
+	}
+
+	if err := verifyChecksums([]string{filepath.Join(dir, "app")}, hashes, nil); err == nil || !strings.Contains(err.Error(), "several entries") {
+		t.Errorf("Unexpected error for an ambiguous base name (Got: %v)", err)
+	}
+}
+
+func TestFIPSDisallowed(t *testing.T) {
+	settings := Settings{MinisignKey: "key", Encrypt: "age"}
+	var disallowed []string
+
+	for _, d := range fipsDisallowed {
+		if d.set(&settings) {
+			disallowed = append(disallowed, d.name)
+		}
+	}
+
+	if expected := []string{"minisign_key", "encrypt"}; !reflect.DeepEqual(disallowed, expected) {
+		t.Errorf("Unexpected settings (Got: %v, Expected: %v)", disallowed, expected)
+	}
+}
+
+func TestChecksum(t *testing.T) {
+	tests := map[string]string{
+		"sha256":  "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824",
+		"blake2b": "e4cfa39a3d37be31c59609e807970799caa68a19bfaa15135f165085e01d41a65ba1e1b146aeb6bd0092b49eac214c103ccfa3a365954bbbe52f74a2b3620c94",
+		"crc32":   "907060870",
+	}
+
+	for method, expected := range tests {
+		actual, err := checksum(strings.NewReader("hello"), method)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if actual != expected {
+			t.Errorf("Unexpected %s checksum (Got: %s, Expected: %s)", method, actual, expected)
+		}
+	}
+}
+
+func TestChecksumLine(t *testing.T) {
+	tests := map[string]string{
+		"gnu": "abc  app.zip\n",
+		"bsd": "SHA256 (app.zip) = abc\n",
+	}
+
+	for style, expected := range tests {
+		if actual := checksumLine(style, "sha256", "abc", "app.zip"); actual != expected {
+			t.Errorf("Unexpected %s line (Got: %s, Expected: %s)", style, actual, expected)
+		}
+	}
+}
+
+func TestWriteSidecars(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "app.zip")
+
+	if err := os.WriteFile(file, []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	sidecars, err := writeSidecars([]string{file}, dir, "gnu")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sidecars) != 1 || sidecars[0] != file+".sha256" {
+		t.Fatalf("Unexpected sidecars (Got: %v, Expected: %v)", sidecars, []string{file + ".sha256"})
+	}
+
+	content, err := os.ReadFile(sidecars[0])
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824  app.zip\n"
+	if string(content) != expected {
+		t.Errorf("Unexpected sidecar (Got: %s, Expected: %s)", content, expected)
+	}
+}
+
+func TestReadFileLists(t *testing.T) {
+	list := filepath.Join(t.TempDir(), "artifacts.txt")
+
+	if err := os.WriteFile(list, []byte("dist/app.zip\r\n\n# debug builds\ndist/*.tar.gz\n!dist/*-debug.tar.gz\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	actual, err := readFileLists([]string{"README.md", "@" + list})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"README.md", "dist/app.zip", "dist/*.tar.gz", "!dist/*-debug.tar.gz"}
+	if strings.Join(actual, ",") != strings.Join(expected, ",") {
+		t.Errorf("Unexpected files (Got: %v, Expected: %v)", actual, expected)
+	}
+}
+
+func TestExpandFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	for _, name := range []string{"app.tar.gz", "app.zip", "notes.md"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	actual, err := expandFiles(ioutil.Discard, logrus.StandardLogger(), []string{
+		filepath.Join(dir, "*.tar.gz"),
+		filepath.Join(dir, "app.*"),
+		filepath.Join(dir, "*.exe"),
+	}, 0)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{filepath.Join(dir, "app.tar.gz"), filepath.Join(dir, "app.zip")}
+	if strings.Join(actual, ",") != strings.Join(expected, ",") {
+		t.Errorf("Unexpected files (Got: %v, Expected: %v)", actual, expected)
+	}
+
+	actual, err = expandFiles(ioutil.Discard, logrus.StandardLogger(), []string{
+		filepath.Join(dir, "*"),
+		"!" + filepath.Join(dir, "app.*"),
+		filepath.Join(dir, "*.zip"),
+		"!*.md",
+	}, 0)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = []string{filepath.Join(dir, "app.zip")}
+	if strings.Join(actual, ",") != strings.Join(expected, ",") {
+		t.Errorf("Unexpected files (Got: %v, Expected: %v)", actual, expected)
+	}
+}
+
+func TestGlobFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	for _, name := range []string{"out/a.zip", "out/x/release/b.zip", "out/x/y/release/c.zip", "out/x/debug/d.zip"} {
+		file := filepath.Join(dir, name)
+
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(file, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := map[string][]string{
+		"out/**/release/*.zip": {"out/x/release/b.zip", "out/x/y/release/c.zip"},
+		"out/**/*.zip":         {"out/a.zip", "out/x/debug/d.zip", "out/x/release/b.zip", "out/x/y/release/c.zip"},
+		"*/x/**/d.zip":         {"out/x/debug/d.zip"},
+	}
+
+	for pattern, names := range tests {
+		actual, err := globFiles(filepath.Join(dir, pattern))
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var expected []string
+		for _, name := range names {
+			expected = append(expected, filepath.Join(dir, name))
+		}
+
+		if strings.Join(actual, ",") != strings.Join(expected, ",") {
+			t.Errorf("Unexpected files for %s (Got: %v, Expected: %v)", pattern, actual, expected)
+		}
+	}
+}
+
+func TestWalkFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	for _, name := range []string{"a", "sub/b", "sub/deep/c"} {
+		file := filepath.Join(dir, name)
+
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(file, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := map[int]int{0: 3, 1: 1, 2: 2}
+
+	for depth, expected := range tests {
+		files, err := walkFiles(dir, depth)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(files) != expected {
+			t.Errorf("Unexpected files for depth %d (Got: %v, Expected: %d files)", depth, files, expected)
+		}
+	}
+}
+
+func TestExcludeFiles(t *testing.T) {
+	files := []string{"dist/app", "dist/app.map", "dist/debug/app.debug", "dist/README.md"}
+
+	actual, err := excludeFiles(ioutil.Discard, files, []string{"*.map", "*.debug", "dist/*.md"})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Join(actual, ",") != "dist/app" {
+		t.Errorf("Unexpected files (Got: %v, Expected: [dist/app])", actual)
+	}
+}
+
+func TestAppendEnvFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "release.env")
+
+	if err := os.WriteFile(file, []byte("VERSION=1.0.0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := appendEnvFile(file, [][2]string{
+		{"RELEASE_URL", "https://github.com/octocat/hello/releases/tag/v1.0.0"},
+		{"RELEASE_NAME", "Version 1.0.0"},
+		{"RELEASE_NOTES", "Don't expand $HOME or `id`\n\\n"},
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(file)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "VERSION=1.0.0\nRELEASE_URL=https://github.com/octocat/hello/releases/tag/v1.0.0\nRELEASE_NAME='Version 1.0.0'\nRELEASE_NOTES='Don'\\''t expand $HOME or `id`\n\\n'\n"
+	if string(content) != expected {
+		t.Errorf("Unexpected env file (Got: %q, Expected: %q)", content, expected)
+	}
+}
@@ -0,0 +1,198 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+var (
+	sizeBudgetValues = map[string]bool{
+		"fail": true,
+		"warn": true,
+	}
+
+	sizeUnits = []struct {
+		suffix string
+		factor int64
+	}{
+		{"GB", 1 << 30},
+		{"MB", 1 << 20},
+		{"KB", 1 << 10},
+		{"G", 1 << 30},
+		{"M", 1 << 20},
+		{"K", 1 << 10},
+		{"B", 1},
+	}
+)
+
+// checkPolicy verifies that every archive contains the required files and
+// the notes contain the required texts, listing all violations.
+func checkPolicy(files, archiveFiles []string, notes string, noteTexts []string) error {
+	var violations []string
+
+	if len(archiveFiles) > 0 {
+		for _, file := range files {
+			if !isArchive(file) {
+				continue
+			}
+
+			entries, err := archiveEntries(file)
+
+			if err != nil {
+				return fmt.Errorf("failed to read archive %s: %w", file, err)
+			}
+
+			for _, pattern := range archiveFiles {
+				if !containsEntry(entries, pattern) {
+					violations = append(violations, fmt.Sprintf("archive %s is missing %s", path.Base(file), pattern))
+				}
+			}
+		}
+	}
+
+	for _, text := range noteTexts {
+		if !strings.Contains(notes, text) {
+			violations = append(violations, fmt.Sprintf("notes are missing %q", text))
+		}
+	}
+
+	if len(violations) > 0 {
+		return fmt.Errorf("policy violations: %s", strings.Join(violations, "; "))
+	}
+
+	return nil
+}
+
+func isArchive(file string) bool {
+	return strings.HasSuffix(file, ".tar.gz") || strings.HasSuffix(file, ".tgz") || strings.HasSuffix(file, ".zip")
+}
+
+// containsEntry matches the pattern against the base names of the entries.
+func containsEntry(entries []string, pattern string) bool {
+	for _, entry := range entries {
+		if ok, _ := path.Match(pattern, path.Base(entry)); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// archiveEntries lists the names of the files within an archive.
+func archiveEntries(file string) ([]string, error) {
+	var entries []string
+
+	if strings.HasSuffix(file, ".zip") {
+		r, err := zip.OpenReader(file)
+
+		if err != nil {
+			return nil, err
+		}
+
+		defer r.Close()
+
+		for _, f := range r.File {
+			entries = append(entries, f.Name)
+		}
+
+		return entries, nil
+	}
+
+	handle, err := os.Open(file)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer handle.Close()
+
+	gr, err := gzip.NewReader(handle)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tr := tar.NewReader(gr)
+
+	for {
+		header, err := tr.Next()
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, header.Name)
+	}
+
+	return entries, nil
+}
+
+// parseSize parses a byte size with an optional binary unit like 500MB.
+func parseSize(input string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(input))
+	factor := int64(1)
+
+	for _, unit := range sizeUnits {
+		if strings.HasSuffix(s, unit.suffix) {
+			s = strings.TrimSpace(strings.TrimSuffix(s, unit.suffix))
+			factor = unit.factor
+			break
+		}
+	}
+
+	size, err := strconv.ParseInt(s, 10, 64)
+
+	if err != nil || size < 0 {
+		return 0, fmt.Errorf("invalid size %s", input)
+	}
+
+	return size * factor, nil
+}
+
+// checkSizeBudget sums up the sizes of the files and fails if they exceed
+// the budget, listing the largest file as hint.
+func checkSizeBudget(out io.Writer, files []string, budget int64) error {
+	var (
+		total   int64
+		largest string
+		size    int64
+	)
+
+	for _, file := range files {
+		info, err := os.Stat(file)
+
+		if err != nil {
+			return err
+		}
+
+		total += info.Size()
+
+		if info.Size() > size {
+			largest, size = file, info.Size()
+		}
+	}
+
+	fmt.Fprintf(out, "Release assets have a total size of %d bytes\n", total)
+
+	if total > budget {
+		return fmt.Errorf("total asset size of %d bytes exceeds the budget of %d bytes, largest asset is %s with %d bytes", total, budget, path.Base(largest), size)
+	}
+
+	return nil
+}
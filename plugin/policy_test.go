@@ -0,0 +1,67 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckPolicy(t *testing.T) {
+	dir := t.TempDir()
+	app := filepath.Join(dir, "app")
+	archive := filepath.Join(dir, "app.tar.gz")
+
+	if err := os.WriteFile(app, []byte("app"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writeArchive(archive, "tar.gz", []string{app}, archiveOptions{Permissions: "preserve"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := checkPolicy([]string{archive}, []string{"app"}, "Export notice", []string{"Export notice"}); err != nil {
+		t.Errorf("Unexpected error %s", err)
+	}
+
+	err := checkPolicy([]string{archive}, []string{"LICENSE*"}, "", []string{"Export notice"})
+
+	if err == nil {
+		t.Fatal("Expected policy violations")
+	}
+
+	if !strings.Contains(err.Error(), "archive app.tar.gz is missing LICENSE*") || !strings.Contains(err.Error(), `notes are missing "Export notice"`) {
+		t.Errorf("Unexpected error %s", err)
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := map[string]int64{
+		"1024":  1024,
+		"10KB":  10 << 10,
+		"500MB": 500 << 20,
+		"2g":    2 << 30,
+	}
+
+	for input, expected := range tests {
+		actual, err := parseSize(input)
+
+		if err != nil {
+			t.Errorf("Unexpected error for %s: %s", input, err)
+			continue
+		}
+
+		if actual != expected {
+			t.Errorf("Unexpected size for %s (Got: %d, Expected: %d)", input, actual, expected)
+		}
+	}
+
+	if _, err := parseSize("many"); err == nil {
+		t.Error("Expected an error for an invalid size")
+	}
+}
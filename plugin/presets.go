@@ -0,0 +1,132 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// preset bundles the title and note templates for a type of project. The
+// note template gets the configured notes as .Notes.
+type preset struct {
+	Title string
+	Note  string
+}
+
+const assetTable = `{{if .Assets}}
+
+## Downloads
+
+| File | Size | SHA256 |
+| --- | --- | --- |
+{{range .Assets}}| [{{.Name}}]({{.URL}}) | {{humanSize .Size}} | ` + "`{{.SHA256}}`" + ` |
+{{end}}{{end}}`
+
+var (
+	presets = map[string]preset{
+		"go-cli": {
+			Title: "{{.Project}} {{.Tag}}",
+			Note: "{{.Notes}}\n\n## Installation\n\n```sh\ngo install github.com/{{.Owner}}/{{.Project}}@{{.Tag}}\n```\n\n" +
+				"Or download a prebuilt binary for your platform below." + assetTable,
+		},
+		"library": {
+			Title: "{{.Tag}}",
+			Note:  "{{.Notes}}\n\n## Usage\n\n```sh\ngo get github.com/{{.Owner}}/{{.Project}}@{{.Tag}}\n```" + assetTable,
+		},
+		"desktop-app": {
+			Title: "{{.Project}} {{.Version}}",
+			Note: "{{.Notes}}{{if .Assets}}\n\n## Downloads\n\n| Platform | File | Size |\n| --- | --- | --- |\n" +
+				"{{range .Assets}}| {{platform .Name}} | [{{.Name}}]({{.URL}}) | {{humanSize .Size}} |\n{{end}}{{end}}",
+		},
+	}
+
+	platformExtensions = map[string]string{
+		".dmg":      "macOS",
+		".pkg":      "macOS",
+		".exe":      "Windows",
+		".msi":      "Windows",
+		".msix":     "Windows",
+		".appimage": "Linux",
+		".deb":      "Linux",
+		".rpm":      "Linux",
+		".snap":     "Linux",
+		".flatpak":  "Linux",
+	}
+
+	platformNames = []struct {
+		match    string
+		platform string
+	}{
+		{"darwin", "macOS"},
+		{"macos", "macOS"},
+		{"windows", "Windows"},
+		{"linux", "Linux"},
+		{"freebsd", "FreeBSD"},
+	}
+)
+
+// applyPreset renders the title and notes of the preset. A configured
+// title takes precedence over the preset title.
+func applyPreset(name, title, note string, data templateData) (string, string, error) {
+	p, ok := presets[name]
+
+	if !ok {
+		return "", "", fmt.Errorf("unknown template preset %s", name)
+	}
+
+	data.Notes = strings.TrimSpace(note)
+
+	if title == "" {
+		var err error
+
+		if title, err = renderTemplate(name, p.Title, data); err != nil {
+			return "", "", err
+		}
+	}
+
+	note, err := renderTemplate(name, p.Note, data)
+
+	if err != nil {
+		return "", "", err
+	}
+
+	return title, strings.TrimSpace(note), nil
+}
+
+func humanSize(size int64) string {
+	const unit = 1024
+
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+// platform guesses the operating system of a file from its name.
+func platform(name string) string {
+	lower := strings.ToLower(name)
+
+	if p, ok := platformExtensions[path.Ext(lower)]; ok {
+		return p
+	}
+
+	for _, pn := range platformNames {
+		if strings.Contains(lower, pn.match) {
+			return pn.platform
+		}
+	}
+
+	return "Other"
+}
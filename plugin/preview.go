@@ -0,0 +1,46 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"fmt"
+	"html"
+	"io/ioutil"
+
+	"github.com/google/go-github/v44/github"
+)
+
+// previewPage wraps the rendered notes into a standalone html document.
+func previewPage(title, body string) string {
+	title = html.EscapeString(title)
+
+	return "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>" + title + "</title>\n</head>\n<body>\n<h1>" + title + "</h1>\n" + body + "\n</body>\n</html>\n"
+}
+
+// writePreview renders the notes of the release through the markdown api,
+// so the preview matches the release page, and writes it to the file.
+func (rc *releaseClient) writePreview(release *github.RepositoryRelease, file string) error {
+	body, _, err := rc.Client.Markdown(rc.Context, stripMetadata(release.GetBody()), &github.MarkdownOptions{
+		Mode:    "gfm",
+		Context: rc.Owner + "/" + rc.Repo,
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to render the release notes: %w", err)
+	}
+
+	title := release.GetName()
+	if title == "" {
+		title = release.GetTagName()
+	}
+
+	if err := ioutil.WriteFile(file, []byte(previewPage(title, body)), 0644); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(rc.Out, "Successfully wrote release notes preview to %s\n", file)
+	return nil
+}
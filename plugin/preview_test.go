@@ -0,0 +1,24 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPreviewPage(t *testing.T) {
+	actual := previewPage("v1.0.0 <beta>", "<p>notes</p>")
+
+	for _, expected := range []string{
+		"<title>v1.0.0 &lt;beta&gt;</title>",
+		"<h1>v1.0.0 &lt;beta&gt;</h1>\n<p>notes</p>\n</body>",
+	} {
+		if !strings.Contains(actual, expected) {
+			t.Errorf("Unexpected preview (Got: %s, Expected: %s)", actual, expected)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestConfirm(t *testing.T) {
+	tests := map[string]bool{
+		"y\n":   true,
+		"YES\n": true,
+		"n\n":   false,
+		"\n":    false,
+		"":      false,
+	}
+
+	for input, expected := range tests {
+		actual, err := confirm(strings.NewReader(input), ioutil.Discard, "Proceed?")
+
+		if err != nil {
+			t.Errorf("Unexpected error for %q: %s", input, err)
+		}
+
+		if actual != expected {
+			t.Errorf("Unexpected answer for %q (Got: %t, Expected: %t)", input, actual, expected)
+		}
+	}
+}
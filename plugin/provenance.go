@@ -0,0 +1,134 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/drone-plugins/drone-plugin-lib/drone"
+)
+
+const (
+	provenanceName      = "provenance.intoto.jsonl"
+	provenanceBuildType = "https://github.com/drone-plugins/drone-github-release/buildtypes/drone/v1"
+)
+
+// statement is an in-toto v1 statement.
+type statement struct {
+	Type          string      `json:"_type"`
+	Subject       []subject   `json:"subject"`
+	PredicateType string      `json:"predicateType"`
+	Predicate     interface{} `json:"predicate"`
+}
+
+// subject is an artifact the statement is about.
+type subject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+// provenance is a SLSA v1 provenance predicate.
+type provenance struct {
+	BuildDefinition struct {
+		BuildType            string               `json:"buildType"`
+		ExternalParameters   map[string]string    `json:"externalParameters"`
+		InternalParameters   map[string]string    `json:"internalParameters,omitempty"`
+		ResolvedDependencies []resourceDescriptor `json:"resolvedDependencies"`
+	} `json:"buildDefinition"`
+	RunDetails struct {
+		Builder struct {
+			ID string `json:"id"`
+		} `json:"builder"`
+		Metadata struct {
+			InvocationID string     `json:"invocationId,omitempty"`
+			StartedOn    *time.Time `json:"startedOn,omitempty"`
+			FinishedOn   *time.Time `json:"finishedOn,omitempty"`
+		} `json:"metadata"`
+	} `json:"runDetails"`
+}
+
+type resourceDescriptor struct {
+	URI    string            `json:"uri"`
+	Digest map[string]string `json:"digest"`
+}
+
+// fileSubjects describes the files by name and sha256 digest.
+func fileSubjects(files []string) ([]subject, error) {
+	var subjects []subject
+
+	for _, file := range files {
+		handle, err := os.Open(file)
+
+		if err != nil {
+			return nil, err
+		}
+
+		hash, err := checksum(handle, "sha256")
+		handle.Close()
+
+		if err != nil {
+			return nil, err
+		}
+
+		subjects = append(subjects, subject{
+			Name:   filepath.Base(file),
+			Digest: map[string]string{"sha256": hash},
+		})
+	}
+
+	return subjects, nil
+}
+
+// buildProvenance describes how the pipeline built the subjects.
+func buildProvenance(subjects []subject, pipeline drone.Pipeline, now time.Time) statement {
+	var predicate provenance
+
+	predicate.BuildDefinition.BuildType = provenanceBuildType
+	predicate.BuildDefinition.ExternalParameters = map[string]string{
+		"repository": pipeline.Repo.Link,
+		"ref":        pipeline.Commit.Ref,
+		"event":      pipeline.Build.Event,
+	}
+	predicate.BuildDefinition.InternalParameters = map[string]string{
+		"stage":    pipeline.Stage.Name,
+		"platform": pipeline.Stage.OS + "/" + pipeline.Stage.Arch,
+	}
+	predicate.BuildDefinition.ResolvedDependencies = []resourceDescriptor{{
+		URI:    "git+" + pipeline.Repo.Link + "@" + pipeline.Commit.Ref,
+		Digest: map[string]string{"gitCommit": pipeline.Commit.SHA},
+	}}
+
+	predicate.RunDetails.Builder.ID = pipeline.System.Proto + "://" + pipeline.System.Host
+	predicate.RunDetails.Metadata.InvocationID = pipeline.Build.Link
+
+	if !pipeline.Build.Started.IsZero() {
+		predicate.RunDetails.Metadata.StartedOn = &pipeline.Build.Started
+	}
+
+	predicate.RunDetails.Metadata.FinishedOn = &now
+
+	return statement{
+		Type:          "https://in-toto.io/Statement/v1",
+		Subject:       subjects,
+		PredicateType: "https://slsa.dev/provenance/v1",
+		Predicate:     predicate,
+	}
+}
+
+// writeProvenance writes the statement as a single json line.
+func writeProvenance(file string, s statement) error {
+	b, err := json.Marshal(s)
+
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(file, append(b, '\n'), 0644)
+}
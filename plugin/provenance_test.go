@@ -0,0 +1,82 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/drone-plugins/drone-plugin-lib/drone"
+)
+
+func TestWriteProvenance(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "app.txt")
+
+	if err := ioutil.WriteFile(file, []byte("hello\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	subjects, err := fileSubjects([]string{file})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var pipeline drone.Pipeline
+	pipeline.Repo.Link = "https://github.com/octocat/hello"
+	pipeline.Commit.Ref = "refs/tags/v1.0.0"
+	pipeline.Commit.SHA = "abc123"
+	pipeline.System.Proto = "https"
+	pipeline.System.Host = "drone.example.com"
+
+	output := filepath.Join(dir, provenanceName)
+
+	if err := writeProvenance(output, buildProvenance(subjects, pipeline, time.Now())); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := ioutil.ReadFile(output)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var result struct {
+		Subject   []subject `json:"subject"`
+		Predicate struct {
+			BuildDefinition struct {
+				ResolvedDependencies []resourceDescriptor `json:"resolvedDependencies"`
+			} `json:"buildDefinition"`
+			RunDetails struct {
+				Builder struct {
+					ID string `json:"id"`
+				} `json:"builder"`
+			} `json:"runDetails"`
+		} `json:"predicate"`
+	}
+
+	if err := json.Unmarshal(content, &result); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03"
+
+	if len(result.Subject) != 1 || result.Subject[0].Name != "app.txt" || result.Subject[0].Digest["sha256"] != expected {
+		t.Errorf("Unexpected subject (Got: %v, Expected: app.txt %s)", result.Subject, expected)
+	}
+
+	if got := result.Predicate.BuildDefinition.ResolvedDependencies[0].Digest["gitCommit"]; got != "abc123" {
+		t.Errorf("Unexpected commit (Got: %s, Expected: %s)", got, "abc123")
+	}
+
+	if got := result.Predicate.RunDetails.Builder.ID; got != "https://drone.example.com" {
+		t.Errorf("Unexpected builder (Got: %s, Expected: %s)", got, "https://drone.example.com")
+	}
+}
@@ -0,0 +1,194 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v44/github"
+)
+
+var (
+	queueValues = map[string]bool{
+		"":       true,
+		"github": true,
+	}
+
+	queueScopeValues = map[string]bool{
+		"repo":  true,
+		"token": true,
+	}
+)
+
+// repoLock serializes plugin runs by a lock ref within a repository. The
+// ref points to an annotated tag, whose date marks when it was taken.
+type repoLock struct {
+	Owner string
+	Repo  string
+	Ref   string
+}
+
+// lockRef returns the lock ref for the scope, either one per released
+// repository or one shared by all runs using the lock repository.
+func lockRef(scope, owner, repo string) string {
+	if scope == "token" {
+		return "refs/locks/drone-github-release/global"
+	}
+
+	return fmt.Sprintf("refs/locks/drone-github-release/%s/%s", owner, repo)
+}
+
+// acquireLock waits until the lock got created and returns the sha of the
+// lock tag, removing locks older than stale which were left by crashed runs.
+// Every attempt creates a new tag, so the date of the tag holding the lock
+// marks when the lock was acquired, not when the run started waiting.
+func (rc *releaseClient) acquireLock(lock repoLock, timeout, stale, interval time.Duration) (string, error) {
+	repo, _, err := rc.Client.Repositories.Get(rc.Context, lock.Owner, lock.Repo)
+
+	if err != nil {
+		return "", fmt.Errorf("failed to retrieve lock repository: %w", err)
+	}
+
+	branch, _, err := rc.Client.Repositories.GetBranch(rc.Context, lock.Owner, lock.Repo, repo.GetDefaultBranch(), true)
+
+	if err != nil {
+		return "", fmt.Errorf("failed to retrieve default branch of lock repository: %w", err)
+	}
+
+	deadline := time.Now().Add(timeout)
+
+	for {
+		tag, err := rc.lockTag(lock, branch.GetCommit().GetSHA())
+
+		if err != nil {
+			return "", err
+		}
+
+		_, resp, err := rc.Client.Git.CreateRef(rc.Context, lock.Owner, lock.Repo, &github.Reference{
+			Ref:    github.String(lock.Ref),
+			Object: &github.GitObject{SHA: tag.SHA},
+		})
+
+		if err == nil {
+			fmt.Fprintf(rc.Out, "Acquired lock %s\n", lock.Ref)
+			return tag.GetSHA(), nil
+		}
+
+		if resp == nil || resp.StatusCode != http.StatusUnprocessableEntity {
+			return "", fmt.Errorf("failed to create lock %s: %w", lock.Ref, err)
+		}
+
+		held, taken, err := rc.lockHolder(lock)
+
+		if err != nil {
+			return "", err
+		}
+
+		if held != "" && time.Since(taken) > stale {
+			fmt.Fprintf(rc.Out, "Removing stale lock %s taken at %s\n", lock.Ref, taken)
+
+			if err := rc.releaseLock(lock, held); err != nil {
+				return "", err
+			}
+
+			continue
+		}
+
+		if time.Now().Add(interval).After(deadline) {
+			return "", fmt.Errorf("timed out waiting for lock %s", lock.Ref)
+		}
+
+		fmt.Fprintf(rc.Out, "Waiting for lock %s\n", lock.Ref)
+		time.Sleep(interval)
+	}
+}
+
+// lockTag creates the annotated tag dated now, which the lock ref points to
+// once it got acquired.
+func (rc *releaseClient) lockTag(lock repoLock, sha string) (*github.Tag, error) {
+	now := time.Now()
+
+	tag, _, err := rc.Client.Git.CreateTag(rc.Context, lock.Owner, lock.Repo, &github.Tag{
+		Tag:     github.String(strings.TrimPrefix(lock.Ref, "refs/")),
+		Message: github.String(fmt.Sprintf("Lock for %s/%s %s", rc.Owner, rc.Repo, rc.Tag)),
+		Object: &github.GitObject{
+			Type: github.String("commit"),
+			SHA:  github.String(sha),
+		},
+		Tagger: &github.CommitAuthor{
+			Name:  github.String("drone-github-release"),
+			Email: github.String("drone-github-release@users.noreply.github.com"),
+			Date:  &now,
+		},
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to create lock tag: %w", err)
+	}
+
+	return tag, nil
+}
+
+// lockSHA returns the sha of the tag holding the lock, which is empty if
+// the lock is free.
+func (rc *releaseClient) lockSHA(lock repoLock) (string, error) {
+	ref, resp, err := rc.Client.Git.GetRef(rc.Context, lock.Owner, lock.Repo, strings.TrimPrefix(lock.Ref, "refs/"))
+
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return "", nil
+		}
+
+		return "", fmt.Errorf("failed to get lock %s: %w", lock.Ref, err)
+	}
+
+	return ref.GetObject().GetSHA(), nil
+}
+
+// lockHolder returns the sha of the tag holding the lock and when it was
+// taken, which are empty if the lock got released in the meantime.
+func (rc *releaseClient) lockHolder(lock repoLock) (string, time.Time, error) {
+	sha, err := rc.lockSHA(lock)
+
+	if err != nil || sha == "" {
+		return "", time.Time{}, err
+	}
+
+	tag, _, err := rc.Client.Git.GetTag(rc.Context, lock.Owner, lock.Repo, sha)
+
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to get lock tag %s: %w", lock.Ref, err)
+	}
+
+	return sha, tag.GetTagger().GetDate(), nil
+}
+
+// releaseLock deletes the lock if it is still held by the tag with the sha,
+// so a lock taken over by another run is never removed.
+func (rc *releaseClient) releaseLock(lock repoLock, sha string) error {
+	held, err := rc.lockSHA(lock)
+
+	if err != nil {
+		return err
+	}
+
+	if held != sha {
+		fmt.Fprintf(rc.Out, "Lock %s is no longer held by %s, keeping it\n", lock.Ref, sha)
+		return nil
+	}
+
+	resp, err := rc.Client.Git.DeleteRef(rc.Context, lock.Owner, lock.Repo, strings.TrimPrefix(lock.Ref, "refs/"))
+
+	if err != nil && (resp == nil || resp.StatusCode != http.StatusUnprocessableEntity) {
+		return fmt.Errorf("failed to release lock %s: %w", lock.Ref, err)
+	}
+
+	fmt.Fprintf(rc.Out, "Released lock %s\n", lock.Ref)
+	return nil
+}
@@ -0,0 +1,175 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeLocks is a fake git database holding lock refs and tags.
+type fakeLocks struct {
+	mu      sync.Mutex
+	refs    map[string]string
+	dates   map[string]time.Time
+	deleted int
+}
+
+func (f *fakeLocks) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	path := strings.TrimPrefix(r.URL.Path+"/", "/repos/octocat/locks/")
+	path = strings.TrimSuffix(path, "/")
+
+	switch {
+	case path == "":
+		fmt.Fprint(w, `{"default_branch": "main"}`)
+	case path == "branches/main":
+		fmt.Fprint(w, `{"commit": {"sha": "c0ffee"}}`)
+	case path == "git/tags" && r.Method == http.MethodPost:
+		sha := fmt.Sprintf("tag%d", len(f.dates))
+		f.dates[sha] = time.Now()
+		fmt.Fprintf(w, `{"sha": %q}`, sha)
+	case strings.HasPrefix(path, "git/tags/"):
+		sha := strings.TrimPrefix(path, "git/tags/")
+		fmt.Fprintf(w, `{"sha": %q, "tagger": {"date": %q}}`, sha, f.dates[sha].Format(time.RFC3339Nano))
+	case path == "git/refs" && r.Method == http.MethodPost:
+		var ref struct {
+			Ref string `json:"ref"`
+			SHA string `json:"sha"`
+		}
+
+		json.NewDecoder(r.Body).Decode(&ref)
+
+		if _, ok := f.refs[ref.Ref]; ok {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			fmt.Fprint(w, `{"message": "Reference already exists"}`)
+			return
+		}
+
+		f.refs[ref.Ref] = ref.SHA
+		fmt.Fprint(w, `{}`)
+	case strings.HasPrefix(path, "git/ref/") || strings.HasPrefix(path, "git/refs/"):
+		name := "refs/" + strings.SplitN(path, "/", 3)[2]
+		sha, ok := f.refs[name]
+
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		if r.Method == http.MethodDelete {
+			delete(f.refs, name)
+			f.deleted++
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		fmt.Fprintf(w, `{"ref": %q, "object": {"sha": %q}}`, name, sha)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestAcquireStaleLock(t *testing.T) {
+	lock := repoLock{Owner: "octocat", Repo: "locks", Ref: lockRef("token", "octocat", "hello")}
+	fake := &fakeLocks{
+		refs:  map[string]string{lock.Ref: "stale"},
+		dates: map[string]time.Time{"stale": time.Now().Add(-2 * time.Hour)},
+	}
+
+	rc := newTestClient(t, fake.ServeHTTP)
+	sha, err := rc.acquireLock(lock, time.Minute, time.Hour, time.Millisecond)
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if fake.refs[lock.Ref] != sha || fake.deleted != 1 {
+		t.Errorf("Unexpected lock (Got: %s, Expected: %s)", fake.refs[lock.Ref], sha)
+	}
+
+	// a lock taken over by another run is kept
+	fake.refs[lock.Ref] = "other"
+
+	if err := rc.releaseLock(lock, sha); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if fake.refs[lock.Ref] != "other" {
+		t.Errorf("Unexpected release of a lock held by another run")
+	}
+
+	fake.refs[lock.Ref] = sha
+
+	if err := rc.releaseLock(lock, sha); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if _, ok := fake.refs[lock.Ref]; ok {
+		t.Errorf("Unexpected lock left after release")
+	}
+}
+
+func TestWaitForLock(t *testing.T) {
+	lock := repoLock{Owner: "octocat", Repo: "locks", Ref: lockRef("repo", "octocat", "hello")}
+	fake := &fakeLocks{
+		refs:  map[string]string{lock.Ref: "held"},
+		dates: map[string]time.Time{"held": time.Now()},
+	}
+
+	rc := newTestClient(t, fake.ServeHTTP)
+
+	if _, err := rc.acquireLock(lock, 20*time.Millisecond, time.Hour, 5*time.Millisecond); err == nil {
+		t.Errorf("Expected a timeout waiting for a held lock")
+	}
+
+	if fake.refs[lock.Ref] != "held" || fake.deleted != 0 {
+		t.Errorf("Unexpected lock state (Got: ref %s, %d deleted)", fake.refs[lock.Ref], fake.deleted)
+	}
+}
+
+func TestLockAcquisitionDate(t *testing.T) {
+	lock := repoLock{Owner: "octocat", Repo: "locks", Ref: lockRef("repo", "octocat", "hello")}
+	fake := &fakeLocks{
+		refs:  map[string]string{lock.Ref: "held"},
+		dates: map[string]time.Time{"held": time.Now()},
+	}
+
+	rc := newTestClient(t, fake.ServeHTTP)
+	start := time.Now()
+
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+
+		fake.mu.Lock()
+		delete(fake.refs, lock.Ref)
+		fake.mu.Unlock()
+	}()
+
+	sha, err := rc.acquireLock(lock, time.Second, time.Hour, 5*time.Millisecond)
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	// the lock is dated by its acquisition, not by the start of the wait
+	_, taken, err := rc.lockHolder(lock)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fake.refs[lock.Ref] != sha || taken.Sub(start) < 20*time.Millisecond {
+		t.Errorf("Unexpected lock date (Got: %s after the start of the wait)", taken.Sub(start))
+	}
+}
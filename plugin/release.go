@@ -8,9 +8,16 @@ package plugin
 import (
 	"context"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
 	"os"
 	"path"
+	"regexp"
+	"sort"
+	"strings"
 
+	"github.com/drone-plugins/drone-github-release/pkg/release"
 	"github.com/google/go-github/v44/github"
 )
 
@@ -21,6 +28,7 @@ type releaseClient struct {
 	Owner                string
 	Repo                 string
 	Tag                  string
+	Commit               string
 	Draft                bool
 	Prerelease           bool
 	FileExists           string
@@ -28,9 +36,44 @@ type releaseClient struct {
 	Note                 string
 	Overwrite            bool
 	GenerateReleaseNotes bool
+	ConsolidateDrafts    bool
+	ImportPrereleases    bool
+	PromoteAssets        bool
+	Metadata             map[string]string
+	OnlyManageOwn        bool
+	Force                bool
+	Resume               bool
+	UpdatePrerelease     bool
+	TempDir              string
+	Target               string
+	DiscussionCategory   string
+	Redactions           []*regexp.Regexp
+	ProtectDownloaded    bool
+	OwnAssets            bool
+	Streams              map[string][]byte
+	ScanSecrets          bool
+	ReleaseID            int64
+
+	// HTTPClient follows redirects away from the api, without the token
+	HTTPClient *http.Client
+
+	// Out receives the progress, filtered by the output log
+	Out io.Writer
+
+	resumed bool
 }
 
 func (rc *releaseClient) buildRelease() (*github.RepositoryRelease, error) {
+	if rc.ConsolidateDrafts {
+		if err := rc.consolidateDrafts(); err != nil {
+			return nil, fmt.Errorf("failed to consolidate drafts: %w", err)
+		}
+	}
+
+	if err := rc.prepareNote(); err != nil {
+		return nil, err
+	}
+
 	// first attempt to get a release by that tag
 	release, err := rc.getRelease()
 
@@ -42,8 +85,17 @@ func (rc *releaseClient) buildRelease() (*github.RepositoryRelease, error) {
 		// if no release was found by that tag, create a new one
 		release, err = rc.newRelease()
 	} else {
-		// update release if exists
-		release, err = rc.editRelease(*release)
+		if err := rc.checkOwnership(release); err != nil {
+			return nil, err
+		}
+
+		if rc.Resume && rc.isResumable(release) {
+			// continue the draft of an interrupted run
+			release, err = rc.resumeRelease(release)
+		} else {
+			// update release if exists
+			release, err = rc.editRelease(*release)
+		}
 	}
 
 	if err != nil {
@@ -53,54 +105,135 @@ func (rc *releaseClient) buildRelease() (*github.RepositoryRelease, error) {
 	return release, nil
 }
 
-func (rc *releaseClient) getRelease() (*github.RepositoryRelease, error) {
+// prepareNote adds generated sections to the note.
+func (rc *releaseClient) prepareNote() error {
+	if rc.ImportPrereleases && !rc.Prerelease {
+		notes, err := rc.prereleaseNotes()
 
-	listOpts := &github.ListOptions{PerPage: 10}
+		if err != nil {
+			return fmt.Errorf("failed to import prerelease notes: %w", err)
+		}
+
+		if notes != "" {
+			rc.Note = strings.TrimSpace(rc.Note + "\n\n" + notes)
+		}
+	}
+
+	// notes generated by GitHub on creation can't be redacted or scanned, so
+	// they are generated up front instead
+	if rc.GenerateReleaseNotes && (len(rc.Redactions) > 0 || rc.ScanSecrets) {
+		notes, _, err := rc.Client.Repositories.GenerateReleaseNotes(rc.Context, rc.Owner, rc.Repo, &github.GenerateNotesOptions{
+			TagName:         rc.Tag,
+			TargetCommitish: github.String(rc.Commit),
+		})
 
-	for {
-		// get list of releases (10 releases per page)
-		releases, resp, err := rc.Client.Repositories.ListReleases(rc.Context, rc.Owner, rc.Repo, listOpts)
 		if err != nil {
-			return nil, fmt.Errorf("failed to list releases: %w", err)
+			return fmt.Errorf("failed to generate release notes: %w", err)
 		}
 
-		// browse through current release page
-		for _, release := range releases {
+		rc.Note = strings.TrimSpace(rc.Note + "\n\n" + notes.Body)
+		rc.GenerateReleaseNotes = false
+	}
 
-			// return release associated to the given tag (can only be one)
-			if release.GetTagName() == rc.Tag {
-				fmt.Printf("Found release %d for tag %s\n", release.GetID(), release.GetTagName())
-				return release, nil
-			}
+	if len(rc.Redactions) > 0 {
+		rc.Note = redactNotes(rc.Note, rc.Redactions)
+	}
+
+	// scanned once the imported and generated notes are added
+	if rc.ScanSecrets {
+		if err := scanSecrets("", rc.Note, nil, nil); err != nil {
+			return fmt.Errorf("secret scan failed: %w", err)
 		}
+	}
 
-		// end of list found without finding a matching release
-		if resp.NextPage == 0 {
-			fmt.Println("no existing release (draft) found for the given tag")
-			return nil, nil
+	return nil
+}
+
+// checkOwnership fails if only releases created by the plugin should be
+// managed and the release lacks the metadata marker.
+func (rc *releaseClient) checkOwnership(release *github.RepositoryRelease) error {
+	if !rc.OnlyManageOwn || readMetadata(release.GetBody()) != nil {
+		return nil
+	}
+
+	if rc.Force {
+		fmt.Fprintf(rc.Out, "Forcing modification of release %d not created by this plugin\n", release.GetID())
+		return nil
+	}
+
+	return fmt.Errorf("release %d for tag %s was not created by this plugin, use force to modify it anyway", release.GetID(), release.GetTagName())
+}
+
+func (rc *releaseClient) getRelease() (*github.RepositoryRelease, error) {
+	// a handed off draft is taken over by its id
+	if rc.ReleaseID != 0 {
+		found, _, err := rc.Client.Repositories.GetRelease(rc.Context, rc.Owner, rc.Repo, rc.ReleaseID)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve release %d: %w", rc.ReleaseID, err)
 		}
 
-		// go to next page in the next iteration
-		listOpts.Page = resp.NextPage
+		fmt.Fprintf(rc.Out, "Found release %d for tag %s\n", found.GetID(), found.GetTagName())
+		return found, nil
+	}
+
+	found, err := rc.library().GetByTag(rc.Context, rc.Tag)
+
+	if err != nil {
+		return nil, err
 	}
+
+	if found == nil {
+		fmt.Fprintln(rc.Out, "no existing release (draft) found for the given tag")
+		return nil, nil
+	}
+
+	fmt.Fprintf(rc.Out, "Found release %d for tag %s\n", found.GetID(), found.GetTagName())
+	return found, nil
 }
 
-func (rc *releaseClient) editRelease(targetRelease github.RepositoryRelease) (*github.RepositoryRelease, error) {
-	sourceRelease := &github.RepositoryRelease{}
+// reloadRelease fetches the release again, as uploads recording the owned
+// assets change its body behind the given copy.
+func (rc *releaseClient) reloadRelease(release *github.RepositoryRelease) (*github.RepositoryRelease, error) {
+	found, _, err := rc.Client.Repositories.GetRelease(rc.Context, rc.Owner, rc.Repo, release.GetID())
 
-	if rc.Overwrite {
-		sourceRelease.Name = &rc.Title
-		sourceRelease.Body = &rc.Note
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve release %d: %w", release.GetID(), err)
 	}
 
-	// only potentially change the draft value, if it's a draft right now
-	// i.e. a drafted release will be published, but a release won't be unpublished
+	return found, nil
+}
+
+// library returns the client of the release package for the repository.
+func (rc *releaseClient) library() *release.Client {
+	return release.New(rc.Client, rc.Owner, rc.Repo)
+}
+
+// options returns the release options of the release package.
+func (rc *releaseClient) options() release.Options {
+	return release.Options{
+		Tag:                  rc.Tag,
+		Title:                rc.Title,
+		Note:                 rc.Note,
+		Draft:                rc.Draft,
+		Prerelease:           rc.Prerelease,
+		KeepDraft:            rc.Resume,
+		Overwrite:            rc.Overwrite,
+		UpdatePrerelease:     rc.UpdatePrerelease,
+		GenerateReleaseNotes: rc.GenerateReleaseNotes,
+		Target:               rc.Target,
+		DiscussionCategory:   rc.DiscussionCategory,
+	}
+}
+
+func (rc *releaseClient) editRelease(targetRelease github.RepositoryRelease) (*github.RepositoryRelease, error) {
+	sourceRelease := rc.editPayload(targetRelease)
+
 	if targetRelease.GetDraft() {
-		fmt.Printf("DRAFT: %+v\n", rc.Draft)
+		fmt.Fprintf(rc.Out, "DRAFT: %+v\n", rc.Draft)
 		if !rc.Draft {
-			fmt.Println("Publishing a release draft")
+			fmt.Fprintln(rc.Out, "Publishing a release draft")
 		}
-		sourceRelease.Draft = &rc.Draft
 	}
 
 	modifiedRelease, _, err := rc.Client.Repositories.EditRelease(rc.Context, rc.Owner, rc.Repo, targetRelease.GetID(), sourceRelease)
@@ -109,34 +242,57 @@ func (rc *releaseClient) editRelease(targetRelease github.RepositoryRelease) (*g
 		return nil, fmt.Errorf("failed to update release: %w", err)
 	}
 
-	fmt.Printf("Successfully updated %s release\n", rc.Tag)
+	fmt.Fprintf(rc.Out, "Successfully updated %s release\n", rc.Tag)
 	return modifiedRelease, nil
 }
 
-func (rc *releaseClient) newRelease() (*github.RepositoryRelease, error) {
-	rr := &github.RepositoryRelease{
-		TagName:              github.String(rc.Tag),
-		Draft:                &rc.Draft,
-		Prerelease:           &rc.Prerelease,
-		Name:                 &rc.Title,
-		Body:                 &rc.Note,
-		GenerateReleaseNotes: &rc.GenerateReleaseNotes,
+// editPayload builds the changes applied to an existing release.
+func (rc *releaseClient) editPayload(targetRelease github.RepositoryRelease) *github.RepositoryRelease {
+	sourceRelease := release.EditPayload(rc.options(), &targetRelease)
+
+	// keep the metadata of previous runs, only updating the given values
+	if rc.Metadata != nil {
+		body := targetRelease.GetBody()
+
+		if rc.Overwrite {
+			body = rc.Note
+		}
+
+		body = writeMetadata(body, mergeMetadata(readMetadata(targetRelease.GetBody()), rc.Metadata))
+		sourceRelease.Body = &body
 	}
 
+	return sourceRelease
+}
+
+// createPayload builds a new release.
+func (rc *releaseClient) createPayload() *github.RepositoryRelease {
+	rr := release.CreatePayload(rc.options())
+
+	if rc.Metadata != nil {
+		rr.Body = github.String(writeMetadata(rc.Note, rc.Metadata))
+	}
+
+	return rr
+}
+
+func (rc *releaseClient) newRelease() (*github.RepositoryRelease, error) {
+	rr := rc.createPayload()
+
 	if *rr.Prerelease {
-		fmt.Printf("Release %s identified as a pre-release\n", rc.Tag)
+		fmt.Fprintf(rc.Out, "Release %s identified as a pre-release\n", rc.Tag)
 	} else {
-		fmt.Printf("Release %s identified as a full release\n", rc.Tag)
+		fmt.Fprintf(rc.Out, "Release %s identified as a full release\n", rc.Tag)
 	}
 
 	if *rr.Draft {
-		fmt.Printf("Release %s will be created as draft (unpublished) release\n", rc.Tag)
+		fmt.Fprintf(rc.Out, "Release %s will be created as draft (unpublished) release\n", rc.Tag)
 	} else {
-		fmt.Printf("Release %s will be created and published\n", rc.Tag)
+		fmt.Fprintf(rc.Out, "Release %s will be created and published\n", rc.Tag)
 	}
 
 	if *rr.GenerateReleaseNotes {
-		fmt.Printf("Release notes for %s will be automatically generated\n", rc.Tag)
+		fmt.Fprintf(rc.Out, "Release notes for %s will be automatically generated\n", rc.Tag)
 	}
 
 	release, _, err := rc.Client.Repositories.CreateRelease(rc.Context, rc.Owner, rc.Repo, rr)
@@ -145,19 +301,190 @@ func (rc *releaseClient) newRelease() (*github.RepositoryRelease, error) {
 		return nil, fmt.Errorf("failed to create release: %w", err)
 	}
 
-	fmt.Printf("Successfully created %s release\n", rc.Tag)
+	fmt.Fprintf(rc.Out, "Successfully created %s release\n", rc.Tag)
 	return release, nil
 }
 
 func (rc *releaseClient) uploadFiles(id int64, files []string) error {
-	var assets []*github.ReleaseAsset
-	listOpts := &github.ListOptions{PerPage: 10}
+	assets, err := rc.listAssets(id)
+
+	if err != nil {
+		return err
+	}
+
+	planned, err := planAssets(files, assets, rc.FileExists)
+
+	if err != nil {
+		return err
+	}
+
+	downloads := make(map[int64]int)
+	for _, asset := range assets {
+		downloads[asset.GetID()] = asset.GetDownloadCount()
+	}
+
+	if err := rc.checkDownloadedAssets(planned, downloads); err != nil {
+		return err
+	}
+
+	var (
+		release *github.RepositoryRelease
+		owned   map[string]bool
+	)
+
+	if rc.OwnAssets {
+		if release, owned, err = rc.ownedAssets(id); err != nil {
+			return err
+		}
+
+		if err := rc.checkOwnedAssets(planned, owned); err != nil {
+			return err
+		}
+	}
+
+	for _, pa := range planned {
+		if err := rc.uploadAsset(id, pa, downloads[pa.AssetID], rc.assetLog(pa.Name)); err != nil {
+			return err
+		}
+
+		// recorded right away, so a retry after a failed upload still
+		// owns the assets uploaded before
+		if owned != nil && pa.Action != "skip" {
+			if err := rc.recordOwnedAssets(release, owned, []string{pa.Name}); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// uploadAsset applies the plan of a single file, logging with its prefix.
+func (rc *releaseClient) uploadAsset(id int64, pa plannedAsset, downloads int, log *assetLog) error {
+	if pa.Action == "replace" && rc.verifiedOnResume(pa) {
+		identical, err := rc.verifyAsset(pa)
+
+		if err != nil {
+			return err
+		}
+
+		if identical {
+			log.Printf("Skipping already uploaded %s artifact\n", pa.Name)
+			return nil
+		}
+	}
+
+	if pa.Action == "skip" {
+		log.Printf("Skipping pre-existing %s artifact\n", pa.Name)
+		return nil
+	}
+
+	// checked here only after the verification, all other replacements
+	// are checked before the first one
+	if pa.Action == "replace" && downloads > 0 && rc.ProtectDownloaded && rc.verifiedOnResume(pa) {
+		if !rc.Force {
+			return fmt.Errorf("refusing to replace %s artifact downloaded %d times, use force to replace it anyway", pa.Name, downloads)
+		}
+
+		log.Printf("Forcing replacement of %s artifact downloaded %d times\n", pa.Name, downloads)
+	}
+
+	var upload func() error
+
+	if content, ok := rc.Streams[pa.File]; ok {
+		upload = func() error {
+			return rc.uploadContent(id, pa.Name, content)
+		}
+	} else {
+		handle, err := os.Open(pa.File)
+
+		if err != nil {
+			return fmt.Errorf("failed to read %s artifact: %w", pa.File, err)
+		}
+
+		defer handle.Close()
+
+		upload = func() error {
+			_, _, err := rc.Client.Repositories.UploadReleaseAsset(rc.Context, rc.Owner, rc.Repo, id, &github.UploadOptions{Name: pa.Name}, handle)
+			return err
+		}
+	}
+
+	if pa.Action == "replace" {
+		if _, err := rc.Client.Repositories.DeleteReleaseAsset(rc.Context, rc.Owner, rc.Repo, pa.AssetID); err != nil {
+			return fmt.Errorf("failed to delete %s artifact: %w", pa.File, err)
+		}
+
+		log.Printf("Successfully deleted old %s artifact\n", pa.Name)
+	}
+
+	if err := upload(); err != nil {
+		return fmt.Errorf("failed to upload %s artifact: %w", pa.File, err)
+	}
+
+	log.Printf("Successfully uploaded %s artifact\n", pa.File)
+	return nil
+}
+
+// checkDownloadedAssets refuses to replace downloaded assets before the
+// first one gets deleted.
+func (rc *releaseClient) checkDownloadedAssets(planned []plannedAsset, downloads map[int64]int) error {
+	if !rc.ProtectDownloaded {
+		return nil
+	}
+
+	for _, pa := range planned {
+		count := downloads[pa.AssetID]
+
+		if pa.Action != "replace" || count == 0 || rc.verifiedOnResume(pa) {
+			continue
+		}
+
+		if !rc.Force {
+			return fmt.Errorf("refusing to replace %s artifact downloaded %d times, use force to replace it anyway", pa.Name, count)
+		}
+
+		fmt.Fprintf(rc.Out, "Forcing replacement of %s artifact downloaded %d times\n", pa.Name, count)
+	}
+
+	return nil
+}
+
+// verifiedOnResume reports whether the asset gets compared with the file
+// before it is replaced. Streams can't be compared without downloading them
+// again.
+func (rc *releaseClient) verifiedOnResume(pa plannedAsset) bool {
+	return rc.resumed && rc.Streams[pa.File] == nil
+}
+
+func (rc *releaseClient) listAssets(id int64) ([]*github.ReleaseAsset, error) {
+	return rc.library().ListAssets(rc.Context, id)
+}
+
+// planAssets decides for each file whether it gets uploaded, replaces an
+// existing asset or gets skipped.
+func planAssets(files []string, assets []*github.ReleaseAsset, fileExists string) ([]plannedAsset, error) {
+	return release.PlanAssets(files, assets, fileExists)
+}
+
+// consolidateDrafts merges all drafts for the tag into the oldest one. Assets
+// missing from the oldest draft are copied over before the duplicates are
+// deleted.
+func (rc *releaseClient) consolidateDrafts() error {
+	var drafts []*github.RepositoryRelease
+	listOpts := &github.ListOptions{PerPage: 100}
+
 	for {
-		a, resp, err := rc.Client.Repositories.ListReleaseAssets(rc.Context, rc.Owner, rc.Repo, id, listOpts)
+		releases, resp, err := rc.Client.Repositories.ListReleases(rc.Context, rc.Owner, rc.Repo, listOpts)
 		if err != nil {
-			return fmt.Errorf("failed to fetch existing assets: %w", err)
+			return fmt.Errorf("failed to list releases: %w", err)
+		}
+
+		for _, release := range releases {
+			if release.GetDraft() && release.GetTagName() == rc.Tag {
+				drafts = append(drafts, release)
+			}
 		}
-		assets = append(assets, a...)
 
 		// stop iteration if there is no next page
 		if resp.NextPage == 0 {
@@ -167,54 +494,385 @@ func (rc *releaseClient) uploadFiles(id int64, files []string) error {
 		listOpts.Page = resp.NextPage
 	}
 
-	var uploadFiles []string
+	if len(drafts) < 2 {
+		return nil
+	}
 
-files:
-	for _, file := range files {
-		for _, asset := range assets {
-			if *asset.Name == path.Base(file) {
-				switch rc.FileExists {
-				case "overwrite":
-					// do nothing
-				case "fail":
-					return fmt.Errorf("asset file %s already exists", path.Base(file))
-				case "skip":
-					fmt.Printf("Skipping pre-existing %s artifact\n", *asset.Name)
-					continue files
-				default:
-					return fmt.Errorf("internal error, unknown file_exist value %s", rc.FileExists)
-				}
+	fmt.Fprintf(rc.Out, "Found %d drafts for tag %s, consolidating\n", len(drafts), rc.Tag)
+
+	target := drafts[0]
+	for _, draft := range drafts[1:] {
+		if draft.GetID() < target.GetID() {
+			target = draft
+		}
+	}
+
+	// every draft gets modified, so all of them need to be owned before
+	// anything is copied or deleted
+	for _, draft := range drafts {
+		if err := rc.checkOwnership(draft); err != nil {
+			return err
+		}
+	}
+
+	existing := make(map[string]bool)
+	for _, asset := range target.Assets {
+		existing[asset.GetName()] = true
+	}
+
+	for _, draft := range drafts {
+		if draft.GetID() == target.GetID() {
+			continue
+		}
+
+		for _, asset := range draft.Assets {
+			if existing[asset.GetName()] {
+				continue
+			}
+
+			if err := rc.copyAsset(asset, target.GetID()); err != nil {
+				return err
 			}
+
+			existing[asset.GetName()] = true
+			fmt.Fprintf(rc.Out, "Moved %s artifact from draft %d to draft %d\n", asset.GetName(), draft.GetID(), target.GetID())
+		}
+
+		if _, err := rc.Client.Repositories.DeleteRelease(rc.Context, rc.Owner, rc.Repo, draft.GetID()); err != nil {
+			return fmt.Errorf("failed to delete duplicate draft %d: %w", draft.GetID(), err)
 		}
 
-		uploadFiles = append(uploadFiles, file)
+		fmt.Fprintf(rc.Out, "Successfully deleted duplicate draft %d\n", draft.GetID())
+	}
+
+	return nil
+}
+
+// copyAsset uploads the content of an existing asset to the given release.
+func (rc *releaseClient) copyAsset(asset *github.ReleaseAsset, id int64) error {
+	handle, err := rc.downloadAsset(asset)
+
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(handle.Name())
+	defer handle.Close()
+
+	uo := &github.UploadOptions{Name: asset.GetName()}
+
+	if _, _, err := rc.Client.Repositories.UploadReleaseAsset(rc.Context, rc.Owner, rc.Repo, id, uo, handle); err != nil {
+		return fmt.Errorf("failed to upload %s artifact: %w", asset.GetName(), err)
+	}
+
+	return nil
+}
+
+// downloadAsset stores the content of an asset in a temporary file, which
+// has to be removed by the caller.
+func (rc *releaseClient) downloadAsset(asset *github.ReleaseAsset) (*os.File, error) {
+	body, _, err := rc.Client.Repositories.DownloadReleaseAsset(rc.Context, rc.Owner, rc.Repo, asset.GetID(), rc.HTTPClient)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s artifact: %w", asset.GetName(), err)
 	}
 
-	for _, file := range uploadFiles {
-		handle, err := os.Open(file)
+	defer body.Close()
+
+	handle, err := ioutil.TempFile(rc.TempDir, "download-")
+
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := io.Copy(handle, body); err != nil {
+		handle.Close()
+		os.Remove(handle.Name())
+		return nil, fmt.Errorf("failed to download %s artifact: %w", asset.GetName(), err)
+	}
+
+	if _, err := handle.Seek(0, io.SeekStart); err != nil {
+		handle.Close()
+		os.Remove(handle.Name())
+		return nil, err
+	}
 
+	return handle, nil
+}
+
+// prereleaseChain returns all published prereleases created since the last
+// stable release, oldest first.
+func (rc *releaseClient) prereleaseChain() ([]*github.RepositoryRelease, error) {
+	var (
+		prereleases []*github.RepositoryRelease
+		lastStable  *github.RepositoryRelease
+	)
+
+	listOpts := &github.ListOptions{PerPage: 100}
+
+	for {
+		releases, resp, err := rc.Client.Repositories.ListReleases(rc.Context, rc.Owner, rc.Repo, listOpts)
 		if err != nil {
-			return fmt.Errorf("failed to read %s artifact: %w", file, err)
+			return nil, fmt.Errorf("failed to list releases: %w", err)
 		}
 
-		for _, asset := range assets {
-			if *asset.Name == path.Base(file) {
-				if _, err := rc.Client.Repositories.DeleteReleaseAsset(rc.Context, rc.Owner, rc.Repo, *asset.ID); err != nil {
-					return fmt.Errorf("failed to delete %s artifact: %w", file, err)
-				}
+		for _, release := range releases {
+			if release.GetDraft() || release.GetTagName() == rc.Tag {
+				continue
+			}
 
-				fmt.Printf("Successfully deleted old %s artifact\n", *asset.Name)
+			if release.GetPrerelease() {
+				prereleases = append(prereleases, release)
+			} else if lastStable == nil || release.GetCreatedAt().After(lastStable.GetCreatedAt().Time) {
+				lastStable = release
 			}
 		}
 
-		uo := &github.UploadOptions{Name: path.Base(file)}
+		// stop iteration if there is no next page
+		if resp.NextPage == 0 {
+			break
+		}
+
+		listOpts.Page = resp.NextPage
+	}
 
-		if _, _, err = rc.Client.Repositories.UploadReleaseAsset(rc.Context, rc.Owner, rc.Repo, id, uo, handle); err != nil {
-			return fmt.Errorf("failed to upload %s artifact: %w", file, err)
+	sort.Slice(prereleases, func(i, j int) bool {
+		return prereleases[i].GetCreatedAt().Before(prereleases[j].GetCreatedAt().Time)
+	})
+
+	var result []*github.RepositoryRelease
+	for _, release := range prereleases {
+		if lastStable == nil || release.GetCreatedAt().After(lastStable.GetCreatedAt().Time) {
+			result = append(result, release)
 		}
+	}
+
+	return result, nil
+}
+
+// promoteAssets copies the assets of the final prerelease to the release. If
+// a local file with the same name is about to be uploaded, it has to be
+// identical to the prerelease asset and is dropped from the returned files.
+func (rc *releaseClient) promoteAssets(release *github.RepositoryRelease, files []string) ([]string, error) {
+	prereleases, err := rc.prereleaseChain()
 
-		fmt.Printf("Successfully uploaded %s artifact\n", file)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(prereleases) == 0 {
+		fmt.Fprintln(rc.Out, "No prerelease found to promote assets from")
+		return files, nil
+	}
+
+	source := prereleases[len(prereleases)-1]
+	fmt.Fprintf(rc.Out, "Promoting assets of prerelease %s\n", source.GetTagName())
+
+	local := make(map[string]string)
+	for _, file := range files {
+		local[path.Base(file)] = file
+	}
+
+	existing := make(map[string]bool)
+	for _, asset := range release.Assets {
+		existing[asset.GetName()] = true
+	}
+
+	for _, asset := range source.Assets {
+		if existing[asset.GetName()] {
+			fmt.Fprintf(rc.Out, "Skipping already promoted %s artifact\n", asset.GetName())
+			continue
+		}
+
+		handle, err := rc.downloadAsset(asset)
+
+		if err != nil {
+			return nil, err
+		}
+
+		hash, err := checksum(handle, "sha256")
+
+		if err == nil {
+			_, err = handle.Seek(0, io.SeekStart)
+		}
+
+		if err == nil {
+			if file, ok := local[asset.GetName()]; ok {
+				err = compareFileHash(file, hash)
+				delete(local, asset.GetName())
+			}
+		}
+
+		if err == nil {
+			uo := &github.UploadOptions{Name: asset.GetName()}
+
+			if _, _, err = rc.Client.Repositories.UploadReleaseAsset(rc.Context, rc.Owner, rc.Repo, release.GetID(), uo, handle); err != nil {
+				err = fmt.Errorf("failed to upload %s artifact: %w", asset.GetName(), err)
+			}
+		}
+
+		handle.Close()
+		os.Remove(handle.Name())
+
+		if err != nil {
+			return nil, err
+		}
+
+		fmt.Fprintf(rc.Out, "Successfully promoted %s artifact (sha256 %s)\n", asset.GetName(), hash)
+	}
+
+	var result []string
+	for _, file := range files {
+		if _, ok := local[path.Base(file)]; ok {
+			result = append(result, file)
+		}
+	}
+
+	return result, nil
+}
+
+func compareFileHash(file, expected string) error {
+	handle, err := os.Open(file)
+
+	if err != nil {
+		return fmt.Errorf("failed to read %s artifact: %w", file, err)
+	}
+
+	defer handle.Close()
+
+	hash, err := checksum(handle, "sha256")
+
+	if err != nil {
+		return err
+	}
+
+	if hash != expected {
+		return fmt.Errorf("artifact %s differs from the prerelease asset", file)
 	}
 
 	return nil
 }
+
+// preflight verifies that both the api and the upload endpoints are
+// reachable before anything gets modified.
+func (rc *releaseClient) preflight() error {
+	if _, _, err := rc.Client.Repositories.Get(rc.Context, rc.Owner, rc.Repo); err != nil {
+		return fmt.Errorf("failed to reach api endpoint %s: %w", rc.Client.BaseURL, err)
+	}
+
+	fmt.Fprintf(rc.Out, "Successfully reached api endpoint %s\n", rc.Client.BaseURL)
+
+	req, err := http.NewRequestWithContext(rc.Context, http.MethodHead, rc.Client.UploadURL.String(), nil)
+
+	if err != nil {
+		return err
+	}
+
+	// any response proves that the upload endpoint is reachable, the status
+	// depends on the proxy in front of it
+	resp, err := rc.Client.Client().Do(req)
+
+	if err != nil {
+		return fmt.Errorf("failed to reach upload endpoint %s: %w", rc.Client.UploadURL, err)
+	}
+
+	resp.Body.Close()
+
+	fmt.Fprintf(rc.Out, "Successfully reached upload endpoint %s\n", rc.Client.UploadURL)
+	return nil
+}
+
+// isResumable checks if the release is a draft staged by a previous run for
+// the same commit.
+func (rc *releaseClient) isResumable(release *github.RepositoryRelease) bool {
+	if !release.GetDraft() {
+		return false
+	}
+
+	metadata := readMetadata(release.GetBody())
+	return metadata != nil && metadata["commit"] == rc.Metadata["commit"]
+}
+
+// resumeRelease continues a draft staged by an interrupted run. Incomplete
+// uploads are removed, the remaining assets get verified before uploading.
+func (rc *releaseClient) resumeRelease(release *github.RepositoryRelease) (*github.RepositoryRelease, error) {
+	fmt.Fprintf(rc.Out, "Resuming draft %d for tag %s\n", release.GetID(), rc.Tag)
+
+	assets, err := rc.listAssets(release.GetID())
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, asset := range assets {
+		if asset.GetState() == "uploaded" {
+			continue
+		}
+
+		if _, err := rc.Client.Repositories.DeleteReleaseAsset(rc.Context, rc.Owner, rc.Repo, asset.GetID()); err != nil {
+			return nil, fmt.Errorf("failed to delete incomplete %s artifact: %w", asset.GetName(), err)
+		}
+
+		fmt.Fprintf(rc.Out, "Successfully deleted incomplete %s artifact\n", asset.GetName())
+	}
+
+	rc.resumed = true
+	return release, nil
+}
+
+// verifyAsset compares the content of an uploaded asset with the file.
+func (rc *releaseClient) verifyAsset(pa plannedAsset) (bool, error) {
+	handle, err := rc.downloadAsset(&github.ReleaseAsset{ID: &pa.AssetID, Name: &pa.Name})
+
+	if err != nil {
+		return false, err
+	}
+
+	defer os.Remove(handle.Name())
+	defer handle.Close()
+
+	hash, err := checksum(handle, "sha256")
+
+	if err != nil {
+		return false, err
+	}
+
+	return compareFileHash(pa.File, hash) == nil, nil
+}
+
+// publishRelease publishes a release staged as draft.
+func (rc *releaseClient) publishRelease(release *github.RepositoryRelease) (*github.RepositoryRelease, error) {
+	if rc.Draft || !release.GetDraft() {
+		return release, nil
+	}
+
+	fmt.Fprintln(rc.Out, "Publishing a release draft")
+
+	modifiedRelease, _, err := rc.Client.Repositories.EditRelease(rc.Context, rc.Owner, rc.Repo, release.GetID(), &github.RepositoryRelease{Draft: github.Bool(false)})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to publish release: %w", err)
+	}
+
+	fmt.Fprintf(rc.Out, "Successfully published %s release\n", rc.Tag)
+	return modifiedRelease, nil
+}
+
+// writeRelease fetches the final state of the release, including every
+// uploaded asset, and writes it as json.
+func (rc *releaseClient) writeRelease(id int64, file string) error {
+	release, _, err := rc.Client.Repositories.GetRelease(rc.Context, rc.Owner, rc.Repo, id)
+
+	if err != nil {
+		return fmt.Errorf("failed to get release %d: %w", id, err)
+	}
+
+	assets, err := rc.listAssets(id)
+
+	if err != nil {
+		return err
+	}
+
+	release.Assets = assets
+	return writeJSON(rc.Out, file, release)
+}
@@ -0,0 +1,178 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v44/github"
+)
+
+func TestProtectDownloaded(t *testing.T) {
+	var deleted []string
+
+	rc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/octocat/hello/releases/1/assets":
+			fmt.Fprint(w, `[{"id": 7, "name": "app", "download_count": 0}, {"id": 8, "name": "lib", "download_count": 3}]`)
+		case r.Method == http.MethodDelete:
+			deleted = append(deleted, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	rc.ProtectDownloaded = true
+	rc.FileExists = "overwrite"
+
+	dir := t.TempDir()
+	files := []string{filepath.Join(dir, "app"), filepath.Join(dir, "lib")}
+
+	for _, file := range files {
+		if err := ioutil.WriteFile(file, []byte("content"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := rc.uploadFiles(1, files)
+
+	if err == nil || !strings.Contains(err.Error(), "downloaded 3 times") {
+		t.Errorf("Unexpected error for a downloaded asset (Got: %v)", err)
+	}
+
+	if len(deleted) != 0 {
+		t.Errorf("Unexpected deletions before the check (Got: %v)", deleted)
+	}
+}
+
+// newTestClient returns a release client for the octocat/hello repository
+// talking to a fake github api.
+func newTestClient(t *testing.T, handler http.HandlerFunc) *releaseClient {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client := github.NewClient(server.Client())
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	client.UploadURL, _ = url.Parse(server.URL + "/")
+
+	return &releaseClient{Client: client, HTTPClient: server.Client(), Context: context.Background(), Owner: "octocat", Repo: "hello", Out: ioutil.Discard}
+}
+
+func TestWriteRelease(t *testing.T) {
+	rc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/octocat/hello/releases/1":
+			fmt.Fprint(w, `{"id": 1, "tag_name": "v1.0.0"}`)
+		case "/repos/octocat/hello/releases/1/assets":
+			fmt.Fprint(w, `[{"id": 2, "name": "app", "browser_download_url": "https://example.com/app"}]`)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	file := filepath.Join(t.TempDir(), "release.json")
+
+	if err := rc.writeRelease(1, file); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	b, _ := ioutil.ReadFile(file)
+	release := github.RepositoryRelease{}
+
+	if err := json.Unmarshal(b, &release); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if release.GetTagName() != "v1.0.0" || len(release.Assets) != 1 || release.Assets[0].GetBrowserDownloadURL() != "https://example.com/app" {
+		t.Errorf("Unexpected release (Got: %s)", b)
+	}
+}
+
+func TestDownloadAsset(t *testing.T) {
+	var redirected []string
+
+	rc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/octocat/hello/releases/assets/3":
+			http.Redirect(w, r, "/downloads/app", http.StatusFound)
+		case "/downloads/app":
+			redirected = append(redirected, r.Header.Get("User-Agent"))
+			fmt.Fprint(w, "content")
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	rc.HTTPClient = &http.Client{Transport: &userAgentTransport{base: rc.HTTPClient.Transport}}
+	rc.TempDir = t.TempDir()
+
+	handle, err := rc.downloadAsset(&github.ReleaseAsset{ID: github.Int64(3), Name: github.String("app")})
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	defer handle.Close()
+
+	if content, _ := ioutil.ReadAll(handle); string(content) != "content" {
+		t.Errorf("Unexpected content (Got: %s, Expected: %s)", content, "content")
+	}
+
+	if len(redirected) != 1 || redirected[0] != "test" {
+		t.Errorf("Unexpected download client (Got: %v)", redirected)
+	}
+}
+
+type userAgentTransport struct {
+	base http.RoundTripper
+}
+
+func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req.Header.Set("User-Agent", "test")
+	return t.base.RoundTrip(req)
+}
+
+func TestConsolidateForeignDrafts(t *testing.T) {
+	var modified []string
+
+	rc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			modified = append(modified, r.Method+" "+r.URL.Path)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/repos/octocat/hello/releases":
+			fmt.Fprintf(w, `[
+				{"id": 1, "tag_name": "v1.0.0", "draft": true, "body": "manual"},
+				{"id": 2, "tag_name": "v1.0.0", "draft": true, "body": %q, "assets": [{"id": 3, "name": "app"}]}
+			]`, writeMetadata("", map[string]string{"build": "1"}))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	rc.Tag = "v1.0.0"
+	rc.OnlyManageOwn = true
+
+	if err := rc.consolidateDrafts(); err == nil || !strings.Contains(err.Error(), "release 1") {
+		t.Errorf("Unexpected error for a foreign draft (Got: %v)", err)
+	}
+
+	if len(modified) != 0 {
+		t.Errorf("Unexpected modifications (Got: %v)", modified)
+	}
+}
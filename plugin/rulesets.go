@@ -0,0 +1,173 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// ruleset is the part of a repository ruleset relevant for tags, which is
+// missing from go-github.
+type ruleset struct {
+	ID          int64  `json:"id"`
+	Name        string `json:"name"`
+	Target      string `json:"target"`
+	Enforcement string `json:"enforcement"`
+	Bypass      string `json:"current_user_can_bypass"`
+	Conditions  struct {
+		RefName struct {
+			Include []string `json:"include"`
+			Exclude []string `json:"exclude"`
+		} `json:"ref_name"`
+	} `json:"conditions"`
+	Rules []struct {
+		Type string `json:"type"`
+	} `json:"rules"`
+}
+
+// refMatches matches a ruleset ref pattern, where "**" spans slashes.
+func refMatches(pattern, ref string) bool {
+	if pattern == "~ALL" {
+		return true
+	}
+
+	if strings.Contains(pattern, "**") {
+		return matchSegments(strings.Split(pattern, "/"), strings.Split(ref, "/"))
+	}
+
+	ok, _ := path.Match(pattern, ref)
+	return ok
+}
+
+// blockedBy returns the rule types of the ruleset blocking the operations
+// on the tag.
+func (r ruleset) blockedBy(tag string, operations []string) []string {
+	if r.Target != "tag" || r.Enforcement != "active" || r.Bypass == "always" {
+		return nil
+	}
+
+	ref := "refs/tags/" + tag
+	included := false
+
+	for _, pattern := range r.Conditions.RefName.Include {
+		included = included || refMatches(pattern, ref)
+	}
+
+	for _, pattern := range r.Conditions.RefName.Exclude {
+		included = included && !refMatches(pattern, ref)
+	}
+
+	if !included {
+		return nil
+	}
+
+	var blocked []string
+
+	for _, rule := range r.Rules {
+		for _, operation := range operations {
+			if rule.Type == operation {
+				blocked = append(blocked, operation)
+			}
+		}
+	}
+
+	return blocked
+}
+
+// checkRulesets verifies that the token may create releases and that no
+// ruleset or tag protection blocks the given operations on the tags.
+// Servers without rulesets or tag protection skip those checks.
+func (rc *releaseClient) checkRulesets(tags map[string][]string) error {
+	repo, _, err := rc.Client.Repositories.Get(rc.Context, rc.Owner, rc.Repo)
+
+	if err != nil {
+		return fmt.Errorf("failed to retrieve repository: %w", err)
+	}
+
+	if permissions := repo.GetPermissions(); permissions != nil && !permissions["push"] {
+		return fmt.Errorf("token has no push access to %s, which is required to create releases", repo.GetFullName())
+	}
+
+	if len(tags) == 0 {
+		return nil
+	}
+
+	req, err := rc.Client.NewRequest(http.MethodGet, fmt.Sprintf("repos/%s/%s/rulesets?includes_parents=true&per_page=100", rc.Owner, rc.Repo), nil)
+
+	if err != nil {
+		return err
+	}
+
+	var summaries []ruleset
+	resp, err := rc.Client.Do(rc.Context, req, &summaries)
+
+	if err != nil && (resp == nil || resp.StatusCode != http.StatusNotFound) {
+		return fmt.Errorf("failed to list rulesets: %w", err)
+	}
+
+	for _, summary := range summaries {
+		if summary.Target != "tag" || summary.Enforcement != "active" {
+			continue
+		}
+
+		// the list omits conditions, rules and the bypass of the token
+		req, err := rc.Client.NewRequest(http.MethodGet, fmt.Sprintf("repos/%s/%s/rulesets/%d", rc.Owner, rc.Repo, summary.ID), nil)
+
+		if err != nil {
+			return err
+		}
+
+		var detail ruleset
+
+		if _, err := rc.Client.Do(rc.Context, req, &detail); err != nil {
+			return fmt.Errorf("failed to get ruleset %s: %w", summary.Name, err)
+		}
+
+		for tag, operations := range tags {
+			if blocked := detail.blockedBy(tag, operations); len(blocked) > 0 {
+				return fmt.Errorf("ruleset %q blocks %s of tag %s for this token", detail.Name, strings.Join(blocked, " and "), tag)
+			}
+		}
+	}
+
+	return rc.checkTagProtection(repo.GetPermissions(), tags)
+}
+
+// checkTagProtection checks the legacy tag protection, which only allows
+// admins and maintainers to create or update matching tags.
+func (rc *releaseClient) checkTagProtection(permissions map[string]bool, tags map[string][]string) error {
+	if permissions["admin"] || permissions["maintain"] {
+		return nil
+	}
+
+	req, err := rc.Client.NewRequest(http.MethodGet, fmt.Sprintf("repos/%s/%s/tags/protection", rc.Owner, rc.Repo), nil)
+
+	if err != nil {
+		return err
+	}
+
+	var protections []struct {
+		Pattern string `json:"pattern"`
+	}
+
+	// listing requires admin access, so failures just skip the check
+	if _, err := rc.Client.Do(rc.Context, req, &protections); err != nil {
+		return nil
+	}
+
+	for tag := range tags {
+		for _, protection := range protections {
+			if ok, _ := path.Match(protection.Pattern, tag); ok {
+				return fmt.Errorf("tag %s is protected by %s, which requires admin or maintain access", tag, protection.Pattern)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,53 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestRulesetBlockedBy(t *testing.T) {
+	var r ruleset
+
+	err := json.Unmarshal([]byte(`{
+		"name": "release tags",
+		"target": "tag",
+		"enforcement": "active",
+		"current_user_can_bypass": "never",
+		"conditions": {"ref_name": {"include": ["refs/tags/v*", "refs/tags/latest"], "exclude": ["refs/tags/v0.*"]}},
+		"rules": [{"type": "creation"}, {"type": "update"}]
+	}`), &r)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		tag        string
+		operations []string
+		expected   string
+	}{
+		{"v1.0.0", []string{"creation"}, "creation"},
+		{"latest", []string{"creation", "update"}, "creation,update"},
+		{"v0.1.0", []string{"creation"}, ""},
+		{"nightly", []string{"update"}, ""},
+		{"v1.0.0", []string{"deletion"}, ""},
+	}
+
+	for _, test := range tests {
+		if actual := strings.Join(r.blockedBy(test.tag, test.operations), ","); actual != test.expected {
+			t.Errorf("Unexpected blocked operations for %s (Got: %s, Expected: %s)", test.tag, actual, test.expected)
+		}
+	}
+
+	r.Bypass = "always"
+
+	if blocked := r.blockedBy("v1.0.0", []string{"creation"}); len(blocked) != 0 {
+		t.Errorf("Unexpected blocked operations with bypass (Got: %v, Expected: none)", blocked)
+	}
+}
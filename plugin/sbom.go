@@ -0,0 +1,112 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// sbomFormat detects whether the content is a SPDX or CycloneDX document,
+// returning an empty string for unknown formats.
+func sbomFormat(content []byte) string {
+	var doc struct {
+		SPDXVersion string `json:"spdxVersion"`
+		BOMFormat   string `json:"bomFormat"`
+	}
+
+	if err := json.Unmarshal(content, &doc); err == nil {
+		switch {
+		case doc.SPDXVersion != "":
+			return "spdx"
+		case doc.BOMFormat == "CycloneDX":
+			return "cyclonedx"
+		}
+
+		return ""
+	}
+
+	text := string(content)
+
+	switch {
+	case strings.Contains(text, "SPDXVersion:"):
+		return "spdx"
+	case strings.Contains(text, "cyclonedx.org/schema/bom"):
+		return "cyclonedx"
+	}
+
+	return ""
+}
+
+// checkSBOM verifies that the file is a SPDX or CycloneDX document.
+func checkSBOM(out io.Writer, file string) error {
+	content, err := ioutil.ReadFile(file)
+
+	if err != nil {
+		return err
+	}
+
+	format := sbomFormat(content)
+
+	if format == "" {
+		return fmt.Errorf("%s is neither a SPDX nor a CycloneDX document", file)
+	}
+
+	fmt.Fprintf(out, "Found %s sbom %s\n", format, file)
+	return nil
+}
+
+// generateSBOM runs the command in the working directory and writes its
+// output to the directory, named by project and format.
+func generateSBOM(out io.Writer, command, project, dir string) (string, error) {
+	args := strings.Fields(command)
+
+	if len(args) == 0 {
+		return "", errors.New("empty sbom command")
+	}
+
+	output, err := exec.Command(args[0], args[1:]...).Output()
+
+	if err != nil {
+		var exitErr *exec.ExitError
+
+		if errors.As(err, &exitErr) {
+			return "", fmt.Errorf("%w: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+
+		return "", err
+	}
+
+	var name string
+
+	switch sbomFormat(output) {
+	case "spdx":
+		name = project + ".spdx"
+	case "cyclonedx":
+		name = project + ".cdx"
+	default:
+		return "", errors.New("sbom command wrote neither a SPDX nor a CycloneDX document")
+	}
+
+	if json.Valid(output) {
+		name += ".json"
+	}
+
+	file := filepath.Join(dir, name)
+
+	if err := ioutil.WriteFile(file, output, 0644); err != nil {
+		return "", err
+	}
+
+	fmt.Fprintf(out, "Successfully generated sbom %s\n", name)
+	return file, nil
+}
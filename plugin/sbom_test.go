@@ -0,0 +1,26 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"testing"
+)
+
+func TestSBOMFormat(t *testing.T) {
+	tests := map[string]string{
+		`{"spdxVersion": "SPDX-2.3", "name": "app"}`:                    "spdx",
+		`{"bomFormat": "CycloneDX", "specVersion": "1.4"}`:              "cyclonedx",
+		"SPDXVersion: SPDX-2.3\nDataLicense: CC0-1.0\n":                 "spdx",
+		`<bom xmlns="http://cyclonedx.org/schema/bom/1.4" version="1">`: "cyclonedx",
+		`{"name": "app"}`: "",
+	}
+
+	for content, expected := range tests {
+		if actual := sbomFormat([]byte(content)); actual != expected {
+			t.Errorf("Unexpected format for %s (Got: %s, Expected: %s)", content, actual, expected)
+		}
+	}
+}
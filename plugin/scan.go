@@ -0,0 +1,62 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// scanFiles runs the scanner command for every file and writes the combined
+// output to the report. Following the clamscan convention, exit code 1
+// means a detection while any other non-zero code is a scanner failure.
+func scanFiles(out io.Writer, command string, files []string, report string) error {
+	args := strings.Fields(command)
+
+	if len(args) == 0 {
+		return errors.New("empty scan command")
+	}
+
+	var (
+		buf      bytes.Buffer
+		detected []string
+	)
+
+	for _, file := range files {
+		cmd := exec.Command(args[0], append(args[1:], file)...)
+		output, err := cmd.CombinedOutput()
+
+		fmt.Fprintf(&buf, "==> %s\n%s\n", filepath.Base(file), strings.TrimSpace(string(output)))
+
+		var exitErr *exec.ExitError
+
+		switch {
+		case err == nil:
+			fmt.Fprintf(out, "Scanned %s artifact, no detection\n", file)
+		case errors.As(err, &exitErr) && exitErr.ExitCode() == 1:
+			fmt.Fprintf(out, "Scanned %s artifact, detection found\n", file)
+			detected = append(detected, file)
+		default:
+			return fmt.Errorf("failed to scan %s: %w: %s", file, err, strings.TrimSpace(string(output)))
+		}
+	}
+
+	if err := ioutil.WriteFile(report, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+
+	if len(detected) > 0 {
+		return fmt.Errorf("scanner detected issues in %s", strings.Join(detected, ", "))
+	}
+
+	return nil
+}
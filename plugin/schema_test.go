@@ -0,0 +1,85 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v44/github"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+func validateSchema(name string, v interface{}) error {
+	schema, err := jsonschema.Compile(filepath.Join("..", "schema", fmt.Sprintf("%s.v%d.json", name, resultSchemaVersion)))
+
+	if err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(v)
+
+	if err != nil {
+		return err
+	}
+
+	var doc interface{}
+
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return err
+	}
+
+	return schema.Validate(doc)
+}
+
+func TestPlanSchema(t *testing.T) {
+	rc := releaseClient{Tag: "v1.0.0", Title: "v1.0.0", Draft: true}
+	assets := []*github.ReleaseAsset{{ID: github.Int64(7), Name: github.String("app")}}
+
+	planned, err := planAssets([]string{"dist/app", "dist/app.exe"}, assets, "overwrite")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plan := releasePlan{
+		SchemaVersion: resultSchemaVersion,
+		Tag:           rc.Tag,
+		Action:        "create",
+		Payload:       rc.createPayload(),
+		Assets:        planned,
+	}
+
+	if err := validateSchema("plan", plan); err != nil {
+		t.Errorf("Unexpected invalid plan (Got: %s)", err)
+	}
+
+	plan.Action = "delete"
+
+	if err := validateSchema("plan", plan); err == nil || !strings.Contains(err.Error(), "action") {
+		t.Errorf("Unexpected validation of an unknown action (Got: %v)", err)
+	}
+}
+
+func TestCompareSchema(t *testing.T) {
+	report := compareReport{
+		SchemaVersion: resultSchemaVersion,
+		Base:          "v1.0.0",
+		Head:          "v1.1.0",
+		URL:           "https://github.com/octocat/hello/compare/v1.0.0...v1.1.0",
+		Commits:       []comparedCommit{{SHA: "abc123", Message: "Fix", Author: "octocat"}},
+		PullRequests:  []int{12},
+		Contributors:  []string{"octocat"},
+		Assets:        assetDiff{Added: []string{"app.exe"}},
+	}
+
+	if err := validateSchema("compare", report); err != nil {
+		t.Errorf("Unexpected invalid report (Got: %s)", err)
+	}
+}
@@ -0,0 +1,216 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// maxSecretScanSize limits the assets scanned for secrets, larger files
+// are binaries or archives rather than generated metadata.
+const maxSecretScanSize = 1 << 20
+
+var (
+	secretPatterns = map[string]*regexp.Regexp{
+		"github token":      regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`),
+		"aws access key":    regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`),
+		"slack token":       regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}\b`),
+		"google api key":    regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`),
+		"private key":       regexp.MustCompile(`-----BEGIN (?:[A-Z]+ )?PRIVATE KEY-----`),
+		"basic auth in url": regexp.MustCompile(`[a-z][a-z0-9+.-]*://[^/\s:@]+:[^/\s:@]+@`),
+	}
+)
+
+// findSecrets returns the names of the secret patterns matching the text.
+func findSecrets(text string) []string {
+	var found []string
+
+	for name, pattern := range secretPatterns {
+		if pattern.MatchString(text) {
+			found = append(found, name)
+		}
+	}
+
+	sort.Strings(found)
+	return found
+}
+
+// scanSecrets checks the title, notes, metadata and the small text assets
+// for credentials which should never be published.
+func scanSecrets(title, note string, metadata map[string]string, files []string) error {
+	sources := map[string]string{
+		"title": title,
+		"notes": note,
+	}
+
+	for key, value := range metadata {
+		sources["metadata "+key] = value
+	}
+
+	for _, file := range files {
+		info, err := os.Stat(file)
+
+		if err != nil || info.Size() > maxSecretScanSize {
+			continue
+		}
+
+		content, err := ioutil.ReadFile(file)
+
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", file, err)
+		}
+
+		// skip binaries
+		if bytes.IndexByte(content, 0) >= 0 {
+			continue
+		}
+
+		sources["asset "+filepath.Base(file)] = string(content)
+	}
+
+	var matches []string
+
+	for source, text := range sources {
+		for _, name := range findSecrets(text) {
+			matches = append(matches, fmt.Sprintf("%s in %s", name, source))
+		}
+	}
+
+	if len(matches) > 0 {
+		sort.Strings(matches)
+		return fmt.Errorf("possible secrets found: %s", strings.Join(matches, ", "))
+	}
+
+	return nil
+}
+
+// urlTokenParam matches credentials passed in url query parameters, like
+// signed download urls.
+var urlTokenParam = regexp.MustCompile(`(?i)([?&](?:access_token|token|client_secret|x-amz-signature|x-amz-credential|sig)=)[^&\s"']+`)
+
+// scrubber removes known secret values and url query tokens from output.
+type scrubber struct {
+	secrets []string
+}
+
+// newScrubber ignores empty and very short values, which would garble the
+// output without protecting anything.
+func newScrubber(secrets ...string) *scrubber {
+	s := &scrubber{}
+
+	for _, secret := range secrets {
+		if len(secret) >= 4 {
+			s.secrets = append(s.secrets, secret)
+		}
+	}
+
+	return s
+}
+
+// credentialParam matches the names of query parameters carrying
+// credentials.
+var credentialParam = regexp.MustCompile(`(?i)token|key|secret|sig|pass|auth|credential|code`)
+
+// webhookSecrets returns the urls of webhooks, like the ones of Slack, which
+// carry their secret, along with the credentials in their userinfo and query.
+// Paths on their own are left alone, they would match unrelated output.
+func webhookSecrets(urls ...string) []string {
+	var secrets []string
+
+	for _, raw := range urls {
+		if raw == "" {
+			continue
+		}
+
+		secrets = append(secrets, raw)
+		u, err := url.Parse(raw)
+
+		if err != nil {
+			continue
+		}
+
+		if password, ok := u.User.Password(); ok {
+			secrets = append(secrets, password)
+		}
+
+		for name, values := range u.Query() {
+			if credentialParam.MatchString(name) {
+				secrets = append(secrets, values...)
+			}
+		}
+	}
+
+	return secrets
+}
+
+func (s *scrubber) scrub(text string) string {
+	if s == nil {
+		return text
+	}
+
+	for _, secret := range s.secrets {
+		text = strings.ReplaceAll(text, secret, "[secret]")
+	}
+
+	return urlTokenParam.ReplaceAllString(text, "${1}[secret]")
+}
+
+// scrubbedError keeps the wrapped error, but hides secrets in its message.
+type scrubbedError struct {
+	err error
+	msg string
+}
+
+func (e *scrubbedError) Error() string {
+	return e.msg
+}
+
+func (e *scrubbedError) Unwrap() error {
+	return e.err
+}
+
+func (s *scrubber) scrubError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if msg := s.scrub(err.Error()); msg != err.Error() {
+		return &scrubbedError{err: err, msg: msg}
+	}
+
+	return err
+}
+
+// scrubHook removes secrets from logrus entries, like the debug logs.
+type scrubHook struct {
+	scrubber *scrubber
+}
+
+func (h *scrubHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *scrubHook) Fire(entry *logrus.Entry) error {
+	entry.Message = h.scrubber.scrub(entry.Message)
+
+	for key, value := range entry.Data {
+		if text, ok := value.(string); ok {
+			entry.Data[key] = h.scrubber.scrub(text)
+		}
+	}
+
+	return nil
+}
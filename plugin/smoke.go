@@ -0,0 +1,71 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v44/github"
+)
+
+// smokeTest sends a HEAD request to the download URL of every asset and
+// checks the status and size. Failing assets are retried to give the CDN
+// time to propagate the new files.
+func smokeTest(out io.Writer, client *http.Client, assets []*github.ReleaseAsset, retries int, delay time.Duration) error {
+	var failed []string
+
+	for _, asset := range assets {
+		var err error
+
+		for attempt := 0; attempt <= retries; attempt++ {
+			if attempt > 0 {
+				time.Sleep(delay)
+			}
+
+			if err = checkAssetURL(client, asset); err == nil {
+				break
+			}
+		}
+
+		if err != nil {
+			fmt.Fprintf(out, "Warning: smoke test failed for %s artifact: %s\n", asset.GetName(), err)
+			failed = append(failed, asset.GetName())
+			continue
+		}
+
+		fmt.Fprintf(out, "Smoke test passed for %s artifact\n", asset.GetName())
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("assets not available: %s", strings.Join(failed, ", "))
+	}
+
+	return nil
+}
+
+func checkAssetURL(client *http.Client, asset *github.ReleaseAsset) error {
+	resp, err := client.Head(asset.GetBrowserDownloadURL())
+
+	if err != nil {
+		return err
+	}
+
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	if resp.ContentLength >= 0 && resp.ContentLength != int64(asset.GetSize()) {
+		return fmt.Errorf("unexpected size %d, expected %d", resp.ContentLength, asset.GetSize())
+	}
+
+	return nil
+}
@@ -0,0 +1,48 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-github/v44/github"
+)
+
+func TestSmokeTest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/redirect":
+			http.Redirect(w, r, "/app", http.StatusFound)
+		case "/app":
+			w.Header().Set("Content-Length", "4")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	ok := []*github.ReleaseAsset{
+		{Name: github.String("app"), Size: github.Int(4), BrowserDownloadURL: github.String(server.URL + "/redirect")},
+	}
+
+	if err := smokeTest(ioutil.Discard, server.Client(), ok, 0, 0); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+
+	tests := []*github.ReleaseAsset{
+		{Name: github.String("size"), Size: github.Int(8), BrowserDownloadURL: github.String(server.URL + "/app")},
+		{Name: github.String("missing"), Size: github.Int(4), BrowserDownloadURL: github.String(server.URL + "/missing")},
+	}
+
+	for _, asset := range tests {
+		if err := smokeTest(ioutil.Discard, server.Client(), []*github.ReleaseAsset{asset}, 1, 0); err == nil {
+			t.Errorf("Expected an error for %s", asset.GetName())
+		}
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// sshSigner creates ssh signatures with ssh-keygen -Y sign, verifying them
+// against the allowed signers if given.
+type sshSigner struct {
+	Key            string
+	Namespace      string
+	AllowedSigners string
+}
+
+func (s *sshSigner) run(stdin io.Reader, args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.Command("ssh-keygen", args...)
+	cmd.Stdin = stdin
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// sign returns the armored signature of the file.
+func (s *sshSigner) sign(file string) ([]byte, error) {
+	handle, err := os.Open(file)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer handle.Close()
+
+	return s.run(handle, "-Y", "sign", "-f", s.Key, "-n", s.Namespace)
+}
+
+// verify checks the signature of the file against the allowed signers.
+func (s *sshSigner) verify(file, signature string) error {
+	out, err := s.run(nil, "-Y", "find-principals", "-f", s.AllowedSigners, "-s", signature)
+
+	if err != nil {
+		return fmt.Errorf("signing key is not an allowed signer: %w", err)
+	}
+
+	principal := strings.TrimSpace(strings.SplitN(string(out), "\n", 2)[0])
+	handle, err := os.Open(file)
+
+	if err != nil {
+		return err
+	}
+
+	defer handle.Close()
+
+	_, err = s.run(handle, "-Y", "verify", "-f", s.AllowedSigners, "-I", principal, "-n", s.Namespace, "-s", signature)
+	return err
+}
+
+// signFiles writes a <name>.sshsig signature for every file into the
+// directory, apart from the <name>.sig signatures of cosign.
+func (s *sshSigner) signFiles(out io.Writer, files []string, dir string) ([]string, error) {
+	var signatures []string
+
+	for _, file := range files {
+		content, err := s.sign(file)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign %s: %w", file, err)
+		}
+
+		signature := filepath.Join(dir, filepath.Base(file)+".sshsig")
+
+		if err := ioutil.WriteFile(signature, content, 0644); err != nil {
+			return nil, err
+		}
+
+		if s.AllowedSigners != "" {
+			if err := s.verify(file, signature); err != nil {
+				return nil, fmt.Errorf("failed to verify signature of %s: %w", file, err)
+			}
+		}
+
+		fmt.Fprintf(out, "Successfully signed %s with ssh\n", file)
+		signatures = append(signatures, signature)
+	}
+
+	return signatures, nil
+}
+
+// newSSHSigner writes the key and the allowed signers into the run
+// directory, as ssh-keygen only reads them from files.
+func (p *Plugin) newSSHSigner() (*sshSigner, error) {
+	key, err := readStringOrFile(p.settings.SSHKey)
+
+	if err != nil {
+		return nil, fmt.Errorf("error while reading ssh key: %w", err)
+	}
+
+	dir, err := p.runSubDir("ssh")
+
+	if err != nil {
+		return nil, err
+	}
+
+	s := &sshSigner{
+		Key:       filepath.Join(dir, "id"),
+		Namespace: p.settings.SSHNamespace,
+	}
+
+	// ssh-keygen refuses keys readable by others
+	if err := ioutil.WriteFile(s.Key, []byte(strings.TrimSpace(key)+"\n"), 0600); err != nil {
+		return nil, err
+	}
+
+	if p.settings.SSHAllowedSigners != "" {
+		signers, err := readStringOrFile(p.settings.SSHAllowedSigners)
+
+		if err != nil {
+			return nil, fmt.Errorf("error while reading ssh allowed signers: %w", err)
+		}
+
+		s.AllowedSigners = filepath.Join(dir, "allowed_signers")
+
+		if err := ioutil.WriteFile(s.AllowedSigners, []byte(signers), 0644); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
@@ -0,0 +1,72 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSSHSigner(t *testing.T) {
+	if _, err := exec.LookPath("ssh-keygen"); err != nil {
+		t.Skip("ssh-keygen is not installed")
+	}
+
+	dir := t.TempDir()
+	key := filepath.Join(dir, "id")
+
+	if out, err := exec.Command("ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-C", "release@example.com", "-f", key).CombinedOutput(); err != nil {
+		t.Skipf("failed to generate an ssh key: %s", out)
+	}
+
+	public, err := ioutil.ReadFile(key + ".pub")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signers := filepath.Join(dir, "allowed_signers")
+
+	if err := ioutil.WriteFile(signers, []byte("release@example.com "+string(public)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	file := filepath.Join(dir, "checksums.txt")
+
+	if err := ioutil.WriteFile(file, []byte("abc  app\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := sshSigner{Key: key, Namespace: "file", AllowedSigners: signers}
+	signatures, err := s.signFiles(ioutil.Discard, []string{file}, dir)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := file + ".sshsig"; signatures[0] != expected {
+		t.Errorf("Unexpected signature file (Got: %s, Expected: %s)", signatures[0], expected)
+	}
+
+	content, err := ioutil.ReadFile(signatures[0])
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(string(content), "-----BEGIN SSH SIGNATURE-----") {
+		t.Errorf("Unexpected signature (Got: %s)", content)
+	}
+
+	s.Namespace = "git"
+
+	if err := s.verify(file, signatures[0]); err == nil {
+		t.Error("Expected an error for a different namespace")
+	}
+}
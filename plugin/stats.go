@@ -0,0 +1,125 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// runStats collects the api statistics of a run, which are appended to a
+// file or posted to an endpoint to track the flakiness over many runs.
+type runStats struct {
+	mu sync.Mutex
+
+	Repo          string        `json:"repo"`
+	Tag           string        `json:"tag"`
+	Build         int           `json:"build"`
+	Started       time.Time     `json:"started"`
+	Duration      time.Duration `json:"duration"`
+	Requests      int           `json:"requests"`
+	Uploads       int           `json:"uploads"`
+	NetworkErrors int           `json:"network_errors"`
+	ServerErrors  int           `json:"server_errors"`
+	RateLimited   int           `json:"rate_limited"`
+	TagLatency    time.Duration `json:"tag_latency,omitempty"`
+	Success       bool          `json:"success"`
+	Error         string        `json:"error,omitempty"`
+}
+
+// statsTransport counts the requests and transient failures.
+type statsTransport struct {
+	base  http.RoundTripper
+	stats *runStats
+}
+
+func (t *statsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+
+	t.stats.mu.Lock()
+	defer t.stats.mu.Unlock()
+
+	t.stats.Requests++
+
+	if req.Method == http.MethodPost && req.URL.Query().Get("name") != "" {
+		t.stats.Uploads++
+	}
+
+	switch {
+	case err != nil:
+		t.stats.NetworkErrors++
+	case resp.StatusCode == http.StatusTooManyRequests,
+		resp.StatusCode == http.StatusForbidden && resp.Header.Get("X-RateLimit-Remaining") == "0":
+		t.stats.RateLimited++
+	case resp.StatusCode >= 500:
+		t.stats.ServerErrors++
+	}
+
+	return resp, err
+}
+
+// finish records the outcome of the run.
+func (s *runStats) finish(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.Duration = time.Since(s.Started)
+	s.Success = err == nil
+
+	if err != nil {
+		s.Error = err.Error()
+	}
+}
+
+// appendStats appends the statistics as a JSON line to the file.
+func appendStats(file string, s *runStats) error {
+	b, err := json.Marshal(s)
+
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// postJSON sends the value as JSON to the endpoint.
+func postJSON(client *http.Client, endpoint string, v interface{}) error {
+	b, err := json.Marshal(v)
+
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Post(endpoint, "application/json", bytes.NewReader(b))
+
+	if err != nil {
+		return err
+	}
+
+	resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	return nil
+}
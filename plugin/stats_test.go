@@ -0,0 +1,48 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStatsTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/limited":
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.WriteHeader(http.StatusForbidden)
+		case "/broken":
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+
+	stats := &runStats{}
+	client := &http.Client{Transport: &statsTransport{base: http.DefaultTransport, stats: stats}}
+
+	for _, path := range []string{"/ok", "/limited", "/broken", "/upload?name=app"} {
+		method := http.MethodGet
+		if path == "/upload?name=app" {
+			method = http.MethodPost
+		}
+
+		req, _ := http.NewRequest(method, server.URL+path, nil)
+		resp, err := client.Do(req)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resp.Body.Close()
+	}
+
+	if stats.Requests != 4 || stats.Uploads != 1 || stats.RateLimited != 1 || stats.ServerErrors != 1 {
+		t.Errorf("Unexpected statistics (Got: %+v)", stats)
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v44/github"
+)
+
+// streamSeparator separates the source of a streamed asset from its name,
+// e.g. -:name=coverage.tar.gz reads the asset from stdin.
+const streamSeparator = ":name="
+
+// streamDisallowed lists the settings processing files on disk, which would
+// skip streamed assets as they are kept in memory.
+var streamDisallowed = []struct {
+	name string
+	set  func(s *Settings) bool
+}{
+	{"checksum", func(s *Settings) bool { return len(s.Checksum.Value()) > 0 }},
+	{"checksum_combined", func(s *Settings) bool { return s.ChecksumCombined != "" }},
+	{"checksum_sidecar", func(s *Settings) bool { return s.ChecksumSidecar }},
+	{"verify_files", func(s *Settings) bool { return s.VerifyFiles != "" }},
+	{"gpg_key", func(s *Settings) bool { return s.GPGKey != "" }},
+	{"minisign_key", func(s *Settings) bool { return s.MinisignKey != "" }},
+	{"ssh_key", func(s *Settings) bool { return s.SSHKey != "" }},
+	{"cosign", func(s *Settings) bool { return s.Cosign != "" }},
+	{"encrypt", func(s *Settings) bool { return s.Encrypt != "" }},
+}
+
+// parseStream splits a files entry reading from stdin or a named pipe into
+// its source and name.
+func parseStream(entry string) (string, string, bool) {
+	source, name, ok := strings.Cut(entry, streamSeparator)
+
+	if !ok || source == "" || name == "" {
+		return "", "", false
+	}
+
+	return source, name, true
+}
+
+// streamPath is the pseudo path of a streamed asset, its base is the name.
+func streamPath(name string) string {
+	return path.Join("-", name)
+}
+
+// readStreams reads the streamed assets into memory, as uploads need to
+// know their size up front, without writing them to disk. The remaining
+// entries are returned for globbing.
+func readStreams(out io.Writer, entries []string, stdin io.Reader, maxSize int64) ([]string, map[string][]byte, error) {
+	var (
+		globs   []string
+		streams = make(map[string][]byte)
+		read    bool
+	)
+
+	for _, entry := range entries {
+		source, name, ok := parseStream(entry)
+
+		if !ok {
+			globs = append(globs, entry)
+			continue
+		}
+
+		if name != path.Base(name) {
+			return nil, nil, fmt.Errorf("streamed asset name %s must not contain a directory", name)
+		}
+
+		if source == "-" {
+			if read {
+				return nil, nil, fmt.Errorf("stdin can only be read once")
+			}
+
+			read = true
+		}
+
+		content, err := readStream(source, stdin, maxSize)
+
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read %s from %s: %w", name, source, err)
+		}
+
+		fmt.Fprintf(out, "Successfully read streamed asset %s\n", name)
+		streams[streamPath(name)] = content
+	}
+
+	return globs, streams, nil
+}
+
+func readStream(source string, stdin io.Reader, maxSize int64) ([]byte, error) {
+	if source != "-" {
+		info, err := os.Stat(source)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if info.Mode()&os.ModeNamedPipe == 0 {
+			return nil, fmt.Errorf("%s is not a named pipe", source)
+		}
+
+		handle, err := os.Open(source)
+
+		if err != nil {
+			return nil, err
+		}
+
+		defer handle.Close()
+		stdin = handle
+	}
+
+	content, err := ioutil.ReadAll(io.LimitReader(stdin, maxSize+1))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(content)) > maxSize {
+		return nil, fmt.Errorf("stream exceeds the maximum size of %s", humanSize(maxSize))
+	}
+
+	return content, nil
+}
+
+// streamFiles returns the sorted pseudo paths of the streamed assets.
+func streamFiles(streams map[string][]byte) []string {
+	files := make([]string, 0, len(streams))
+
+	for file := range streams {
+		files = append(files, file)
+	}
+
+	sort.Strings(files)
+	return files
+}
+
+// uploadContent uploads an asset from memory.
+func (rc *releaseClient) uploadContent(id int64, name string, content []byte) error {
+	u := fmt.Sprintf("repos/%s/%s/releases/%d/assets?name=%s", rc.Owner, rc.Repo, id, url.QueryEscape(name))
+	req, err := rc.Client.NewUploadRequest(u, bytes.NewReader(content), int64(len(content)), "application/octet-stream")
+
+	if err != nil {
+		return err
+	}
+
+	_, err = rc.Client.Do(rc.Context, req, new(github.ReleaseAsset))
+	return err
+}
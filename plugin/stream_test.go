@@ -0,0 +1,98 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/urfave/cli/v2"
+)
+
+func TestReadStreams(t *testing.T) {
+	globs, streams, err := readStreams(ioutil.Discard, []string{"dist/*", "-:name=coverage.tar.gz"}, strings.NewReader("coverage"), 1024)
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if expected := []string{"dist/*"}; !reflect.DeepEqual(globs, expected) {
+		t.Errorf("Unexpected globs (Got: %v, Expected: %v)", globs, expected)
+	}
+
+	if expected := map[string][]byte{"-/coverage.tar.gz": []byte("coverage")}; !reflect.DeepEqual(streams, expected) {
+		t.Errorf("Unexpected streams (Got: %v, Expected: %v)", streams, expected)
+	}
+
+	file := filepath.Join(t.TempDir(), "app")
+
+	if err := ioutil.WriteFile(file, []byte("app"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string][]string{
+		"stdin read twice":    {"-:name=a", "-:name=b"},
+		"name with directory": {"-:name=../a"},
+		"missing pipe":        {"missing:name=app"},
+		"regular file":        {file + ":name=app"},
+		"stream too large":    {"-:name=large"},
+	}
+
+	for name, entries := range tests {
+		if _, _, err := readStreams(ioutil.Discard, entries, strings.NewReader(strings.Repeat("x", 2048)), 1024); err == nil {
+			t.Errorf("Expected an error for %s", name)
+		}
+	}
+}
+
+func TestUploadStream(t *testing.T) {
+	var uploaded string
+
+	rc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/octocat/hello/releases/1/assets":
+			w.Write([]byte(`[]`))
+		case r.Method == http.MethodPost && r.URL.Path == "/repos/octocat/hello/releases/1/assets":
+			b, _ := ioutil.ReadAll(r.Body)
+			uploaded = r.URL.Query().Get("name") + "=" + string(b)
+			w.Write([]byte(`{}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	rc.FileExists = "overwrite"
+	rc.Streams = map[string][]byte{streamPath("coverage.tar.gz"): []byte("coverage")}
+
+	if err := rc.uploadFiles(1, streamFiles(rc.Streams)); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if uploaded != "coverage.tar.gz=coverage" {
+		t.Errorf("Unexpected upload (Got: %s, Expected: coverage.tar.gz=coverage)", uploaded)
+	}
+}
+
+func TestStreamDisallowed(t *testing.T) {
+	settings := Settings{Encrypt: "age", ChecksumSidecar: true}
+	settings.Checksum = *cli.NewStringSlice("sha256")
+
+	var disallowed []string
+
+	for _, d := range streamDisallowed {
+		if d.set(&settings) {
+			disallowed = append(disallowed, d.name)
+		}
+	}
+
+	if expected := []string{"checksum", "checksum_sidecar", "encrypt"}; !reflect.DeepEqual(disallowed, expected) {
+		t.Errorf("Unexpected settings (Got: %v, Expected: %v)", disallowed, expected)
+	}
+}
@@ -0,0 +1,327 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-github/v44/github"
+)
+
+var (
+	tagSyncValues = map[string]bool{
+		"":         true,
+		"to-tag":   true,
+		"from-tag": true,
+	}
+
+	onMissingTagValues = map[string]bool{
+		"fail":   true,
+		"derive": true,
+		"skip":   true,
+	}
+
+	sameCommitValues = map[string]bool{
+		"":     true,
+		"warn": true,
+		"link": true,
+	}
+
+	verifySignatureValues = map[string]bool{
+		"":              true,
+		"tag":           true,
+		"tag-or-commit": true,
+	}
+)
+
+// resolveTag returns the reference of the tag and the annotated tag object
+// it points to, which is nil for lightweight tags.
+func (rc *releaseClient) resolveTag(name string) (*github.Reference, *github.Tag, error) {
+	ref, _, err := rc.Client.Git.GetRef(rc.Context, rc.Owner, rc.Repo, "tags/"+name)
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get tag %s: %w", name, err)
+	}
+
+	if ref.GetObject().GetType() != "tag" {
+		return ref, nil, nil
+	}
+
+	tag, _, err := rc.Client.Git.GetTag(rc.Context, rc.Owner, rc.Repo, ref.GetObject().GetSHA())
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get tag object %s: %w", ref.GetObject().GetSHA(), err)
+	}
+
+	return ref, tag, nil
+}
+
+// tagMessage returns the message of the annotated tag.
+func (rc *releaseClient) tagMessage() (string, error) {
+	_, tag, err := rc.resolveTag(rc.Tag)
+
+	if err != nil {
+		return "", err
+	}
+
+	if tag == nil {
+		fmt.Fprintf(rc.Out, "Tag %s is not annotated, keeping the notes\n", rc.Tag)
+		return "", nil
+	}
+
+	return tag.GetMessage(), nil
+}
+
+// syncTagMessage replaces the annotated tag with one carrying the message,
+// pointing to the same commit. Lightweight tags are kept as they are, signed
+// tags are refused, as replacing them drops the signature.
+func (rc *releaseClient) syncTagMessage(message string) error {
+	ref, tag, err := rc.resolveTag(rc.Tag)
+
+	if err != nil {
+		return err
+	}
+
+	if tag == nil {
+		fmt.Fprintf(rc.Out, "Tag %s is not annotated, keeping it lightweight\n", rc.Tag)
+		return nil
+	}
+
+	if tag.GetMessage() == message {
+		fmt.Fprintf(rc.Out, "Tag %s message is already up to date\n", rc.Tag)
+		return nil
+	}
+
+	if tag.GetVerification().GetVerified() || tag.GetVerification().GetSignature() != "" {
+		return fmt.Errorf("refusing to replace signed tag %s, its signature would be lost", rc.Tag)
+	}
+
+	created, _, err := rc.Client.Git.CreateTag(rc.Context, rc.Owner, rc.Repo, &github.Tag{
+		Tag:     github.String(rc.Tag),
+		Message: github.String(message),
+		Object:  tag.GetObject(),
+		Tagger:  tag.GetTagger(),
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to create tag object: %w", err)
+	}
+
+	ref.Object = &github.GitObject{SHA: created.SHA}
+
+	if _, _, err := rc.Client.Git.UpdateRef(rc.Context, rc.Owner, rc.Repo, ref, true); err != nil {
+		return fmt.Errorf("failed to update tag %s: %w", rc.Tag, err)
+	}
+
+	fmt.Fprintf(rc.Out, "Successfully updated %s tag message\n", rc.Tag)
+	return nil
+}
+
+// verifySignature requires a verified signature on the annotated tag, or
+// on the tagged commit if allowed, made by one of the allowed signers.
+// Signers are matched by email or login, any signer is allowed if the list
+// is empty.
+func (rc *releaseClient) verifySignature(mode string, allowed []string) error {
+	ref, tag, err := rc.resolveTag(rc.Tag)
+
+	if err != nil {
+		return err
+	}
+
+	sha := ref.GetObject().GetSHA()
+
+	if tag != nil {
+		if tag.GetVerification().GetVerified() && signerAllowed(allowed, tag.GetTagger().GetEmail()) {
+			fmt.Fprintf(rc.Out, "Tag %s is signed by %s\n", rc.Tag, tag.GetTagger().GetEmail())
+			return nil
+		}
+
+		if mode == "tag" {
+			return fmt.Errorf("tag %s has no valid signature of an allowed signer: %s", rc.Tag, tag.GetVerification().GetReason())
+		}
+
+		sha = tag.GetObject().GetSHA()
+	} else if mode == "tag" {
+		return fmt.Errorf("tag %s is not annotated and cannot be signed", rc.Tag)
+	}
+
+	commit, _, err := rc.Client.Repositories.GetCommit(rc.Context, rc.Owner, rc.Repo, sha, nil)
+
+	if err != nil {
+		return fmt.Errorf("failed to get commit %s: %w", sha, err)
+	}
+
+	verification := commit.GetCommit().GetVerification()
+	email := commit.GetCommit().GetCommitter().GetEmail()
+
+	if verification.GetVerified() && signerAllowed(allowed, email, commit.GetCommitter().GetLogin()) {
+		fmt.Fprintf(rc.Out, "Commit %s of tag %s is signed by %s\n", sha, rc.Tag, email)
+		return nil
+	}
+
+	return fmt.Errorf("neither tag %s nor commit %s have a valid signature of an allowed signer: %s", rc.Tag, sha, verification.GetReason())
+}
+
+func signerAllowed(allowed []string, identities ...string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+
+	for _, signer := range allowed {
+		for _, identity := range identities {
+			if identity != "" && strings.EqualFold(signer, identity) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// deriveTag names the release of a build without tag, using the version of
+// the version file if given, otherwise the short commit.
+func deriveTag(versionFile, commit string) (string, error) {
+	if versionFile != "" {
+		content, err := ioutil.ReadFile(versionFile)
+
+		if err != nil {
+			return "", err
+		}
+
+		version := strings.TrimSpace(string(content))
+
+		if version == "" {
+			return "", fmt.Errorf("version file %s is empty", versionFile)
+		}
+
+		return "v" + strings.TrimPrefix(version, "v"), nil
+	}
+
+	if len(commit) < 7 {
+		return "", fmt.Errorf("no commit to derive the tag from")
+	}
+
+	return "snapshot-" + commit[:7], nil
+}
+
+// checkDerivedTag fails if the derived tag already exists for another
+// commit, a version file which wasn't bumped would release it again.
+func (rc *releaseClient) checkDerivedTag() error {
+	ref, resp, err := rc.Client.Git.GetRef(rc.Context, rc.Owner, rc.Repo, "tags/"+rc.Tag)
+
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to get tag %s: %w", rc.Tag, err)
+	}
+
+	commit := ref.GetObject().GetSHA()
+
+	// annotated tags point to a tag object instead of the commit
+	if ref.GetObject().GetType() == "tag" {
+		tag, _, err := rc.Client.Git.GetTag(rc.Context, rc.Owner, rc.Repo, commit)
+
+		if err != nil {
+			return fmt.Errorf("failed to get tag object %s: %w", commit, err)
+		}
+
+		commit = tag.GetObject().GetSHA()
+	}
+
+	if commit != rc.Commit {
+		return fmt.Errorf("derived tag %s already exists for commit %s", rc.Tag, commit)
+	}
+
+	return nil
+}
+
+// sameCommitReleases lists the published releases of other tags pointing to
+// the released commit.
+func (rc *releaseClient) sameCommitReleases() ([]*github.RepositoryRelease, error) {
+	var tags []string
+	listOpts := &github.ListOptions{PerPage: 100}
+
+	for {
+		page, resp, err := rc.Client.Repositories.ListTags(rc.Context, rc.Owner, rc.Repo, listOpts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list tags: %w", err)
+		}
+
+		for _, tag := range page {
+			if tag.GetName() != rc.Tag && tag.GetCommit().GetSHA() == rc.Commit {
+				tags = append(tags, tag.GetName())
+			}
+		}
+
+		// stop iteration if there is no next page
+		if resp.NextPage == 0 {
+			break
+		}
+
+		listOpts.Page = resp.NextPage
+	}
+
+	var releases []*github.RepositoryRelease
+
+	for _, tag := range tags {
+		release, resp, err := rc.Client.Repositories.GetReleaseByTag(rc.Context, rc.Owner, rc.Repo, tag)
+
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			continue
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve release of tag %s: %w", tag, err)
+		}
+
+		releases = append(releases, release)
+	}
+
+	return releases, nil
+}
+
+const sameCommitHeading = "## Releases of the same commit"
+
+// sameCommitSection links the releases of other tags of the commit.
+func sameCommitSection(releases []*github.RepositoryRelease) string {
+	if len(releases) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString(sameCommitHeading + "\n\n")
+
+	for _, release := range releases {
+		fmt.Fprintf(&sb, "- [%s](%s)\n", release.GetTagName(), release.GetHTMLURL())
+	}
+
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// linkReleases adds the releases of other tags of the commit to the notes.
+func (rc *releaseClient) linkReleases(release *github.RepositoryRelease, others []*github.RepositoryRelease) (*github.RepositoryRelease, error) {
+	section := sameCommitSection(others)
+
+	if section == "" {
+		return release, nil
+	}
+
+	body := replaceSection(release.GetBody(), sameCommitHeading, section)
+
+	release, _, err := rc.Client.Repositories.EditRelease(rc.Context, rc.Owner, rc.Repo, release.GetID(), &github.RepositoryRelease{Body: &body})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to update release notes: %w", err)
+	}
+
+	fmt.Fprintf(rc.Out, "Successfully linked %d releases of the same commit\n", len(others))
+	return release, nil
+}
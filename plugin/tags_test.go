@@ -0,0 +1,154 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-github/v44/github"
+)
+
+func TestSignerAllowed(t *testing.T) {
+	if !signerAllowed(nil, "dev@example.com") {
+		t.Error("Expected any signer to be allowed without a list")
+	}
+
+	allowed := []string{"release@example.com", "octocat"}
+
+	if !signerAllowed(allowed, "someone@example.com", "OctoCat") {
+		t.Error("Expected the login to be allowed")
+	}
+
+	if signerAllowed(allowed, "someone@example.com", "") {
+		t.Error("Expected the signer not to be allowed")
+	}
+}
+
+func TestDeriveTag(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "VERSION")
+
+	if err := os.WriteFile(file, []byte("1.4.0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		file     string
+		expected string
+	}{
+		{file, "v1.4.0"},
+		{"", "snapshot-0123456"},
+	}
+
+	for _, test := range tests {
+		actual, err := deriveTag(test.file, "0123456789abcdef")
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if actual != test.expected {
+			t.Errorf("Unexpected tag (Got: %s, Expected: %s)", actual, test.expected)
+		}
+	}
+}
+
+func TestSameCommitSection(t *testing.T) {
+	releases := []*github.RepositoryRelease{
+		{TagName: github.String("v1.0.0"), HTMLURL: github.String("https://github.com/o/r/releases/tag/v1.0.0")},
+		{TagName: github.String("stable"), HTMLURL: github.String("https://github.com/o/r/releases/tag/stable")},
+	}
+
+	expected := "## Releases of the same commit\n\n- [v1.0.0](https://github.com/o/r/releases/tag/v1.0.0)\n- [stable](https://github.com/o/r/releases/tag/stable)"
+
+	if actual := sameCommitSection(releases); actual != expected {
+		t.Errorf("Unexpected section (Got: %s, Expected: %s)", actual, expected)
+	}
+
+	if actual := sameCommitSection(nil); actual != "" {
+		t.Errorf("Unexpected section (Got: %s, Expected: %s)", actual, "")
+	}
+}
+
+func TestSyncTagMessage(t *testing.T) {
+	tests := []struct {
+		name      string
+		ref       string
+		tag       string
+		expectErr bool
+		updated   bool
+	}{
+		{name: "lightweight", ref: `{"ref": "refs/tags/v1.0.0", "object": {"sha": "abc", "type": "commit"}}`},
+		{name: "annotated", ref: `{"ref": "refs/tags/v1.0.0", "object": {"sha": "def", "type": "tag"}}`, tag: `{"sha": "def", "message": "old", "object": {"sha": "abc", "type": "commit"}}`, updated: true},
+		{name: "signed", ref: `{"ref": "refs/tags/v1.0.0", "object": {"sha": "def", "type": "tag"}}`, tag: `{"sha": "def", "message": "old", "verification": {"verified": true, "signature": "sig"}}`, expectErr: true},
+		{name: "up to date", ref: `{"ref": "refs/tags/v1.0.0", "object": {"sha": "def", "type": "tag"}}`, tag: `{"sha": "def", "message": "notes"}`},
+	}
+
+	for _, test := range tests {
+		updated := false
+
+		rc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case r.URL.Path == "/repos/octocat/hello/git/ref/tags/v1.0.0":
+				fmt.Fprint(w, test.ref)
+			case r.URL.Path == "/repos/octocat/hello/git/tags/def":
+				fmt.Fprint(w, test.tag)
+			case r.Method == http.MethodPost && r.URL.Path == "/repos/octocat/hello/git/tags":
+				fmt.Fprint(w, `{"sha": "ghi"}`)
+			case r.Method == http.MethodPatch && r.URL.Path == "/repos/octocat/hello/git/refs/tags/v1.0.0":
+				updated = true
+				fmt.Fprint(w, `{"object": {"sha": "ghi", "type": "tag"}}`)
+			default:
+				http.NotFound(w, r)
+			}
+		})
+
+		rc.Tag = "v1.0.0"
+		err := rc.syncTagMessage("notes")
+
+		if (err != nil) != test.expectErr {
+			t.Errorf("Unexpected error of %s tag (Got: %v)", test.name, err)
+		}
+
+		if updated != test.updated {
+			t.Errorf("Unexpected update of %s tag (Got: %t, Expected: %t)", test.name, updated, test.updated)
+		}
+	}
+}
+
+func TestCheckDerivedTag(t *testing.T) {
+	tests := map[string]bool{
+		"v1.0.0": false,
+		"v1.1.0": true,
+		"v1.2.0": false,
+	}
+
+	rc := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/octocat/hello/git/ref/tags/v1.0.0":
+			fmt.Fprint(w, `{"ref": "refs/tags/v1.0.0", "object": {"sha": "abc", "type": "commit"}}`)
+		case "/repos/octocat/hello/git/ref/tags/v1.1.0":
+			fmt.Fprint(w, `{"ref": "refs/tags/v1.1.0", "object": {"sha": "def", "type": "tag"}}`)
+		case "/repos/octocat/hello/git/tags/def":
+			fmt.Fprint(w, `{"sha": "def", "object": {"sha": "old", "type": "commit"}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	rc.Commit = "abc"
+
+	for tag, expectErr := range tests {
+		rc.Tag = tag
+
+		if err := rc.checkDerivedTag(); (err != nil) != expectErr {
+			t.Errorf("Unexpected error for %s (Got: %v)", tag, err)
+		}
+	}
+}
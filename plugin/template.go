@@ -0,0 +1,151 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"bytes"
+	"os"
+	"path"
+	"strings"
+	"text/template"
+
+	"github.com/google/go-github/v44/github"
+)
+
+// templateData is passed to all templates rendered by the plugin.
+type templateData struct {
+	Owner       string
+	Project     string
+	Tag         string
+	Version     string
+	Commit      string
+	BuildNumber int
+	BuildLink   string
+	Notes       string
+	ReleaseURL  string
+	Assets      []templateAsset
+
+	// PreviousTag and PreviousNotes describe the release before this one,
+	// so notes can carry forward sections like known issues.
+	PreviousTag   string
+	PreviousNotes string
+}
+
+// templateAsset describes an uploaded file within templates.
+type templateAsset struct {
+	Name   string
+	Size   int64
+	SHA256 string
+	URL    string
+}
+
+var templateFuncs = template.FuncMap{
+	"trimPrefix": strings.TrimPrefix,
+	"trimSuffix": strings.TrimSuffix,
+	"replace":    strings.ReplaceAll,
+	"lower":      strings.ToLower,
+	"upper":      strings.ToUpper,
+	"humanSize":  humanSize,
+	"platform":   platform,
+	"section":    findSection,
+}
+
+func renderTemplate(name, text string, data templateData) (string, error) {
+	tmpl, err := template.New(name).Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// findSection returns the markdown section with the heading line, up to the
+// next heading of the same or a higher level, or nothing if the body has no
+// such section.
+func findSection(heading, body string) string {
+	heading = strings.TrimSpace(heading)
+	level := headingLevel(heading)
+	lines := strings.Split(body, "\n")
+
+	for start, line := range lines {
+		if strings.TrimSpace(line) != heading {
+			continue
+		}
+
+		end := len(lines)
+
+		for i := start + 1; i < len(lines); i++ {
+			if l := headingLevel(strings.TrimSpace(lines[i])); l > 0 && l <= level {
+				end = i
+				break
+			}
+		}
+
+		return strings.TrimSpace(strings.Join(lines[start:end], "\n"))
+	}
+
+	return ""
+}
+
+// headingLevel returns the level of a markdown heading line, or 0 if the
+// line is no heading.
+func headingLevel(line string) int {
+	level := len(line) - len(strings.TrimLeft(line, "#"))
+
+	if level == 0 || level > 6 || (len(line) > level && line[level] != ' ') {
+		return 0
+	}
+
+	return level
+}
+
+// templateAssets describes the uploaded files, using the download urls of
+// the matching release assets.
+func templateAssets(files []string, assets []*github.ReleaseAsset) ([]templateAsset, error) {
+	urls := make(map[string]string)
+	for _, asset := range assets {
+		urls[asset.GetName()] = asset.GetBrowserDownloadURL()
+	}
+
+	var result []templateAsset
+
+	for _, file := range files {
+		handle, err := os.Open(file)
+
+		if err != nil {
+			return nil, err
+		}
+
+		hash, err := checksum(handle, "sha256")
+		handle.Close()
+
+		if err != nil {
+			return nil, err
+		}
+
+		info, err := os.Stat(file)
+
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, templateAsset{
+			Name:   path.Base(file),
+			Size:   info.Size(),
+			SHA256: hash,
+			URL:    urls[path.Base(file)],
+		})
+	}
+
+	return result, nil
+}
@@ -0,0 +1,150 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"testing"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	data := templateData{
+		Project: "drone",
+		Version: "1.2.3",
+		Assets:  []templateAsset{{Name: "drone.tar.gz", SHA256: "abc"}},
+	}
+
+	actual, err := renderTemplate("PKGBUILD", "pkgver={{ .Version }}\n{{ range .Assets }}sha256sums=('{{ .SHA256 }}'){{ end }}", data)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "pkgver=1.2.3\nsha256sums=('abc')"
+	if actual != expected {
+		t.Errorf("Unexpected output (Got: %q, Expected: %q)", actual, expected)
+	}
+
+	if _, err := renderTemplate("invalid", "{{ .Missing }}", data); err == nil {
+		t.Error("Expected an error for an unknown field")
+	}
+}
+
+func TestApplyPreset(t *testing.T) {
+	data := templateData{
+		Owner:   "octocat",
+		Project: "hello",
+		Tag:     "v1.2.0",
+		Version: "1.2.0",
+		Assets: []templateAsset{
+			{Name: "hello-1.2.0.dmg", Size: 3 << 20, URL: "https://example.com/hello-1.2.0.dmg"},
+		},
+	}
+
+	title, note, err := applyPreset("desktop-app", "", "Bug fixes", data)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if title != "hello 1.2.0" {
+		t.Errorf("Unexpected title (Got: %s, Expected: hello 1.2.0)", title)
+	}
+
+	expected := "Bug fixes\n\n## Downloads\n\n| Platform | File | Size |\n| --- | --- | --- |\n| macOS | [hello-1.2.0.dmg](https://example.com/hello-1.2.0.dmg) | 3.0 MiB |"
+	if note != expected {
+		t.Errorf("Unexpected note (Got: %q, Expected: %q)", note, expected)
+	}
+
+	if _, _, err := applyPreset("unknown", "", "", data); err == nil {
+		t.Error("Expected an error for an unknown preset")
+	}
+}
+
+func TestDefaultCommentTemplate(t *testing.T) {
+	data := templateData{
+		Tag:        "v1.0.0",
+		Notes:      "Bug fixes",
+		ReleaseURL: "https://github.com/octocat/hello/releases/tag/v1.0.0",
+	}
+
+	actual, err := renderTemplate("comment", defaultCommentTemplate, data)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "Released in [v1.0.0](https://github.com/octocat/hello/releases/tag/v1.0.0) :rocket:\n\n<details>\n<summary>Release notes</summary>\n\nBug fixes\n\n</details>\n"
+	if actual != expected {
+		t.Errorf("Unexpected comment (Got: %q, Expected: %q)", actual, expected)
+	}
+}
+
+func TestPreviousNotesTemplate(t *testing.T) {
+	data := templateData{
+		PreviousTag:   "v1.0.0",
+		PreviousNotes: "## Changes\n\n- Fix\n\n## Known issues\n\n- Slow on ARM\n\n## Thanks\n\n- octocat",
+	}
+
+	actual, err := renderTemplate("note", "New features\n\n{{ .PreviousNotes | section \"## Known issues\" }}\n{{ .PreviousNotes | section \"## Deprecations\" }}", data)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "New features\n\n## Known issues\n\n- Slow on ARM\n"
+	if actual != expected {
+		t.Errorf("Unexpected note (Got: %q, Expected: %q)", actual, expected)
+	}
+}
+
+func TestFindSection(t *testing.T) {
+	body := "### Known issues\n\n- Nested\n\n## Known issues\n\n- Slow on ARM\n\n### Details\n\n- Only on v7\n\n## Thanks\n\n- octocat"
+
+	expected := "## Known issues\n\n- Slow on ARM\n\n### Details\n\n- Only on v7"
+	if actual := findSection("## Known issues", body); actual != expected {
+		t.Errorf("Unexpected section (Got: %q, Expected: %q)", actual, expected)
+	}
+
+	if actual := findSection("## Known", body); actual != "" {
+		t.Errorf("Unexpected section for a heading prefix (Got: %q)", actual)
+	}
+}
+
+func TestNoteTemplateNotes(t *testing.T) {
+	data := templateData{Tag: "v1.0.0", Notes: "- Document {{ .Tag }} in templates"}
+
+	actual, err := renderTemplate("note", "{{ .Notes }}\n\nReleased as {{ .Tag }}", data)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "- Document {{ .Tag }} in templates\n\nReleased as v1.0.0"
+	if actual != expected {
+		t.Errorf("Unexpected note (Got: %q, Expected: %q)", actual, expected)
+	}
+}
+
+func TestDefaultCommitCommentTemplate(t *testing.T) {
+	data := templateData{
+		Tag:        "v1.0.0",
+		ReleaseURL: "https://github.com/octocat/hello/releases/tag/v1.0.0",
+		Assets: []templateAsset{
+			{Name: "app.zip", Size: 2048, SHA256: "abc", URL: "https://example.com/app.zip"},
+		},
+	}
+
+	actual, err := renderTemplate("commit-comment", defaultCommitCommentTemplate, data)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "Released in [v1.0.0](https://github.com/octocat/hello/releases/tag/v1.0.0) :rocket:\n\n| File | Size | SHA256 |\n| --- | --- | --- |\n| [app.zip](https://example.com/app.zip) | 2.0 KiB | `abc` |\n"
+	if actual != expected {
+		t.Errorf("Unexpected comment (Got: %q, Expected: %q)", actual, expected)
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	ipVersionValues = map[string]bool{
+		"":  true,
+		"4": true,
+		"6": true,
+	}
+)
+
+// transportOptions customize the transport of the injected http client.
+type transportOptions struct {
+	// IPVersion forces connections to use IPv4 or IPv6 if set.
+	IPVersion string
+
+	// Hosts pins host names to specific IP addresses.
+	Hosts map[string]string
+
+	// MaxIdleConns limits the idle connections kept open, if set.
+	MaxIdleConns int
+
+	// MaxIdleConnsPerHost limits the idle connections per host, if set.
+	MaxIdleConnsPerHost int
+
+	// DisableCompression skips transparent gzip handling, which is only
+	// overhead for already compressed assets.
+	DisableCompression bool
+}
+
+func parseHostOverrides(entries []string) (map[string]string, error) {
+	hosts := make(map[string]string)
+
+	for _, entry := range entries {
+		parts := strings.SplitN(entry, "=", 2)
+
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid host override %s, expected host=ip", entry)
+		}
+
+		if net.ParseIP(parts[1]) == nil {
+			return nil, fmt.Errorf("invalid ip address %s for host %s", parts[1], parts[0])
+		}
+
+		hosts[strings.ToLower(parts[0])] = parts[1]
+	}
+
+	return hosts, nil
+}
+
+// configureTransport returns a copy of the client using a transport with
+// the given options applied.
+func configureTransport(client *http.Client, opts transportOptions) (*http.Client, error) {
+	var base *http.Transport
+
+	switch t := client.Transport.(type) {
+	case nil:
+		base = http.DefaultTransport.(*http.Transport)
+	case *http.Transport:
+		base = t
+	default:
+		return nil, fmt.Errorf("unsupported transport %T", client.Transport)
+	}
+
+	transport := base.Clone()
+
+	if opts.MaxIdleConns > 0 {
+		transport.MaxIdleConns = opts.MaxIdleConns
+	}
+
+	if opts.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = opts.MaxIdleConnsPerHost
+	}
+
+	transport.DisableCompression = opts.DisableCompression
+
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if host, port, err := net.SplitHostPort(addr); err == nil {
+			if ip, ok := opts.Hosts[strings.ToLower(host)]; ok {
+				addr = net.JoinHostPort(ip, port)
+			}
+		}
+
+		if opts.IPVersion != "" {
+			network = "tcp" + opts.IPVersion
+		}
+
+		return dialer.DialContext(ctx, network, addr)
+	}
+
+	result := *client
+	result.Transport = transport
+
+	return &result, nil
+}
+
+// debugTransport logs every request with its status and the rate limit of
+// the response.
+type debugTransport struct {
+	base http.RoundTripper
+	log  *logrus.Logger
+}
+
+func (t *debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+
+	if err != nil {
+		t.log.Debugf("%s %s failed after %s: %s", req.Method, req.URL.Redacted(), time.Since(start).Round(time.Millisecond), err)
+		return resp, err
+	}
+
+	t.log.Debugf("%s %s returned %s after %s (rate limit remaining %s of %s, reset %s)",
+		req.Method, req.URL.Redacted(), resp.Status, time.Since(start).Round(time.Millisecond),
+		resp.Header.Get("X-RateLimit-Remaining"), resp.Header.Get("X-RateLimit-Limit"), resp.Header.Get("X-RateLimit-Reset"))
+
+	return resp, err
+}
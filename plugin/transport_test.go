@@ -0,0 +1,70 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestParseHostOverrides(t *testing.T) {
+	hosts, err := parseHostOverrides([]string{"GitHub.example.com=10.0.0.1", "uploads.example.com=::1"})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if hosts["github.example.com"] != "10.0.0.1" {
+		t.Errorf("Unexpected ip for github.example.com (Got: %s, Expected: 10.0.0.1)", hosts["github.example.com"])
+	}
+
+	if hosts["uploads.example.com"] != "::1" {
+		t.Errorf("Unexpected ip for uploads.example.com (Got: %s, Expected: ::1)", hosts["uploads.example.com"])
+	}
+
+	if _, err := parseHostOverrides([]string{"github.example.com"}); err == nil {
+		t.Error("Expected an error for a missing ip address")
+	}
+
+	if _, err := parseHostOverrides([]string{"github.example.com=not-an-ip"}); err == nil {
+		t.Error("Expected an error for an invalid ip address")
+	}
+}
+
+func TestDebugTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "4999")
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	var out bytes.Buffer
+
+	logger := logrus.New()
+	logger.SetOutput(&out)
+	logger.SetLevel(logrus.DebugLevel)
+
+	client := &http.Client{Transport: &debugTransport{base: http.DefaultTransport, log: logger}}
+	resp, err := client.Get(server.URL + "/repos/octocat/hello/releases/tags/v1.0.0")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp.Body.Close()
+
+	for _, expected := range []string{"GET " + server.URL + "/repos/octocat/hello/releases/tags/v1.0.0", "404 Not Found", "remaining 4999 of 5000"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Unexpected log (Got: %s, Expected: %s)", out.String(), expected)
+		}
+	}
+}
@@ -10,15 +10,23 @@ import (
 	"crypto/sha1"
 	"crypto/sha256"
 	"crypto/sha512"
+	"encoding/json"
 	"fmt"
 	"hash/adler32"
 	"hash/crc32"
 	"io"
+	"io/fs"
 	"io/ioutil"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/blake2b"
 )
 
 var (
@@ -27,6 +35,49 @@ var (
 		"fail":      true,
 		"skip":      true,
 	}
+
+	checksumValues = map[string]bool{
+		"md5":     true,
+		"sha1":    true,
+		"sha256":  true,
+		"sha512":  true,
+		"blake2b": true,
+		"adler32": true,
+		"crc32":   true,
+	}
+
+	checksumFormatValues = map[string]bool{
+		"gnu": true,
+		"bsd": true,
+	}
+
+	shellSafe = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]*$`)
+
+	bsdChecksumLine = regexp.MustCompile(`^(\w+) \((.+)\) = ([0-9A-Fa-f]+)$`)
+
+	fipsChecksumValues = map[string]bool{
+		"sha256": true,
+		"sha512": true,
+	}
+
+	// fipsDisallowed lists the signing and encryption settings relying on
+	// algorithms which are not FIPS approved.
+	fipsDisallowed = []struct {
+		name string
+		set  func(s *Settings) bool
+	}{
+		{"gpg_key", func(s *Settings) bool { return s.GPGKey != "" }},
+		{"minisign_key", func(s *Settings) bool { return s.MinisignKey != "" }},
+		{"ssh_key", func(s *Settings) bool { return s.SSHKey != "" }},
+		{"cosign", func(s *Settings) bool { return s.Cosign != "" }},
+		{"encrypt", func(s *Settings) bool { return s.Encrypt != "" }},
+	}
+
+	actionValues = map[string]bool{
+		"release": true,
+		"list":    true,
+		"compare": true,
+	}
 )
 
 func readStringOrFile(input string) (string, error) {
@@ -63,6 +114,8 @@ func checksum(r io.Reader, method string) (string, error) {
 		return fmt.Sprintf("%x", sha256.Sum256(b)), nil
 	case "sha512":
 		return fmt.Sprintf("%x", sha512.Sum512(b)), nil
+	case "blake2b":
+		return fmt.Sprintf("%x", blake2b.Sum512(b)), nil
 	case "adler32":
 		return strconv.FormatUint(uint64(adler32.Checksum(b)), 10), nil
 	case "crc32":
@@ -72,7 +125,420 @@ func checksum(r io.Reader, method string) (string, error) {
 	return "", fmt.Errorf("hashing method %s is not supported", method)
 }
 
-func writeChecksums(files, methods []string, format string, flatten bool) ([]string, error) {
+// manifestMethods maps the length of a hex digest to its hashing method.
+var manifestMethods = map[int]string{
+	32:  "md5",
+	40:  "sha1",
+	64:  "sha256",
+	128: "sha512",
+}
+
+// manifestEntry is a digest listed in a checksums file, with its hashing
+// method if the file names it.
+type manifestEntry struct {
+	method string
+	digest string
+}
+
+// readChecksumManifest parses a checksums file in the GNU sha256sum or the
+// BSD format, keyed by the cleaned paths as listed. BSD lines name their
+// hashing method, other lines use the method unless it is empty.
+func readChecksumManifest(file, method string) (map[string]manifestEntry, error) {
+	content, err := ioutil.ReadFile(file)
+
+	if err != nil {
+		return nil, err
+	}
+
+	hashes := make(map[string]manifestEntry)
+
+	for _, line := range strings.Split(string(content), "\n") {
+		if m := bsdChecksumLine.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			hashes[path.Clean(m[2])] = manifestEntry{
+				method: strings.ToLower(m[1]),
+				digest: strings.ToLower(m[3]),
+			}
+
+			continue
+		}
+
+		fields := strings.Fields(line)
+
+		if len(fields) != 2 {
+			continue
+		}
+
+		// binary mode entries are prefixed by an asterisk
+		name := path.Clean(strings.TrimPrefix(fields[1], "*"))
+
+		hashes[name] = manifestEntry{
+			method: method,
+			digest: strings.ToLower(fields[0]),
+		}
+	}
+
+	return hashes, nil
+}
+
+// lookupChecksum finds the manifest entry of the file, either by its path or
+// by a unique entry the path ends with or which ends with the path, falling
+// back to a unique entry with the same base name.
+func lookupChecksum(hashes map[string]manifestEntry, file string) (manifestEntry, error) {
+	name := path.Clean(filepath.ToSlash(file))
+
+	if entry, ok := hashes[name]; ok {
+		return entry, nil
+	}
+
+	var suffixed, based []string
+
+	for key := range hashes {
+		if strings.HasSuffix(name, "/"+key) || strings.HasSuffix(key, "/"+name) {
+			suffixed = append(suffixed, key)
+		}
+
+		if path.Base(key) == path.Base(name) {
+			based = append(based, key)
+		}
+	}
+
+	for _, candidates := range [][]string{suffixed, based} {
+		switch len(candidates) {
+		case 0:
+			continue
+		case 1:
+			return hashes[candidates[0]], nil
+		}
+
+		sort.Strings(candidates)
+		return manifestEntry{}, fmt.Errorf("%s matches several entries: %s", name, strings.Join(candidates, ", "))
+	}
+
+	return manifestEntry{}, fmt.Errorf("%s is not listed", name)
+}
+
+// verifyChecksums checks every file against the manifest, failing for files
+// which are missing from it or have a different checksum. Entries without a
+// hashing method get it from the digest length, assuming sha512 for 128 hex
+// digits. Unless allowed is nil, only the allowed hashing methods are accepted.
+func verifyChecksums(files []string, hashes map[string]manifestEntry, allowed map[string]bool) error {
+	var mismatches []string
+
+	for _, file := range files {
+		entry, err := lookupChecksum(hashes, file)
+
+		if err != nil {
+			mismatches = append(mismatches, err.Error())
+			continue
+		}
+
+		method := entry.method
+
+		if method == "" {
+			var ok bool
+
+			if method, ok = manifestMethods[len(entry.digest)]; !ok {
+				return fmt.Errorf("unknown checksum format for %s", path.Base(file))
+			}
+		}
+
+		if allowed != nil && !allowed[method] {
+			return fmt.Errorf("%s checksum of %s is not allowed", method, path.Base(file))
+		}
+
+		handle, err := os.Open(file)
+
+		if err != nil {
+			return fmt.Errorf("failed to read %s artifact: %w", file, err)
+		}
+
+		actual, err := checksum(handle, method)
+		handle.Close()
+
+		if err != nil {
+			return err
+		}
+
+		if actual != entry.digest {
+			mismatches = append(mismatches, fmt.Sprintf("%s has %s %s, expected %s", path.Base(file), method, actual, entry.digest))
+		}
+	}
+
+	if len(mismatches) > 0 {
+		return fmt.Errorf("checksum verification failed: %s", strings.Join(mismatches, "; "))
+	}
+
+	return nil
+}
+
+// readFileLists replaces "@file" entries with the paths or globs listed in
+// the file, one per line, ignoring empty lines and "#" comments.
+func readFileLists(entries []string) ([]string, error) {
+	var result []string
+
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry, "@") {
+			result = append(result, entry)
+			continue
+		}
+
+		content, err := ioutil.ReadFile(entry[1:])
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file list %s: %w", entry[1:], err)
+		}
+
+		for _, line := range strings.Split(string(content), "\n") {
+			line = strings.TrimSpace(line)
+
+			if line != "" && !strings.HasPrefix(line, "#") {
+				result = append(result, line)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// expandFiles expands the glob patterns, keeping the order of the patterns
+// and dropping files matched by more than one of them. Matched directories
+// are walked up to the depth, with 0 walking them completely. Patterns
+// starting with "!" drop the files matched so far, like in .gitignore.
+func expandFiles(out io.Writer, log *logrus.Logger, globs []string, depth int) ([]string, error) {
+	var files []string
+	seen := make(map[string]bool)
+
+	for _, glob := range globs {
+		if strings.HasPrefix(glob, "!") {
+			var kept []string
+
+			for _, file := range files {
+				matched, err := matchFile(glob[1:], file)
+
+				if err != nil {
+					return nil, fmt.Errorf("invalid pattern %s: %w", glob, err)
+				}
+
+				if matched {
+					log.Debugf("Dropping %s matched by %s", file, glob)
+					delete(seen, filepath.Clean(file))
+					continue
+				}
+
+				kept = append(kept, file)
+			}
+
+			files = kept
+			continue
+		}
+
+		globed, err := globFiles(glob)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to glob %s: %w", glob, err)
+		}
+
+		if len(globed) == 0 {
+			fmt.Fprintf(out, "No files match %s\n", glob)
+		}
+
+		for _, match := range globed {
+			walked, err := walkFiles(match, depth)
+
+			if err != nil {
+				return nil, fmt.Errorf("failed to walk %s: %w", match, err)
+			}
+
+			for _, file := range walked {
+				if seen[filepath.Clean(file)] {
+					log.Debugf("Skipping %s matched by %s, it is already added", file, glob)
+					continue
+				}
+
+				log.Debugf("Adding %s matched by %s", file, glob)
+				seen[filepath.Clean(file)] = true
+				files = append(files, file)
+			}
+		}
+	}
+
+	return files, nil
+}
+
+// globFiles extends filepath.Glob by "**" segments matching any number of
+// directories, walking from the longest prefix without pattern.
+func globFiles(pattern string) ([]string, error) {
+	if !strings.Contains(pattern, "**") {
+		return filepath.Glob(pattern)
+	}
+
+	parts := strings.Split(filepath.ToSlash(pattern), "/")
+
+	for _, part := range parts {
+		if _, err := path.Match(part, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	i := 0
+	for i < len(parts) && !strings.ContainsAny(parts[i], `*?[\`) {
+		i++
+	}
+
+	root := strings.Join(parts[:i], "/")
+
+	switch {
+	case i == 1 && parts[0] == "":
+		root = "/"
+	case root == "":
+		root = "."
+	}
+
+	root = filepath.FromSlash(root)
+
+	if _, err := os.Stat(root); err != nil {
+		return nil, nil
+	}
+
+	var matches []string
+
+	err := filepath.WalkDir(root, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, _ := filepath.Rel(root, file)
+
+		if rel != "." && matchSegments(parts[i:], strings.Split(filepath.ToSlash(rel), "/")) {
+			matches = append(matches, file)
+		}
+
+		return nil
+	})
+
+	return matches, err
+}
+
+// matchFile reports whether the pattern matches the path or the base name
+// of the file, supporting "**" segments.
+func matchFile(pattern, file string) (bool, error) {
+	if strings.Contains(pattern, "**") {
+		for _, part := range strings.Split(filepath.ToSlash(pattern), "/") {
+			if _, err := path.Match(part, ""); err != nil {
+				return false, err
+			}
+		}
+
+		return matchSegments(strings.Split(path.Clean(filepath.ToSlash(pattern)), "/"), strings.Split(path.Clean(filepath.ToSlash(file)), "/")), nil
+	}
+
+	matchPath, err := filepath.Match(filepath.Clean(pattern), filepath.Clean(file))
+
+	if err != nil {
+		return false, err
+	}
+
+	matchBase, _ := filepath.Match(pattern, filepath.Base(file))
+	return matchPath || matchBase, nil
+}
+
+// matchSegments matches the path segments against the pattern segments,
+// where "**" matches zero or more segments.
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for k := 0; k <= len(name); k++ {
+				if matchSegments(pattern[1:], name[k:]) {
+					return true
+				}
+			}
+
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+
+		pattern, name = pattern[1:], name[1:]
+	}
+
+	return len(name) == 0
+}
+
+// excludeFiles drops the files whose path or base name matches a pattern.
+func excludeFiles(out io.Writer, files, patterns []string) ([]string, error) {
+	var result []string
+
+	for _, file := range files {
+		excluded := false
+
+		for _, pattern := range patterns {
+			matched, err := matchFile(pattern, file)
+
+			if err != nil {
+				return nil, fmt.Errorf("invalid exclude pattern %s: %w", pattern, err)
+			}
+
+			if matched {
+				excluded = true
+				break
+			}
+		}
+
+		if excluded {
+			fmt.Fprintf(out, "Excluding %s\n", file)
+			continue
+		}
+
+		result = append(result, file)
+	}
+
+	return result, nil
+}
+
+// walkFiles returns the regular files within the directory up to the depth,
+// or the path itself if it isn't a directory.
+func walkFiles(root string, depth int) ([]string, error) {
+	info, err := os.Stat(root)
+
+	if err != nil || !info.IsDir() {
+		return []string{root}, nil
+	}
+
+	var files []string
+
+	err = filepath.WalkDir(root, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, _ := filepath.Rel(root, file)
+		level := len(strings.Split(rel, string(filepath.Separator)))
+
+		if entry.IsDir() {
+			if file != root && depth > 0 && level >= depth {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if entry.Type().IsRegular() && (depth == 0 || level <= depth) {
+			files = append(files, file)
+		}
+
+		return nil
+	})
+
+	return files, err
+}
+
+func writeChecksums(files, methods []string, format, style string, flatten bool) ([]string, error) {
 	checksums := make(map[string][]string)
 
 	for _, method := range methods {
@@ -95,6 +561,7 @@ func writeChecksums(files, methods []string, format string, flatten bool) ([]str
 
 	for method, results := range checksums {
 		filename := strings.Replace(format, "CHECKSUM", method, -1)
+		filename = strings.Replace(filename, "{{method}}", method, -1)
 		f, err := os.Create(filename)
 
 		if err != nil {
@@ -109,7 +576,7 @@ func writeChecksums(files, methods []string, format string, flatten bool) ([]str
 				file = filepath.Base(file)
 			}
 
-			if _, err := f.WriteString(fmt.Sprintf("%s  %s\n", hash, file)); err != nil {
+			if _, err := f.WriteString(checksumLine(style, method, hash, file)); err != nil {
 				return nil, err
 			}
 		}
@@ -119,3 +586,160 @@ func writeChecksums(files, methods []string, format string, flatten bool) ([]str
 
 	return files, nil
 }
+
+// checksumLine formats a checksum like GNU coreutils "<hash>  <file>" or
+// like BSD "SHA256 (<file>) = <hash>".
+func checksumLine(style, method, hash, file string) string {
+	if style == "bsd" {
+		name := strings.ToUpper(method)
+
+		if method == "blake2b" {
+			name = "BLAKE2b"
+		}
+
+		return fmt.Sprintf("%s (%s) = %s\n", name, file, hash)
+	}
+
+	return fmt.Sprintf("%s  %s\n", hash, file)
+}
+
+// writeSidecars writes a <name>.sha256 file for every file into the
+// directory, in the GNU or BSD checksum format.
+func writeSidecars(files []string, dir, style string) ([]string, error) {
+	var sidecars []string
+
+	for _, file := range files {
+		handle, err := os.Open(file)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s artifact: %w", file, err)
+		}
+
+		hash, err := checksum(handle, "sha256")
+		handle.Close()
+
+		if err != nil {
+			return nil, err
+		}
+
+		sidecar := filepath.Join(dir, filepath.Base(file)+".sha256")
+
+		if err := ioutil.WriteFile(sidecar, []byte(checksumLine(style, "sha256", hash, filepath.Base(file))), 0644); err != nil {
+			return nil, err
+		}
+
+		sidecars = append(sidecars, sidecar)
+	}
+
+	return sidecars, nil
+}
+
+// writeJSON serializes v as indented JSON to the given file, or to out if no
+// file is set.
+func writeJSON(out io.Writer, file string, v interface{}) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	b = append(b, '\n')
+
+	if file == "" {
+		_, err = out.Write(b)
+		return err
+	}
+
+	return ioutil.WriteFile(file, b, 0644)
+}
+
+// appendEnvFile appends the variables as KEY=value lines, so later steps
+// can source the file. Values with characters special to the shell are
+// single quoted.
+func appendEnvFile(file string, vars [][2]string) error {
+	handle, err := os.OpenFile(file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+
+	if err != nil {
+		return err
+	}
+
+	for _, v := range vars {
+		if _, err := fmt.Fprintf(handle, "%s=%s\n", v[0], shellQuote(v[1])); err != nil {
+			handle.Close()
+			return err
+		}
+	}
+
+	return handle.Close()
+}
+
+// shellQuote quotes the value for a posix shell unless it only consists of
+// characters without special meaning. Single quotes within the value end the
+// quoting, get escaped and start it again.
+func shellQuote(value string) string {
+	if shellSafe.MatchString(value) {
+		return value
+	}
+
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// dedupFiles drops files with identical content, keeping the first one. The
+// dropped files are returned as aliases of the kept file.
+func dedupFiles(files []string) ([]string, map[string][]string, error) {
+	var unique []string
+	aliases := make(map[string][]string)
+	hashes := make(map[string]string)
+
+	for _, file := range files {
+		handle, err := os.Open(file)
+
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read %s artifact: %w", file, err)
+		}
+
+		hash, err := checksum(handle, "sha256")
+		handle.Close()
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if original, ok := hashes[hash]; ok {
+			aliases[original] = append(aliases[original], file)
+			continue
+		}
+
+		hashes[hash] = file
+		unique = append(unique, file)
+	}
+
+	return unique, aliases, nil
+}
+
+// aliasNotes documents the files which were not uploaded because of
+// identical content.
+func aliasNotes(files []string, aliases map[string][]string) string {
+	var lines []string
+
+	for _, file := range files {
+		for _, alias := range aliases[file] {
+			lines = append(lines, fmt.Sprintf("- `%s` is identical to `%s`", filepath.Base(alias), filepath.Base(file)))
+		}
+	}
+
+	if len(lines) == 0 {
+		return ""
+	}
+
+	return "### Aliases\n\n" + strings.Join(lines, "\n")
+}
+
+// renderName replaces the {{key}} placeholders within a file name.
+func renderName(format string, values map[string]string) string {
+	for key, value := range values {
+		format = strings.Replace(format, "{{"+key+"}}", value, -1)
+	}
+
+	return format
+}
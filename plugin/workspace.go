@@ -0,0 +1,62 @@
+// Copyright (c) 2020, the Drone Plugins project authors.
+// Please see the AUTHORS file for details. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be
+// found in the LICENSE file.
+
+package plugin
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// prepareWorkspace changes into the working directory and creates the run
+// directory for temporary files like bundles and downloads.
+func (p *Plugin) prepareWorkspace() error {
+	if p.settings.WorkDir != "" {
+		if err := os.Chdir(p.settings.WorkDir); err != nil {
+			return fmt.Errorf("failed to change into workdir: %w", err)
+		}
+	}
+
+	if p.settings.TempDir != "" {
+		if err := os.MkdirAll(p.settings.TempDir, 0755); err != nil {
+			return fmt.Errorf("failed to create temp dir: %w", err)
+		}
+	}
+
+	dir, err := ioutil.TempDir(p.settings.TempDir, "drone-github-release-")
+
+	if err != nil {
+		return fmt.Errorf("failed to create run directory: %w", err)
+	}
+
+	p.settings.runDir = dir
+	return nil
+}
+
+// runSubDir creates a directory within the run directory.
+func (p *Plugin) runSubDir(name string) (string, error) {
+	dir := filepath.Join(p.settings.runDir, name)
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	return dir, nil
+}
+
+// cleanup removes the run directory unless it should be kept for debugging.
+func (p *Plugin) cleanup() {
+	if p.settings.runDir == "" || p.settings.KeepTempDir {
+		return
+	}
+
+	if err := os.RemoveAll(p.settings.runDir); err != nil {
+		fmt.Fprintf(p.out(), "Warning: failed to remove run directory %s: %s\n", p.settings.runDir, err)
+	}
+
+	p.settings.runDir = ""
+}